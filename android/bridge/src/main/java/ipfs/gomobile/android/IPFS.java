@@ -376,7 +376,7 @@ public class IPFS {
     */
     synchronized public String serveGatewayMultiaddr(@NonNull String multiaddr, @NonNull Boolean writable) throws NodeListenException { // 线程安全方法
         try {
-            return node.serveGatewayMultiaddr(multiaddr, writable); // 在指定多地址上提供网关服务
+            return node.serveGatewayMultiaddr(multiaddr, writable).multiaddr(); // 在指定多地址上提供网关服务
         } catch (Exception e) {
             throw new NodeListenException("failed to listen on gateway", e);
         }
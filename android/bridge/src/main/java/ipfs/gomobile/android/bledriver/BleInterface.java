@@ -42,6 +42,12 @@ public class BleInterface implements ProximityDriver {
         }
     }
 
+    public static void BLESetPeerMTU(String remotePID, int mtu) {
+        if (mTransport != null) {
+            mTransport.setPeerMTU(remotePID, mtu);
+        }
+    }
+
     public static void BLELog(Logger.Level level, String message) {
         if (mTransport != null) {
             mTransport.log(level.getValue(), message);
@@ -1284,6 +1284,11 @@ public class PeerDevice {
 
     public void setMtu(int mtu) {
         mMtu = mtu;
+
+        String remotePID = getRemotePID();
+        if (remotePID != null) {
+            BleInterface.BLESetPeerMTU(remotePID, mtu);
+        }
     }
 
     public void flushServerDataCache() {
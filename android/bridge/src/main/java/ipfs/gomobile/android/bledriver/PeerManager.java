@@ -32,6 +32,7 @@ public class PeerManager {
             mLogger.e(TAG, String.format("registerDevice: device=%s peerID=%s: HandleFoundPeer failed", mLogger.sensitiveObject(peerDevice.getMACAddress()), mLogger.sensitiveObject(peerID)));
             return null;
         }
+        BleInterface.BLESetPeerMTU(peerID, peerDevice.getMtu());
 
         Peer peer = getPeer(peerID);
         if (isClient) {
@@ -0,0 +1,347 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+	ipfs_chunker "github.com/ipfs/go-ipfs-chunker"
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+	ipld "github.com/ipfs/go-ipld-format"
+	ipfs_merkledag "github.com/ipfs/go-merkledag"
+	ipfs_balanced "github.com/ipfs/go-unixfs/importer/balanced"
+	ipfs_ihelper "github.com/ipfs/go-unixfs/importer/helpers"
+	ipfs_trickle "github.com/ipfs/go-unixfs/importer/trickle"
+	ipfs_options "github.com/ipfs/interface-go-ipfs-core/options"
+	ipfs_path "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// AddOptions are the JSON encoded options accepted by the add methods, a
+// missing field keeps its default value.
+type AddOptions struct {
+	// Pin recursively pins the added content, default true.
+	Pin bool
+	// CidVersion is the cid version of the created DAG, default 0.
+	CidVersion int
+	// RawLeaves stores the file data in raw blocks, default false
+	// (forced to true with cid version 1).
+	RawLeaves bool
+	// Chunker is the chunking algorithm, e.g. `size-262144` (default) or
+	// `rabin`.
+	Chunker string
+	// Hidden includes the hidden files of directories, default false.
+	Hidden bool
+	// Layout is the layout of the file DAGs: `balanced` (default) suits
+	// random access, `trickle` suits streaming media read sequentially or
+	// appended to.
+	Layout string
+	// MaxLinks is the maximum number of links of a node of the file DAGs,
+	// default 174. It is only supported when adding a single file.
+	MaxLinks int
+}
+
+// defaultMaxLinks is the maximum number of links of a node of the file DAGs
+// built by the unixfs importer.
+const defaultMaxLinks = 174
+
+// File DAG layouts accepted by AddOptions.Layout
+const (
+	AddLayoutBalanced = "balanced"
+	AddLayoutTrickle  = "trickle"
+)
+
+// AddResult is returned by AddFileDetailed.
+type AddResult struct {
+	// Cid is the cid of the root of the added DAG.
+	Cid string
+	// Size is the total size in bytes of the blocks of the DAG.
+	Size int64
+	// Blocks is the number of blocks of the DAG.
+	Blocks int64
+	// Files is the number of regular files added, 1 for a single file.
+	Files int64
+}
+
+func defaultAddOptions() AddOptions {
+	return AddOptions{
+		Pin:     true,
+		Chunker: "size-262144",
+	}
+}
+
+func parseAddOptions(opts string) (*AddOptions, error) {
+	options := defaultAddOptions()
+
+	if opts != "" {
+		if err := json.Unmarshal([]byte(opts), &options); err != nil {
+			return nil, fmt.Errorf("invalid add options: %w", err)
+		}
+	}
+
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	return &options, nil
+}
+
+func (o *AddOptions) validate() error {
+	switch o.Layout {
+	case "", AddLayoutBalanced, AddLayoutTrickle:
+	default:
+		return fmt.Errorf("invalid layout `%s`, expected `%s` or `%s`", o.Layout, AddLayoutBalanced, AddLayoutTrickle)
+	}
+
+	if o.MaxLinks != 0 && o.MaxLinks < 2 {
+		return fmt.Errorf("invalid max links %d, expected at least 2", o.MaxLinks)
+	}
+
+	return nil
+}
+
+func (o *AddOptions) unixfsOptions() []ipfs_options.UnixfsAddOption {
+	opts := []ipfs_options.UnixfsAddOption{
+		ipfs_options.Unixfs.Pin(o.Pin),
+		ipfs_options.Unixfs.CidVersion(o.CidVersion),
+		ipfs_options.Unixfs.Chunker(o.Chunker),
+	}
+
+	if o.RawLeaves {
+		opts = append(opts, ipfs_options.Unixfs.RawLeaves(true))
+	}
+
+	if o.Layout == AddLayoutTrickle {
+		opts = append(opts, ipfs_options.Unixfs.Layout(ipfs_options.TrickleLayout))
+	}
+
+	return opts
+}
+
+// addFile adds f as a single file DAG built with the options, on behalf of
+// the unixfs API whose importer always uses the default maximum number of
+// links, and returns its root.
+func (n *Node) addFile(ctx context.Context, f ipfs_files.File, o *AddOptions) (ipfs_cid.Cid, error) {
+	settings, prefix, err := ipfs_options.UnixfsAddOptions(o.unixfsOptions()...)
+	if err != nil {
+		return ipfs_cid.Undef, err
+	}
+
+	chunker, err := ipfs_chunker.FromString(f, settings.Chunker)
+	if err != nil {
+		return ipfs_cid.Undef, err
+	}
+
+	// the blocks can't be garbage collected before they are pinned
+	ipfs := n.ipfsMobile.IpfsNode
+	defer ipfs.Blockstore.PinLock(ctx).Unlock(ctx)
+
+	dag := ipld.NewBufferedDAG(ctx, ipfs.DAG)
+	params := ipfs_ihelper.DagBuilderParams{
+		Dagserv:    dag,
+		RawLeaves:  settings.RawLeaves,
+		Maxlinks:   defaultMaxLinks,
+		CidBuilder: prefix,
+	}
+	if o.MaxLinks != 0 {
+		params.Maxlinks = o.MaxLinks
+	}
+
+	db, err := params.New(chunker)
+	if err != nil {
+		return ipfs_cid.Undef, err
+	}
+
+	var nd ipld.Node
+	if settings.Layout == ipfs_options.TrickleLayout {
+		nd, err = ipfs_trickle.Layout(db)
+	} else {
+		nd, err = ipfs_balanced.Layout(db)
+	}
+	if err != nil {
+		return ipfs_cid.Undef, err
+	}
+	if err := dag.Commit(); err != nil {
+		return ipfs_cid.Undef, err
+	}
+
+	if settings.Pin {
+		if err := ipfs.Pinning.Pin(ctx, nd, true); err != nil {
+			return ipfs_cid.Undef, err
+		}
+		if err := ipfs.Pinning.Flush(ctx); err != nil {
+			return ipfs_cid.Undef, err
+		}
+	}
+
+	if err := ipfs.Provider.Provide(nd.Cid()); err != nil {
+		return ipfs_cid.Undef, err
+	}
+	return nd.Cid(), nil
+}
+
+// AddFileDetailed adds the file or directory at the given path with the JSON
+// encoded AddOptions, an empty string uses the defaults. It returns a JSON
+// encoded AddResult.
+func (n *Node) AddFileDetailed(path string, opts string) (string, error) {
+	options, err := parseAddOptions(opts)
+	if err != nil {
+		return "", err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	var files int64
+	if stat.IsDir() {
+		if options.MaxLinks != 0 {
+			return "", errors.New("MaxLinks is only supported when adding a single file")
+		}
+		if files, err = countFiles(path, options.Hidden); err != nil {
+			return "", err
+		}
+	} else {
+		files = 1
+	}
+
+	fnode, err := ipfs_files.NewSerialFile(path, options.Hidden, stat)
+	if err != nil {
+		return "", err
+	}
+	defer fnode.Close()
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return "", err
+	}
+
+	var root ipfs_cid.Cid
+	if f, ok := fnode.(ipfs_files.File); ok && options.MaxLinks != 0 {
+		root, err = n.addFile(n.ctx, f, options)
+	} else {
+		var resolved ipfs_path.Resolved
+		if resolved, err = api.Unixfs().Add(n.ctx, fnode, options.unixfsOptions()...); err == nil {
+			root = resolved.Cid()
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to add `%s`: %w", path, err)
+	}
+
+	blocks, size, err := n.dagStat(n.ctx, root)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := json.Marshal(&AddResult{
+		Cid:    root.String(),
+		Size:   size,
+		Blocks: blocks,
+		Files:  files,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// AddFile adds the file or directory at the given path with the default
+// AddOptions, recursively pinning it when pin is set, and returns the cid of
+// its root.
+func (n *Node) AddFile(path string, pin bool) (string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	fnode, err := ipfs_files.NewSerialFile(path, false, stat)
+	if err != nil {
+		return "", err
+	}
+	defer fnode.Close()
+
+	c, err := n.add(fnode, pin)
+	if err != nil {
+		return "", fmt.Errorf("unable to add `%s`: %w", path, err)
+	}
+	return c, nil
+}
+
+// AddBytes adds data as a file with the default AddOptions, recursively
+// pinning it when pin is set, and returns its cid.
+func (n *Node) AddBytes(data []byte, pin bool) (string, error) {
+	c, err := n.add(ipfs_files.NewBytesFile(data), pin)
+	if err != nil {
+		return "", fmt.Errorf("unable to add content: %w", err)
+	}
+	return c, nil
+}
+
+// add adds fnode with the default AddOptions and returns the cid of its root.
+func (n *Node) add(fnode ipfs_files.Node, pin bool) (string, error) {
+	options := defaultAddOptions()
+	options.Pin = pin
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := api.Unixfs().Add(n.ctx, fnode, options.unixfsOptions()...)
+	if err != nil {
+		return "", err
+	}
+	return resolved.Cid().String(), nil
+}
+
+// dagStat returns the number of blocks and the total size of the DAG under
+// root, every block is expected to be available locally.
+func (n *Node) dagStat(ctx context.Context, root ipfs_cid.Cid) (blocks int64, size int64, err error) {
+	dag := n.ipfsMobile.IpfsNode.DAG
+
+	getLinks := func(ctx context.Context, c ipfs_cid.Cid) ([]*ipld.Link, error) {
+		nd, err := dag.Get(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+
+		blocks++
+		size += int64(len(nd.RawData()))
+		return nd.Links(), nil
+	}
+
+	visited := ipfs_cid.NewSet()
+	if err := ipfs_merkledag.Walk(ctx, getLinks, root, visited.Visit); err != nil {
+		return 0, 0, fmt.Errorf("unable to walk `%s`: %w", root, err)
+	}
+
+	return blocks, size, nil
+}
+
+// countFiles returns the number of regular files under dir.
+func countFiles(dir string, hidden bool) (int64, error) {
+	var count int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !hidden && path != dir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.Mode().IsRegular() {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
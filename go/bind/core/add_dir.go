@@ -0,0 +1,302 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+)
+
+// AddDirectoryOptions are the JSON encoded options accepted by AddDirectory,
+// on top of the AddOptions.
+type AddDirectoryOptions struct {
+	AddOptions
+
+	// Exclude are .gitignore-style patterns matched against the name of each
+	// file and directory, e.g. `*.tmp` or `cache`, an excluded directory is
+	// skipped with all its content.
+	Exclude []string
+	// FollowSymlinks adds the target of the symbolic links instead of the
+	// links themselves, default false.
+	FollowSymlinks bool
+}
+
+// AddDirectoryHandler receives the progress of AddDirectoryWithProgress, it
+// is called from the goroutine of the request.
+type AddDirectoryHandler interface {
+	OnProgress(files int64, bytes int64)
+	OnComplete(cid string)
+	OnError(message string)
+}
+
+func parseAddDirectoryOptions(opts string) (*AddDirectoryOptions, error) {
+	options := &AddDirectoryOptions{AddOptions: defaultAddOptions()}
+
+	if opts != "" {
+		if err := json.Unmarshal([]byte(opts), options); err != nil {
+			return nil, fmt.Errorf("invalid add options: %w", err)
+		}
+	}
+
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	if options.MaxLinks != 0 {
+		return nil, errors.New("MaxLinks is only supported when adding a single file")
+	}
+	return options, nil
+}
+
+// AddDirectory recursively adds the directory at the given path as a single
+// DAG with the JSON encoded AddDirectoryOptions, an empty string uses the
+// defaults, and returns the cid of its root. The DAG is pinned unless the Pin
+// option is false.
+func (n *Node) AddDirectory(path string, opts string) (string, error) {
+	options, err := parseAddDirectoryOptions(opts)
+	if err != nil {
+		return "", err
+	}
+
+	return n.addDirectory(n.ctx, path, options, &dirWalker{})
+}
+
+// AddDirectoryWithProgress is the same as AddDirectory but runs in the
+// background, files are streamed to the DAG as they are read. It returns a
+// handle that can be passed to CancelRequest, the progress and the cid of the
+// root are reported to handler.
+func (n *Node) AddDirectoryWithProgress(path string, opts string, handler AddDirectoryHandler) (int64, error) {
+	options, err := parseAddDirectoryOptions(opts)
+	if err != nil {
+		return 0, err
+	}
+
+	id, ctx, done := n.newRequest()
+	go func() {
+		defer done()
+
+		w := &dirWalker{}
+		report := func() {
+			handler.OnProgress(atomic.LoadInt64(&w.files), atomic.LoadInt64(&w.bytes))
+		}
+
+		addDone := make(chan struct{})
+		reporterDone := make(chan struct{})
+		go func() {
+			defer close(reporterDone)
+
+			ticker := time.NewTicker(progressReportInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-addDone:
+					return
+				case <-ticker.C:
+					report()
+				}
+			}
+		}()
+
+		root, err := n.addDirectory(ctx, path, options, w)
+		close(addDone)
+		<-reporterDone
+		if err != nil {
+			handler.OnError(err.Error())
+			return
+		}
+
+		report()
+		handler.OnComplete(root)
+	}()
+
+	return id, nil
+}
+
+func (n *Node) addDirectory(ctx context.Context, path string, options *AddDirectoryOptions, w *dirWalker) (string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !stat.IsDir() {
+		return "", fmt.Errorf("`%s` is not a directory", path)
+	}
+
+	filter, err := ipfs_files.NewFilter("", options.Exclude, options.Hidden)
+	if err != nil {
+		return "", fmt.Errorf("invalid exclude patterns: %w", err)
+	}
+
+	w.ctx = ctx
+	w.filter = filter
+	w.followSymlinks = options.FollowSymlinks
+
+	root, err := w.node(path, stat, nil)
+	if err != nil {
+		return "", err
+	}
+	defer root.Close()
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := api.Unixfs().Add(ctx, root, options.unixfsOptions()...)
+	if err != nil {
+		return "", fmt.Errorf("unable to add `%s`: %w", path, err)
+	}
+
+	return resolved.Cid().String(), nil
+}
+
+// dirWalker builds the files tree of a directory lazily, applying the exclude
+// patterns and the symlinks policy, and counts the files and bytes read.
+type dirWalker struct {
+	ctx            context.Context
+	filter         *ipfs_files.Filter
+	followSymlinks bool
+
+	files int64
+	bytes int64
+}
+
+// node returns the node of the file at path, parents are the resolved paths
+// of the directories above it, used to detect symlink loops.
+func (w *dirWalker) node(path string, stat os.FileInfo, parents []string) (ipfs_files.Node, error) {
+	if stat.Mode()&os.ModeSymlink != 0 {
+		if !w.followSymlinks {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return nil, err
+			}
+			return ipfs_files.NewLinkFile(target, stat), nil
+		}
+
+		var err error
+		if stat, err = os.Stat(path); err != nil {
+			return nil, fmt.Errorf("unable to follow symlink `%s`: %w", path, err)
+		}
+	}
+
+	switch mode := stat.Mode(); {
+	case mode.IsRegular():
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return ipfs_files.NewReaderPathFile(path, &walkedFile{File: f, w: w}, stat)
+
+	case mode.IsDir():
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			if parent == resolved {
+				return nil, fmt.Errorf("symlink loop at `%s`", path)
+			}
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return &walkedDir{
+			w:       w,
+			path:    path,
+			entries: entries,
+			parents: append(parents[:len(parents):len(parents)], resolved),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported file type for `%s`: %s", path, mode)
+	}
+}
+
+// walkedFile counts the bytes read from a file and stops the add once the
+// request is canceled.
+type walkedFile struct {
+	*os.File
+	w   *dirWalker
+	eof bool
+}
+
+func (f *walkedFile) Read(p []byte) (int, error) {
+	if err := f.w.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err := f.File.Read(p)
+	atomic.AddInt64(&f.w.bytes, int64(n))
+	if err != nil && !f.eof {
+		f.eof = true
+		atomic.AddInt64(&f.w.files, 1)
+	}
+	return n, err
+}
+
+type walkedDir struct {
+	w       *dirWalker
+	path    string
+	entries []os.DirEntry
+	parents []string
+}
+
+func (d *walkedDir) Close() error { return nil }
+
+func (d *walkedDir) Size() (int64, error) { return 0, ipfs_files.ErrNotSupported }
+
+func (d *walkedDir) Entries() ipfs_files.DirIterator {
+	return &walkedDirIterator{dir: d, entries: d.entries}
+}
+
+type walkedDirIterator struct {
+	dir     *walkedDir
+	entries []os.DirEntry
+
+	name string
+	node ipfs_files.Node
+	err  error
+}
+
+func (it *walkedDirIterator) Name() string          { return it.name }
+func (it *walkedDirIterator) Node() ipfs_files.Node { return it.node }
+func (it *walkedDirIterator) Err() error            { return it.err }
+
+func (it *walkedDirIterator) Next() bool {
+	w := it.dir.w
+	for len(it.entries) > 0 {
+		entry := it.entries[0]
+		it.entries = it.entries[1:]
+
+		stat, err := entry.Info()
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		if w.filter.ShouldExclude(stat) {
+			continue
+		}
+
+		nd, err := w.node(filepath.Join(it.dir.path, entry.Name()), stat, it.dir.parents)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.name = entry.Name()
+		it.node = nd
+		return true
+	}
+	return false
+}
+
+var _ ipfs_files.Directory = (*walkedDir)(nil)
@@ -0,0 +1,368 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+)
+
+func TestNodeAddFileDetailed(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	dir := filepath.Join(path, "upload")
+	for name, content := range map[string]string{
+		"a.txt":       "content a",
+		"sub/b.txt":   "content b",
+		".hidden.txt": "hidden content",
+	} {
+		fpath := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fpath), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fpath, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := node.AddFileDetailed(dir, `{"CidVersion": 1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res AddResult
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Files != 2 {
+		t.Errorf("expected 2 files got %d", res.Files)
+	}
+
+	// root, sub directory and the two files
+	if res.Blocks != 4 {
+		t.Errorf("expected 4 blocks got %d", res.Blocks)
+	}
+
+	if res.Size == 0 {
+		t.Error("size should be greater than 0")
+	}
+
+	status, err := node.IsPinned(res.Cid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != PinStatusRecursive {
+		t.Errorf("expected pin status `%s` got `%s`", PinStatusRecursive, status)
+	}
+
+	if _, err := node.AddFileDetailed(dir, "not json"); err == nil {
+		t.Error("invalid options should fail")
+	}
+}
+
+func TestNodeAddBytes(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	content := []byte("hello from the bind layer")
+
+	bcid, err := node.AddBytes(content, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fpath := filepath.Join(path, "hello.txt")
+	if err := os.WriteFile(fpath, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fcid, err := node.AddFile(fpath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fcid != bcid {
+		t.Errorf("expected the same cid for the file and the bytes, got `%s` and `%s`", fcid, bcid)
+	}
+
+	status, err := node.IsPinned(bcid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != PinStatusRecursive {
+		t.Errorf("expected pin status `%s` got `%s`", PinStatusRecursive, status)
+	}
+
+	other, err := node.AddBytes([]byte("not pinned"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err = node.IsPinned(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != PinStatusNotPinned {
+		t.Errorf("expected pin status `%s` got `%s`", PinStatusNotPinned, status)
+	}
+
+	if _, err := node.AddFile(filepath.Join(path, "missing"), true); err == nil {
+		t.Error("adding a missing file should fail")
+	}
+}
+
+func TestNodeAddFileLayout(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	// 9 chunks of 256 bytes
+	fpath := filepath.Join(path, "media.bin")
+	if err := os.WriteFile(fpath, make([]byte, 9*256), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	links := func(c string) []int {
+		t.Helper()
+
+		root, err := ipfs_cid.Decode(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nd, err := node.ipfsMobile.IpfsNode.DAG.Get(node.ctx, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// number of links of every child of the root
+		children := []int{}
+		for _, l := range nd.Links() {
+			child, err := node.ipfsMobile.IpfsNode.DAG.Get(node.ctx, l.Cid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			children = append(children, len(child.Links()))
+		}
+		return children
+	}
+
+	add := func(opts string) []int {
+		t.Helper()
+
+		out, err := node.AddFileDetailed(fpath, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var res AddResult
+		if err := json.Unmarshal([]byte(out), &res); err != nil {
+			t.Fatal(err)
+		}
+
+		// the DAG is pinned as with the unixfs API
+		status, err := node.IsPinned(res.Cid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != PinStatusRecursive {
+			t.Errorf("expected pin status `%s` got `%s`", PinStatusRecursive, status)
+		}
+		return links(res.Cid)
+	}
+
+	// a balanced DAG is a full tree
+	if children := add(`{"Chunker": "size-256", "MaxLinks": 3}`); !reflect.DeepEqual(children, []int{3, 3, 3}) {
+		t.Errorf("unexpected balanced layout `%v`", children)
+	}
+
+	// a trickle DAG starts with leaves directly under the root, followed by
+	// subtrees of increasing depth
+	children := add(`{"Chunker": "size-256", "MaxLinks": 3, "Layout": "trickle"}`)
+	if len(children) <= 3 || !reflect.DeepEqual(children[:3], []int{0, 0, 0}) {
+		t.Errorf("unexpected trickle layout `%v`", children)
+	}
+
+	for _, opts := range []string{`{"Layout": "sideways"}`, `{"MaxLinks": 1}`} {
+		if _, err := node.AddFileDetailed(fpath, opts); err == nil {
+			t.Errorf("expected options `%s` to be refused", opts)
+		}
+	}
+
+	// the maximum number of links is only supported for single files
+	if _, err := node.AddFileDetailed(path, `{"MaxLinks": 3}`); err == nil {
+		t.Error("expected MaxLinks to be refused for a directory")
+	}
+	if _, err := node.AddDirectory(path, `{"MaxLinks": 3}`); err == nil {
+		t.Error("expected MaxLinks to be refused by AddDirectory")
+	}
+}
+
+func TestNodeAddDirectory(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	writeTree := func(dir string, files map[string]string) {
+		t.Helper()
+
+		for name, content := range files {
+			fpath := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(fpath), 0o700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(fpath, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	addExpected := func(files map[string]string) string {
+		t.Helper()
+
+		dir, err := os.MkdirTemp(path, "expected")
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTree(dir, files)
+
+		out, err := node.AddFileDetailed(dir, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var res AddResult
+		if err := json.Unmarshal([]byte(out), &res); err != nil {
+			t.Fatal(err)
+		}
+		return res.Cid
+	}
+
+	dir := filepath.Join(path, "backup")
+	writeTree(dir, map[string]string{
+		"a.txt":           "content a",
+		"sub/b.txt":       "content b",
+		"sub/c.tmp":       "temporary",
+		"cache/thumb.jpg": "thumbnail",
+	})
+	writeTree(filepath.Join(path, "outside"), map[string]string{
+		"d.txt": "content d",
+	})
+	if err := os.Symlink(filepath.Join(path, "outside"), filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("exclude", func(t *testing.T) {
+		root, err := node.AddDirectory(dir, `{"Exclude": ["*.tmp", "cache", "link"]}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := addExpected(map[string]string{
+			"a.txt":     "content a",
+			"sub/b.txt": "content b",
+		})
+		if root != expected {
+			t.Errorf("expected root `%s` got `%s`", expected, root)
+		}
+
+		status, err := node.IsPinned(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != PinStatusRecursive {
+			t.Errorf("expected pin status `%s` got `%s`", PinStatusRecursive, status)
+		}
+	})
+
+	t.Run("follow symlinks", func(t *testing.T) {
+		root, err := node.AddDirectory(dir, `{"Exclude": ["*.tmp", "cache"], "FollowSymlinks": true}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := addExpected(map[string]string{
+			"a.txt":      "content a",
+			"sub/b.txt":  "content b",
+			"link/d.txt": "content d",
+		})
+		if root != expected {
+			t.Errorf("expected root `%s` got `%s`", expected, root)
+		}
+	})
+
+	t.Run("symlink loop", func(t *testing.T) {
+		loop := filepath.Join(path, "loop")
+		writeTree(loop, map[string]string{"e.txt": "content e"})
+		if err := os.Symlink(loop, filepath.Join(loop, "self")); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := node.AddDirectory(loop, `{"FollowSymlinks": true}`); err == nil {
+			t.Error("expected a symlink loop to fail")
+		}
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		handler := &testAddDirectoryHandler{done: make(chan struct{})}
+		if _, err := node.AddDirectoryWithProgress(dir, `{"Exclude": ["link"]}`, handler); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-handler.done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for add")
+		}
+
+		if handler.errorMsg != "" {
+			t.Fatal(handler.errorMsg)
+		}
+
+		if handler.files != 4 {
+			t.Errorf("expected 4 files got %d", handler.files)
+		}
+
+		if handler.cid == "" {
+			t.Error("expected the cid of the root")
+		}
+	})
+
+	if _, err := node.AddDirectory(filepath.Join(dir, "a.txt"), ""); err == nil {
+		t.Error("adding a file should fail")
+	}
+}
+
+type testAddDirectoryHandler struct {
+	files    int64
+	cid      string
+	done     chan struct{}
+	errorMsg string
+}
+
+func (h *testAddDirectoryHandler) OnProgress(files int64, _ int64) { h.files = files }
+func (h *testAddDirectoryHandler) OnComplete(cid string) {
+	h.cid = cid
+	close(h.done)
+}
+
+func (h *testAddDirectoryHandler) OnError(message string) {
+	h.errorMsg = message
+	close(h.done)
+}
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"fmt"
+
+	ipfs_config "github.com/ipfs/kubo/config"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// SetAppendAnnounce persists a newline or comma separated list of multiaddrs
+// announced in addition to the automatically detected addresses of the node
+// (`Addresses.AppendAnnounce`). The addresses are used by the host the next
+// time the node is started.
+func (n *Node) SetAppendAnnounce(multiaddrs string) error {
+	addrs := splitList(multiaddrs)
+	announce := make([]string, len(addrs))
+	for i, addr := range addrs {
+		maddr, err := ma.NewMultiaddr(addr)
+		if err != nil {
+			return fmt.Errorf("invalid multiaddr `%s`: %w", addr, err)
+		}
+		announce[i] = maddr.String()
+	}
+
+	return n.ipfsMobile.Repo.ApplyPatchs(func(cfg *ipfs_config.Config) error {
+		cfg.Addresses.AppendAnnounce = announce
+		return nil
+	})
+}
@@ -0,0 +1,32 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestNodeSetAppendAnnounce(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if err := node.SetAppendAnnounce("not a multiaddr"); err == nil {
+		t.Error("setting an invalid multiaddr should fail")
+	}
+
+	addrs := []string{"/dns4/relay.example.com/tcp/4001", "/dns4/relay.example.com/udp/4001/quic"}
+	if err := node.SetAppendAnnounce(addrs[0] + ",\n" + addrs[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := node.ipfsMobile.Repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	announce := cfg.Addresses.AppendAnnounce
+	if len(announce) != 2 || announce[0] != addrs[0] || announce[1] != addrs[1] {
+		t.Errorf("expected AppendAnnounce to be `%v` got `%v`", addrs, announce)
+	}
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	ipfs_core "github.com/ipfs/kubo/core"
+	ipfs_corehttp "github.com/ipfs/kubo/core/corehttp"
+)
+
+// apiAuth holds the bearer token required by the API servers, none by
+// default.
+type apiAuth struct {
+	mu    sync.RWMutex
+	token []byte
+}
+
+func (a *apiAuth) set(token string) {
+	a.mu.Lock()
+	a.token = []byte(token)
+	a.mu.Unlock()
+}
+
+// authorized reports whether r carries the token, or no token is required.
+func (a *apiAuth) authorized(r *http.Request) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if len(a.token) == 0 {
+		return true
+	}
+
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(header[len(prefix):]), a.token) == 1
+}
+
+// serveOption answers 401 Unauthorized to the requests without the token,
+// before they reach the WebUI or the commands.
+func (a *apiAuth) serveOption() ipfs_corehttp.ServeOption {
+	return a.pathServeOption("/")
+}
+
+// commandsServeOption only requires the token on the commands a writable
+// gateway serves under /api/, the content stays public.
+func (a *apiAuth) commandsServeOption() ipfs_corehttp.ServeOption {
+	return a.pathServeOption("/api/")
+}
+
+// pathServeOption answers 401 Unauthorized to the requests under prefix
+// without the token.
+func (a *apiAuth) pathServeOption(prefix string) ipfs_corehttp.ServeOption {
+	return func(_ *ipfs_core.IpfsNode, _ net.Listener, mux *http.ServeMux) (*http.ServeMux, error) {
+		childMux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			guarded := strings.HasPrefix(path.Clean(r.URL.Path)+"/", prefix)
+			if guarded && !a.authorized(r) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="ipfs api"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			childMux.ServeHTTP(w, r)
+		})
+		return childMux, nil
+	}
+}
+
+// SetAPIAuth makes the API servers require an `Authorization: Bearer <token>`
+// header on every request, WebUI included, answering 401 Unauthorized
+// otherwise. The gateways serve the content without the token, the commands
+// of the writable gateways (/api/v0) require it too. It applies to the running API
+// servers right away, an empty token disables the authentication (default).
+// The CORS preflight requests of the origins allowed with SetAPICORS don't
+// need the token, browsers don't send it.
+func (n *Node) SetAPIAuth(token string) {
+	n.apiAuth.set(token)
+}
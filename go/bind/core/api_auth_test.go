@@ -0,0 +1,117 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+func TestNodeSetAPIAuth(t *testing.T) {
+	const token = "s3cr3t"
+
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	smaddr, err := node.ServeTCPAPI("0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	maddr, err := ma.NewMultiaddr(smaddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := manet.ToNetAddr(maddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := fmt.Sprintf("http://%s/api/v0/id", addr)
+
+	client := http.Client{Timeout: 5 * time.Second}
+	expectStatus := func(url, authorization string, status int) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != status {
+			t.Errorf("expected status %d with `%s` got %d", status, authorization, resp.StatusCode)
+		}
+	}
+
+	// no authentication by default
+	expectStatus(url, "", http.StatusOK)
+
+	node.SetAPIAuth(token)
+	expectStatus(url, "", http.StatusUnauthorized)
+	expectStatus(url, "Bearer wrong", http.StatusUnauthorized)
+	expectStatus(url, "Basic "+token, http.StatusUnauthorized)
+	expectStatus(url, "Bearer "+token, http.StatusOK)
+
+	// the gateways are not affected
+	cid, err := node.AddBytes([]byte("hello auth\n"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node.ServeTCPGateway("0", false); err != nil {
+		t.Fatal(err)
+	}
+	gwURL, err := node.GatewayURL(cid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(gwURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the gateway to serve without token got %d", resp.StatusCode)
+	}
+
+	// the commands of a writable gateway require the token, not its content
+	l, err := node.ServeGatewayMultiaddr("/ip4/127.0.0.1/tcp/0", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maddr, err = ma.NewMultiaddr(l.Multiaddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err = manet.ToNetAddr(maddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gwAPI := fmt.Sprintf("http://%s/api/v0/version", addr)
+	expectStatus(gwAPI, "", http.StatusUnauthorized)
+	expectStatus(gwAPI, "Bearer "+token, http.StatusOK)
+
+	resp, err = client.Get(fmt.Sprintf("http://%s/ipfs/%s", addr, cid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the writable gateway to serve without token got %d", resp.StatusCode)
+	}
+
+	node.SetAPIAuth("")
+	expectStatus(url, "", http.StatusOK)
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	ipfs_core "github.com/ipfs/kubo/core"
+	ipfs_corehttp "github.com/ipfs/kubo/core/corehttp"
+)
+
+// apiCORS holds the cross-origin requests allowed on the API servers, none
+// by default.
+type apiCORS struct {
+	mu      sync.RWMutex
+	origins []string
+	methods []string
+}
+
+func (c *apiCORS) set(origins, methods []string) {
+	c.mu.Lock()
+	c.origins, c.methods = origins, methods
+	c.mu.Unlock()
+}
+
+// allowed returns the value of the `Access-Control-Allow-Origin` header and
+// the allowed methods for origin, an empty value when it isn't allowed.
+func (c *apiCORS) allowed(origin string) (string, []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, o := range c.origins {
+		if o == "*" {
+			return "*", c.methods
+		}
+		if strings.EqualFold(o, origin) {
+			return origin, c.methods
+		}
+	}
+	return "", nil
+}
+
+// serveOption answers the CORS preflight requests of the allowed origins and
+// sets the `Access-Control-Allow-*` headers of their requests. The origin of
+// the requests it lets through has been checked, so it is removed before
+// reaching the commands handler, which only allows the localhost origins.
+// Other requests are left to the commands handler, denying cross-origin
+// requests.
+func (c *apiCORS) serveOption() ipfs_corehttp.ServeOption {
+	return func(_ *ipfs_core.IpfsNode, _ net.Listener, mux *http.ServeMux) (*http.ServeMux, error) {
+		childMux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				childMux.ServeHTTP(w, r)
+				return
+			}
+
+			allowOrigin, methods := c.allowed(origin)
+			if allowOrigin == "" {
+				childMux.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", allowOrigin)
+			h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			h.Add("Vary", "Origin")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+					h.Set("Access-Control-Allow-Headers", headers)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			allowedMethod := false
+			for _, m := range methods {
+				allowedMethod = allowedMethod || m == r.Method
+			}
+			if !allowedMethod {
+				http.Error(w, fmt.Sprintf("method %s not allowed for origin `%s`", r.Method, origin), http.StatusMethodNotAllowed)
+				return
+			}
+
+			r.Header.Del("Origin")
+			r.Header.Del("Referer")
+			childMux.ServeHTTP(w, r)
+		})
+		return childMux, nil
+	}
+}
+
+// SetAPICORS allows the web pages of the given origins (e.g. a WebView or a
+// local web UI), a comma separated list of origins like
+// `http://localhost:3000` or `*` for any origin, to call the API with the
+// given comma separated methods (`POST` when empty, the API only accepts
+// POST requests). It applies to the running API servers right away. By
+// default, and when origins is empty, cross-origin requests are denied, but
+// the ones of the localhost origins on the port of the API.
+func (n *Node) SetAPICORS(allowedOrigins string, allowedMethods string) error {
+	var origins []string
+	for _, o := range strings.Split(allowedOrigins, ",") {
+		o = strings.TrimSpace(o)
+		if o == "" {
+			continue
+		}
+		if o != "*" {
+			u, err := url.Parse(o)
+			if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+				return fmt.Errorf("invalid origin `%s`, expected `<scheme>://<host>[:<port>]` or `*`", o)
+			}
+			o = strings.TrimSuffix(o, "/")
+		}
+		origins = append(origins, o)
+	}
+
+	var methods []string
+	for _, m := range strings.Split(allowedMethods, ",") {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		switch m {
+		case "":
+			continue
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			methods = append(methods, m)
+		default:
+			return fmt.Errorf("invalid method `%s`", m)
+		}
+	}
+	if len(methods) == 0 {
+		methods = []string{http.MethodPost}
+	}
+
+	n.apiCORS.set(origins, methods)
+	return nil
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+func TestNodeSetAPICORS(t *testing.T) {
+	const origin = "http://webview.test"
+
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if err := node.SetAPICORS("not an origin", ""); err == nil {
+		t.Error("expected an invalid origin to be refused")
+	}
+	if err := node.SetAPICORS(origin, "POST,FETCH"); err == nil {
+		t.Error("expected an invalid method to be refused")
+	}
+
+	smaddr, err := node.ServeTCPAPI("0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	maddr, err := ma.NewMultiaddr(smaddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := manet.ToNetAddr(maddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := fmt.Sprintf("http://%s/api/v0/id", addr)
+
+	client := http.Client{Timeout: 5 * time.Second}
+	request := func(method, origin string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// denied by default
+	if resp := request(http.MethodPost, origin); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected cross-origin requests to be denied got %d", resp.StatusCode)
+	}
+
+	if err := node.SetAPICORS(origin+", http://other.test", "post"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := request(http.MethodOptions, origin)
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Origin") != origin {
+		t.Errorf("unexpected preflight response %d `%v`", resp.StatusCode, resp.Header)
+	}
+
+	resp = request(http.MethodPost, origin)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != origin {
+		t.Errorf("unexpected response %d `%v`", resp.StatusCode, resp.Header)
+	}
+
+	if resp := request(http.MethodPost, "http://evil.test"); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected other origins to be denied got %d", resp.StatusCode)
+	}
+
+	if err := node.SetAPICORS("*", ""); err != nil {
+		t.Fatal(err)
+	}
+	if resp := request(http.MethodPost, "http://evil.test"); resp.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("expected any origin to be allowed got `%v`", resp.Header)
+	}
+
+	// back to the default
+	if err := node.SetAPICORS("", ""); err != nil {
+		t.Fatal(err)
+	}
+	if resp := request(http.MethodPost, origin); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected cross-origin requests to be denied again got %d", resp.StatusCode)
+	}
+}
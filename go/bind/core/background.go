@@ -0,0 +1,235 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// backgroundState holds the settings replaced by EnterBackgroundMode,
+// restored by ExitBackgroundMode.
+type backgroundState struct {
+	listenAddrs       []ma.Multiaddr
+	reprovideInterval time.Duration
+	reachability      network.Reachability
+	mdns              bool
+}
+
+// listenCloser is implemented by the libp2p swarm.
+type listenCloser interface {
+	ListenClose(addrs ...ma.Multiaddr)
+}
+
+// EnterBackgroundMode suspends most of the networking of the node, e.g. when
+// the app goes to background, to save battery while keeping the node and its
+// state. It:
+//   - closes the swarm listeners, so no inbound connection is accepted but
+//     through a relay, the relay listener can't be reopened;
+//   - pauses reproviding, as SetReprovideInterval(0) does;
+//   - switches the DHT to client mode, as SetDHTServerMode(false) does;
+//   - stops mDNS, as StopMDNS does.
+//
+// The open connections are kept and trimmed by the connection manager. The
+// local operations (Cat, pins, files, adds) keep working, as do the ones
+// fetching content or querying the DHT over outbound connections, though
+// fewer peers are reachable. Entering it again is a no-op. The settings
+// changed in between are overridden by ExitBackgroundMode, SetMetered
+// applies to the settings restored on exit.
+func (n *Node) EnterBackgroundMode() error {
+	n.muMetered.Lock()
+	defer n.muMetered.Unlock()
+
+	if n.background != nil {
+		return nil
+	}
+	if n.ctx.Err() != nil {
+		return errors.New("unable to enter background mode: node is closed")
+	}
+
+	state := &backgroundState{}
+
+	n.muMDNS.Lock()
+	state.mdns = n.mdnsService != nil
+	n.muMDNS.Unlock()
+	if state.mdns {
+		if err := n.StopMDNS(); err != nil {
+			return err
+		}
+	}
+
+	if n.reprovider != nil {
+		state.reprovideInterval = n.reprovider.getInterval()
+		n.reprovider.setInterval(0)
+	}
+
+	if n.dhtHost != nil && n.ipfsMobile.IpfsNode.DHT != nil {
+		state.reachability = n.dhtHost.Reachability()
+		n.dhtHost.SetReachability(network.ReachabilityPrivate)
+	}
+
+	// closing the relay listener stops the relay client for good, it is kept
+	net := n.ipfsMobile.PeerHost().Network()
+	if lc, ok := net.(listenCloser); ok {
+		for _, addr := range net.ListenAddresses() {
+			if _, err := addr.ValueForProtocol(ma.P_CIRCUIT); err != nil {
+				state.listenAddrs = append(state.listenAddrs, addr)
+			}
+		}
+		lc.ListenClose(state.listenAddrs...)
+	}
+
+	// the addresses not listened again since the last exit are restored on
+	// the next one
+	if n.relisten != nil {
+		n.relisten()
+		state.listenAddrs = append(state.listenAddrs, n.relistenAddrs...)
+		n.relisten, n.relistenAddrs = nil, nil
+	}
+
+	n.background = state
+	return nil
+}
+
+// ExitBackgroundMode restores the settings replaced by EnterBackgroundMode,
+// listening again on the same addresses. The QUIC transport keeps the socket
+// of a closed listener for up to 40 seconds, the addresses still in use are
+// listened again in the background as soon as they are released. Exiting it
+// again is a no-op.
+func (n *Node) ExitBackgroundMode() error {
+	n.muMetered.Lock()
+	defer n.muMetered.Unlock()
+
+	state := n.background
+	if state == nil {
+		return nil
+	}
+	n.background = nil
+
+	var err error
+	if len(state.listenAddrs) > 0 {
+		pending, lerr := n.listenAgain(state.listenAddrs)
+		if lerr != nil {
+			err = fmt.Errorf("unable to listen again: %w", lerr)
+		} else if len(pending) > 0 {
+			ctx, cancel := context.WithCancel(n.ctx)
+			n.relisten, n.relistenAddrs = cancel, pending
+			go n.retryListen(ctx)
+		}
+	}
+
+	if n.reprovider != nil {
+		n.reprovider.setInterval(state.reprovideInterval)
+	}
+
+	if n.dhtHost != nil && n.ipfsMobile.IpfsNode.DHT != nil {
+		n.dhtHost.SetReachability(state.reachability)
+	}
+
+	if state.mdns {
+		if merr := n.StartMDNS(); merr != nil && err == nil {
+			err = merr
+		}
+	}
+	return err
+}
+
+// relistenInterval is the interval at which the addresses still in use on
+// exiting background mode are listened again
+const relistenInterval = 5 * time.Second
+
+// listenAgain listens on each of the addresses closed by EnterBackgroundMode
+// and returns the ones which can't be listened yet. It fails when none can be
+// listened.
+func (n *Node) listenAgain(addrs []ma.Multiaddr) (pending []ma.Multiaddr, err error) {
+	net := n.ipfsMobile.PeerHost().Network()
+	for _, addr := range addrs {
+		if lerr := net.Listen(addr); lerr != nil {
+			pending, err = append(pending, addr), lerr
+		}
+	}
+
+	if len(pending) < len(addrs) {
+		err = nil
+	}
+	return pending, err
+}
+
+// retryListen listens again on the pending addresses until they are all
+// listened, the node enters background mode again or it is closed.
+func (n *Node) retryListen(ctx context.Context) {
+	ticker := time.NewTicker(relistenInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n.muMetered.Lock()
+		if ctx.Err() != nil {
+			n.muMetered.Unlock()
+			return
+		}
+
+		n.relistenAddrs, _ = n.listenAgain(n.relistenAddrs)
+		done := len(n.relistenAddrs) == 0
+		if done {
+			n.relisten()
+			n.relisten, n.relistenAddrs = nil, nil
+		}
+		n.muMetered.Unlock()
+
+		if done {
+			return
+		}
+	}
+}
+
+// IsBackgroundMode returns whether the node runs in background mode, see
+// EnterBackgroundMode.
+func (n *Node) IsBackgroundMode() bool {
+	n.muMetered.Lock()
+	defer n.muMetered.Unlock()
+	return n.background != nil
+}
+
+// foregroundReprovideInterval returns the reprovide interval in use out of
+// background mode, the one restored on exit while in background mode.
+// muMetered must be held.
+func (n *Node) foregroundReprovideInterval() time.Duration {
+	if n.background != nil {
+		return n.background.reprovideInterval
+	}
+	return n.reprovider.getInterval()
+}
+
+func (n *Node) setForegroundReprovideInterval(interval time.Duration) {
+	if n.background != nil {
+		n.background.reprovideInterval = interval
+		return
+	}
+	n.reprovider.setInterval(interval)
+}
+
+// foregroundReachability is the counterpart of foregroundReprovideInterval
+// for the reachability seen by the DHT.
+func (n *Node) foregroundReachability() network.Reachability {
+	if n.background != nil {
+		return n.background.reachability
+	}
+	return n.dhtHost.Reachability()
+}
+
+func (n *Node) setForegroundReachability(reachability network.Reachability) {
+	if n.background != nil {
+		n.background.reachability = reachability
+		return
+	}
+	n.dhtHost.SetReachability(reachability)
+}
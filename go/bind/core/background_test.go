@@ -0,0 +1,104 @@
+package core
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestNodeBackgroundMode(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if node.reprovider == nil || node.dhtHost == nil {
+		t.Skip("reproviding and the dht should be enabled by the testing config")
+	}
+
+	if err := node.SetReprovideInterval(3600); err != nil {
+		t.Fatal(err)
+	}
+
+	net := node.ipfsMobile.PeerHost().Network()
+	listenAddrs := len(net.ListenAddresses())
+	if listenAddrs == 0 {
+		t.Fatal("expected the node to listen")
+	}
+
+	if err := node.EnterBackgroundMode(); err != nil {
+		t.Fatal(err)
+	}
+	if !node.IsBackgroundMode() {
+		t.Fatal("expected the node to be in background mode")
+	}
+
+	for _, addr := range net.ListenAddresses() {
+		if _, err := addr.ValueForProtocol(ma.P_CIRCUIT); err != nil {
+			t.Errorf("expected the listeners but the relay one to be closed got `%s`", addr)
+		}
+	}
+	if got := node.reprovider.getInterval(); got != 0 {
+		t.Errorf("expected reproviding to be paused got `%s`", got)
+	}
+	if got := node.dhtHost.Reachability(); got != network.ReachabilityPrivate {
+		t.Errorf("expected the dht to be in client mode got `%s`", got)
+	}
+
+	// local operations keep working
+	if _, err := node.AddBytes([]byte("background"), true); err != nil {
+		t.Error(err)
+	}
+
+	// metered mode applies to the settings restored on exit
+	node.SetMetered(true)
+	node.SetMetered(false)
+
+	if err := node.ExitBackgroundMode(); err != nil {
+		t.Fatal(err)
+	}
+	if node.IsBackgroundMode() {
+		t.Fatal("expected the node to be in foreground mode")
+	}
+
+	// the quic addresses may still be in use, they are listened later
+	node.muMetered.Lock()
+	pending := len(node.relistenAddrs)
+	node.muMetered.Unlock()
+	if got := len(net.ListenAddresses()); got+pending != listenAddrs {
+		t.Errorf("expected %d listen addresses got %d and %d pending", listenAddrs, got, pending)
+	}
+	if got := node.reprovider.getInterval(); got != time.Hour {
+		t.Errorf("expected the reprovide interval to be restored got `%s`", got)
+	}
+	if got := node.dhtHost.Reachability(); got == network.ReachabilityPrivate {
+		t.Errorf("expected the dht reachability to be restored got `%s`", got)
+	}
+
+	// switching modes repeatedly doesn't leak goroutines: a single leaked
+	// goroutine per switch grows the count by at least cycles
+	const cycles = 50
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < cycles; i++ {
+		if err := node.EnterBackgroundMode(); err != nil {
+			t.Fatal(err)
+		}
+		if err := node.ExitBackgroundMode(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// give the stopped goroutines time to exit
+	got := runtime.NumGoroutine()
+	for deadline := time.Now().Add(5 * time.Second); got-goroutines >= cycles && time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+		got = runtime.NumGoroutine()
+	}
+	if got-goroutines >= cycles {
+		t.Errorf("expected less than %d new goroutines after %d switches got %d", cycles, cycles, got-goroutines)
+	}
+}
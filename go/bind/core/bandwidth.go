@@ -0,0 +1,84 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/metrics"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// errBandwidthDisabled is returned when kubo built the host without bandwidth
+// counter, see Swarm.DisableBandwidthMetrics.
+var errBandwidthDisabled = errors.New("bandwidth metrics are disabled")
+
+// BandwidthStats holds the traffic counters of a peer, totals are in bytes and
+// rates in bytes per second.
+type BandwidthStats struct {
+	TotalIn  int64
+	TotalOut int64
+	RateIn   float64
+	RateOut  float64
+}
+
+// PeerBandwidth returns a JSON encoded BandwidthStats of the traffic exchanged
+// with the given peer. A peer without recorded traffic reports zeros.
+func (n *Node) PeerBandwidth(peerID string) (string, error) {
+	pid, err := peer.Decode(peerID)
+	if err != nil {
+		return "", fmt.Errorf("invalid peer id `%s`: %w", peerID, err)
+	}
+
+	reporter := n.ipfsMobile.IpfsNode.Reporter
+	if reporter == nil {
+		return "", errBandwidthDisabled
+	}
+
+	return marshalBandwidthStats(reporter.GetBandwidthForPeer(pid))
+}
+
+// BandwidthTotals returns a JSON encoded BandwidthStats of the whole traffic
+// of the node since it started, e.g. to show the data usage of the app.
+func (n *Node) BandwidthTotals() (string, error) {
+	reporter := n.ipfsMobile.IpfsNode.Reporter
+	if reporter == nil {
+		return "", errBandwidthDisabled
+	}
+
+	return marshalBandwidthStats(reporter.GetBandwidthTotals())
+}
+
+// BandwidthForProtocol returns a JSON encoded BandwidthStats of the traffic of
+// the streams of the given protocol since the node started, e.g.
+// `/ipfs/bitswap/1.2.0`. A protocol without recorded traffic reports zeros.
+func (n *Node) BandwidthForProtocol(protocolID string) (string, error) {
+	if protocolID == "" {
+		return "", errors.New("empty protocol id")
+	}
+
+	reporter := n.ipfsMobile.IpfsNode.Reporter
+	if reporter == nil {
+		return "", errBandwidthDisabled
+	}
+
+	return marshalBandwidthStats(reporter.GetBandwidthForProtocol(protocol.ID(protocolID)))
+}
+
+func marshalBandwidthStats(stats metrics.Stats) (string, error) {
+	out, err := json.Marshal(newBandwidthStats(stats))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func newBandwidthStats(stats metrics.Stats) *BandwidthStats {
+	return &BandwidthStats{
+		TotalIn:  stats.TotalIn,
+		TotalOut: stats.TotalOut,
+		RateIn:   stats.RateIn,
+		RateOut:  stats.RateOut,
+	}
+}
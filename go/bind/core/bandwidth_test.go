@@ -0,0 +1,64 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNodePeerBandwidth(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if _, err := node.PeerBandwidth("not a peer id"); err == nil {
+		t.Error("invalid peer id should fail")
+	}
+
+	// no traffic has been exchanged with ourself
+	out, err := node.PeerBandwidth(node.ipfsMobile.IpfsNode.Identity.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stats BandwidthStats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats != (BandwidthStats{}) {
+		t.Errorf("expected empty stats got `%+v`", stats)
+	}
+}
+
+func TestNodeBandwidthTotals(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	for _, get := range []func() (string, error){
+		node.BandwidthTotals,
+		func() (string, error) { return node.BandwidthForProtocol("/test/1.0.0") },
+	} {
+		out, err := get()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var stats BandwidthStats
+		if err := json.Unmarshal([]byte(out), &stats); err != nil {
+			t.Fatal(err)
+		}
+
+		if stats.TotalIn < 0 || stats.TotalOut < 0 {
+			t.Errorf("unexpected stats `%+v`", stats)
+		}
+	}
+
+	if _, err := node.BandwidthForProtocol(""); err == nil {
+		t.Error("empty protocol id should fail")
+	}
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+	ipfs_iface "github.com/ipfs/interface-go-ipfs-core"
+	ipfs_path "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// defaultBatchConcurrency is the number of concurrent fetches of GetMany when
+// no concurrency is given
+const defaultBatchConcurrency = 4
+
+// BatchHandler receives the results of GetMany. Calls are never concurrent,
+// every cid gets either OnResult or OnError, then OnComplete is called once
+// the batch is over.
+type BatchHandler interface {
+	OnResult(cid string, data []byte)
+	OnError(cid string, message string)
+	OnComplete()
+}
+
+// GetMany fetches the content of the files of the JSON encoded list of cids or
+// paths, with at most concurrency fetches running at once (4 when lower than
+// 1). Results are delivered to handler as soon as each fetch completes. It
+// returns a handle that can be passed to CancelRequest, the cids not fetched
+// yet are then reported as errors.
+func (n *Node) GetMany(cidsJSON string, concurrency int, handler BatchHandler) (int64, error) {
+	var cids []string
+	if err := json.Unmarshal([]byte(cidsJSON), &cids); err != nil {
+		return 0, fmt.Errorf("invalid cid list: %w", err)
+	}
+
+	paths := make([]ipfs_path.Path, len(cids))
+	for i, c := range cids {
+		p, err := parsePath(c)
+		if err != nil {
+			return 0, err
+		}
+		paths[i] = p
+	}
+
+	if concurrency < 1 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return 0, err
+	}
+
+	id, ctx, done := n.newRequest()
+	go func() {
+		defer done()
+
+		var muHandler sync.Mutex
+		result := func(c string, data []byte, err error) {
+			muHandler.Lock()
+			defer muHandler.Unlock()
+
+			if err != nil {
+				handler.OnError(c, err.Error())
+			} else {
+				handler.OnResult(c, data)
+			}
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, concurrency)
+		for i, p := range paths {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+			}
+
+			if ctx.Err() != nil {
+				result(cids[i], nil, ctx.Err())
+				continue
+			}
+
+			wg.Add(1)
+			go func(c string, p ipfs_path.Path) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+
+				data, err := getFile(ctx, api, p)
+				result(c, data, err)
+			}(cids[i], p)
+		}
+		wg.Wait()
+
+		handler.OnComplete()
+	}()
+
+	return id, nil
+}
+
+// getFile returns the content of the unixfs file at the given path.
+func getFile(ctx context.Context, api ipfs_iface.CoreAPI, p ipfs_path.Path) ([]byte, error) {
+	nd, err := api.Unixfs().Get(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get `%s`: %w", p, err)
+	}
+	defer nd.Close()
+
+	f := ipfs_files.ToFile(nd)
+	if f == nil {
+		return nil, fmt.Errorf("`%s` is not a file", p)
+	}
+
+	return io.ReadAll(f)
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+)
+
+type testBatchHandler struct {
+	results map[string]string
+	errors  map[string]string
+	done    chan struct{}
+}
+
+func (h *testBatchHandler) OnResult(cid string, data []byte)   { h.results[cid] = string(data) }
+func (h *testBatchHandler) OnError(cid string, message string) { h.errors[cid] = message }
+func (h *testBatchHandler) OnComplete()                        { close(h.done) }
+
+func TestNodeGetMany(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	api, err := node.coreAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := []string{"content a", "content b", "content c"}
+	expected := make(map[string]string)
+	for _, content := range contents {
+		resolved, err := api.Unixfs().Add(context.Background(), ipfs_files.NewBytesFile([]byte(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected[resolved.Cid().String()] = content
+	}
+
+	dir, err := api.Unixfs().Add(context.Background(), ipfs_files.NewMapDirectory(map[string]ipfs_files.Node{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cids := []string{dir.Cid().String()}
+	for c := range expected {
+		cids = append(cids, c)
+	}
+	cidsJSON, err := json.Marshal(cids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &testBatchHandler{
+		results: make(map[string]string),
+		errors:  make(map[string]string),
+		done:    make(chan struct{}),
+	}
+	if _, err := node.GetMany(string(cidsJSON), 2, handler); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-handler.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for batch")
+	}
+
+	for c, content := range expected {
+		if handler.results[c] != content {
+			t.Errorf("expected `%s` for `%s` got `%s`", content, c, handler.results[c])
+		}
+	}
+
+	if _, ok := handler.errors[dir.Cid().String()]; !ok {
+		t.Error("expected an error for a directory")
+	}
+
+	if _, err := node.GetMany(`["invalid"]`, 2, handler); err == nil {
+		t.Error("expected an error for an invalid cid")
+	}
+}
@@ -0,0 +1,295 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	proximity "github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/proximitytransport"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// bleEventBufferSize is the number of pending BLE events a slow
+// BLEEventHandler can lag behind before events start being dropped
+const bleEventBufferSize = 64
+
+// BLEEventHandler is notified of the BLE connections of the node, see
+// Node.SetBLEEventHandler. It is called from a dedicated goroutine.
+type BLEEventHandler interface {
+	OnConnected(peerID string)
+	OnDisconnected(peerID string)
+	OnError(message string)
+}
+
+// BLEPeerStats describes a BLE connection.
+type BLEPeerStats struct {
+	Peer          string
+	BytesSent     int64
+	BytesReceived int64
+	// MTU is the MTU negotiated with the peer's device, 0 when the native
+	// driver didn't report it.
+	MTU int
+}
+
+// BLEStats is returned by Node.BLEStats.
+type BLEStats struct {
+	Connections int
+	// BytesSent and BytesReceived count the bytes exchanged over BLE since
+	// the node started.
+	BytesSent     int64
+	BytesReceived int64
+	Peers         []BLEPeerStats
+}
+
+type bleEventKind int
+
+const (
+	bleEventConnected bleEventKind = iota
+	bleEventDisconnected
+	bleEventError
+)
+
+type bleEvent struct {
+	kind    bleEventKind
+	peer    string
+	message string
+}
+
+// bleEvents is the proximity.EventHandler of the BLE transport, it forwards
+// the events to the BLEEventHandler of the node from its own goroutine so a
+// slow handler doesn't block the transport.
+type bleEvents struct {
+	events chan bleEvent
+
+	mu      sync.Mutex
+	handler BLEEventHandler
+}
+
+var _ proximity.EventHandler = (*bleEvents)(nil)
+
+func newBLEEvents() *bleEvents {
+	return &bleEvents{events: make(chan bleEvent, bleEventBufferSize)}
+}
+
+func (e *bleEvents) OnConnected(remotePID string) {
+	e.push(bleEvent{kind: bleEventConnected, peer: remotePID})
+}
+
+func (e *bleEvents) OnDisconnected(remotePID string) {
+	e.push(bleEvent{kind: bleEventDisconnected, peer: remotePID})
+}
+
+func (e *bleEvents) OnError(remotePID string, err error) {
+	e.push(bleEvent{kind: bleEventError, peer: remotePID, message: fmt.Sprintf("%s: %s", remotePID, err)})
+}
+
+func (e *bleEvents) push(evt bleEvent) {
+	select {
+	case e.events <- evt:
+	default:
+	}
+}
+
+func (e *bleEvents) setHandler(handler BLEEventHandler) {
+	e.mu.Lock()
+	e.handler = handler
+	e.mu.Unlock()
+}
+
+func (e *bleEvents) getHandler() BLEEventHandler {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.handler
+}
+
+func (e *bleEvents) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt := <-e.events:
+			handler := e.getHandler()
+			if handler == nil {
+				continue
+			}
+
+			switch evt.kind {
+			case bleEventConnected:
+				handler.OnConnected(evt.peer)
+			case bleEventDisconnected:
+				handler.OnDisconnected(evt.peer)
+			case bleEventError:
+				handler.OnError(evt.message)
+			}
+		}
+	}
+}
+
+// errBLEUnavailable is returned when the node has been created without the
+// BLE transport.
+var errBLEUnavailable = errors.New("ble is not available on this node")
+
+// EnableBLE makes the node listen on BLE again after DisableBLE, which starts
+// the native driver so nearby peers can connect. The BLE transport is
+// registered when the node is created and stays registered, only its
+// listener is opened and closed at runtime, so the other transports and their
+// connections are left untouched. In background mode, the listener is opened
+// on exit. Enabling it again is a no-op. It fails when the node has been
+// created without BLE support.
+func (n *Node) EnableBLE() error {
+	if n.bleProtocol == "" {
+		return errBLEUnavailable
+	}
+
+	addr, err := ma.NewMultiaddr(n.bleDefaultAddr)
+	if err != nil {
+		return fmt.Errorf("invalid ble address `%s`: %w", n.bleDefaultAddr, err)
+	}
+
+	n.muMetered.Lock()
+	defer n.muMetered.Unlock()
+
+	if n.background != nil {
+		if !n.hasBLEAddr(n.background.listenAddrs) {
+			n.background.listenAddrs = append(n.background.listenAddrs, addr)
+		}
+		return nil
+	}
+
+	net := n.ipfsMobile.PeerHost().Network()
+	if n.hasBLEAddr(net.ListenAddresses()) {
+		return nil
+	}
+
+	if err := net.Listen(addr); err != nil {
+		return fmt.Errorf("unable to listen on `%s`: %w", addr, err)
+	}
+	return nil
+}
+
+// DisableBLE closes the BLE connections of the node then its BLE listener,
+// which stops the native driver. The TCP and QUIC listeners and connections
+// are left untouched. Disabling it again is a no-op. It fails when the node
+// has been created without BLE support.
+func (n *Node) DisableBLE() error {
+	if n.bleProtocol == "" {
+		return errBLEUnavailable
+	}
+
+	n.muMetered.Lock()
+	defer n.muMetered.Unlock()
+
+	if n.background != nil {
+		var addrs []ma.Multiaddr
+		for _, addr := range n.background.listenAddrs {
+			if !n.isBLEAddr(addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+		n.background.listenAddrs = addrs
+	}
+
+	net := n.ipfsMobile.PeerHost().Network()
+	for _, conn := range net.Conns() {
+		if n.isBLEAddr(conn.RemoteMultiaddr()) {
+			conn.Close()
+		}
+	}
+
+	var addrs []ma.Multiaddr
+	for _, addr := range net.ListenAddresses() {
+		if n.isBLEAddr(addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	lc, ok := net.(listenCloser)
+	if !ok {
+		return errors.New("unable to close the ble listener")
+	}
+	lc.ListenClose(addrs...)
+	return nil
+}
+
+// IsBLEEnabled returns whether the node listens on BLE, or will once out of
+// background mode.
+func (n *Node) IsBLEEnabled() bool {
+	if n.bleProtocol == "" {
+		return false
+	}
+
+	n.muMetered.Lock()
+	defer n.muMetered.Unlock()
+
+	if n.background != nil {
+		return n.hasBLEAddr(n.background.listenAddrs)
+	}
+	return n.hasBLEAddr(n.ipfsMobile.PeerHost().Network().ListenAddresses())
+}
+
+func (n *Node) isBLEAddr(addr ma.Multiaddr) bool {
+	_, err := addr.ValueForProtocol(ma.ProtocolWithName(n.bleProtocol).Code)
+	return err == nil
+}
+
+func (n *Node) hasBLEAddr(addrs []ma.Multiaddr) bool {
+	for _, addr := range addrs {
+		if n.isBLEAddr(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetBLEEventHandler sets the handler notified when a BLE connection is
+// opened, closed or fails, e.g. to show the nearby sharing activity, nil
+// removes it. Events happening while no handler is set are dropped. It does
+// nothing when BLE is not enabled.
+func (n *Node) SetBLEEventHandler(handler BLEEventHandler) {
+	if n.bleEvents != nil {
+		n.bleEvents.setHandler(handler)
+	}
+}
+
+// BLEStats returns a JSON encoded BLEStats describing the active BLE
+// connections of the node. It fails when BLE is not enabled or its listener
+// is not running.
+func (n *Node) BLEStats() (string, error) {
+	if n.bleProtocol == "" {
+		return "", errBLEUnavailable
+	}
+
+	proximity.TransportMapMutex.RLock()
+	t, ok := proximity.TransportMap[n.bleProtocol]
+	proximity.TransportMapMutex.RUnlock()
+	if !ok {
+		return "", errors.New("ble transport is not running")
+	}
+
+	tstats := t.Stats()
+	stats := BLEStats{
+		Connections:   len(tstats.Conns),
+		BytesSent:     tstats.BytesSent,
+		BytesReceived: tstats.BytesReceived,
+		Peers:         make([]BLEPeerStats, len(tstats.Conns)),
+	}
+	for i, c := range tstats.Conns {
+		stats.Peers[i] = BLEPeerStats{
+			Peer:          c.RemotePID,
+			BytesSent:     c.BytesSent,
+			BytesReceived: c.BytesReceived,
+			MTU:           c.MTU,
+		}
+	}
+
+	out, err := json.Marshal(&stats)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	ble "github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/ble-driver"
+	proximity "github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/proximitytransport"
+)
+
+type testBLEEventHandler struct {
+	events chan string
+}
+
+func (h *testBLEEventHandler) OnConnected(peerID string)    { h.events <- "connected " + peerID }
+func (h *testBLEEventHandler) OnDisconnected(peerID string) { h.events <- "disconnected " + peerID }
+func (h *testBLEEventHandler) OnError(message string)       { h.events <- "error " + message }
+
+func TestNodeBLEStats(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	config := NewNodeConfig()
+	config.SetBleDriver(proximity.NewNoopProximityDriver(ble.ProtocolCode, ble.ProtocolName, ble.DefaultAddr))
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	out, err := node.BLEStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stats BLEStats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Connections != 0 || stats.BytesSent != 0 || stats.BytesReceived != 0 {
+		t.Errorf("expected no ble activity got `%+v`", stats)
+	}
+
+	handler := &testBLEEventHandler{events: make(chan string, 3)}
+	node.SetBLEEventHandler(handler)
+
+	node.bleEvents.OnConnected("peer")
+	node.bleEvents.OnError("peer", errors.New("write failed"))
+	node.bleEvents.OnDisconnected("peer")
+
+	for _, expected := range []string{"connected peer", "error peer: write failed", "disconnected peer"} {
+		select {
+		case evt := <-handler.events:
+			if evt != expected {
+				t.Errorf("expected event `%s` got `%s`", expected, evt)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected event `%s`", expected)
+		}
+	}
+}
+
+func TestNodeBLEStatsDisabled(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if ble.Supported {
+		t.Skip("ble is enabled on this platform")
+	}
+
+	if _, err := node.BLEStats(); err == nil {
+		t.Error("expected an error without ble")
+	}
+	if err := node.EnableBLE(); err == nil {
+		t.Error("expected EnableBLE to fail without ble")
+	}
+
+	// no-op without ble
+	node.SetBLEEventHandler(&testBLEEventHandler{})
+}
+
+func TestNodeEnableDisableBLE(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	config := NewNodeConfig()
+	config.SetBleDriver(proximity.NewNoopProximityDriver(ble.ProtocolCode, ble.ProtocolName, ble.DefaultAddr))
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	net := node.ipfsMobile.PeerHost().Network()
+	countOther := func() (other int) {
+		for _, addr := range net.ListenAddresses() {
+			if !node.isBLEAddr(addr) {
+				other++
+			}
+		}
+		return
+	}
+	other := countOther()
+
+	if !node.IsBLEEnabled() {
+		t.Fatal("expected the node to listen on ble")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := node.DisableBLE(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if node.IsBLEEnabled() {
+		t.Error("expected the ble listener to be closed")
+	}
+	if _, err := node.BLEStats(); err == nil {
+		t.Error("expected the ble transport to be stopped")
+	}
+	if got := countOther(); got != other {
+		t.Errorf("expected %d other listeners got %d", other, got)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := node.EnableBLE(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !node.IsBLEEnabled() {
+		t.Error("expected the node to listen on ble again")
+	}
+	if _, err := node.BLEStats(); err != nil {
+		t.Error(err)
+	}
+	if got := countOther(); got != other {
+		t.Errorf("expected %d other listeners got %d", other, got)
+	}
+}
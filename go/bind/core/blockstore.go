@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+)
+
+// EvictBlock removes a single block from the blockstore. Pinned blocks
+// (directly, recursively or indirectly) are refused, use Unpin first.
+func (n *Node) EvictBlock(cid string) error {
+	c, err := ipfs_cid.Decode(cid)
+	if err != nil {
+		return fmt.Errorf("invalid cid `%s`: %w", cid, err)
+	}
+
+	ctx := context.Background()
+	inode := n.ipfsMobile.IpfsNode
+
+	// hold the pin lock so the block can't get pinned while we delete it
+	unlocker := inode.Blockstore.PinLock(ctx)
+	defer unlocker.Unlock(ctx)
+
+	status, err := n.pinStatus(ctx, c)
+	if err != nil {
+		return err
+	}
+	if status != PinStatusNotPinned {
+		return fmt.Errorf("cannot evict `%s`: block is pinned (%s)", c, status)
+	}
+
+	has, err := inode.Blockstore.Has(ctx, c)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return fmt.Errorf("cannot evict `%s`: block not found", c)
+	}
+
+	return inode.Blockstore.DeleteBlock(ctx, c)
+}
+
+// CacheSize returns the total size in bytes of the blocks in the blockstore.
+func (n *Node) CacheSize() (int64, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bs := n.ipfsMobile.IpfsNode.Blockstore
+	keys, err := bs.AllKeysChan(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for c := range keys {
+		size, err := bs.GetSize(ctx, c)
+		if err != nil {
+			return 0, fmt.Errorf("unable to get size of `%s`: %w", c, err)
+		}
+		total += int64(size)
+	}
+
+	return total, nil
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+	ipfs_options "github.com/ipfs/interface-go-ipfs-core/options"
+	ipfs_coreapi "github.com/ipfs/kubo/core/coreapi"
+)
+
+func TestNodeEvictBlock(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	api, err := ipfs_coreapi.NewCoreAPI(node.ipfsMobile.IpfsNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	unpinned, err := api.Unixfs().Add(ctx, ipfs_files.NewBytesFile([]byte("unpinned content")), ipfs_options.Unixfs.Pin(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pinned, err := api.Unixfs().Add(ctx, ipfs_files.NewBytesFile([]byte("pinned content")), ipfs_options.Unixfs.Pin(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := node.CacheSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size == 0 {
+		t.Fatal("cache size should be greater than 0")
+	}
+
+	status, err := node.IsPinned(pinned.Cid().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != PinStatusRecursive {
+		t.Errorf("expected pin status `%s` got `%s`", PinStatusRecursive, status)
+	}
+
+	status, err = node.IsPinned(unpinned.Cid().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != PinStatusNotPinned {
+		t.Errorf("expected pin status `%s` got `%s`", PinStatusNotPinned, status)
+	}
+
+	if err := node.EvictBlock(pinned.Cid().String()); err == nil {
+		t.Error("evicting a pinned block should fail")
+	}
+
+	if err := node.EvictBlock(unpinned.Cid().String()); err != nil {
+		t.Fatal(err)
+	}
+
+	has, err := node.ipfsMobile.IpfsNode.Blockstore.Has(ctx, unpinned.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if has {
+		t.Error("block should have been evicted")
+	}
+}
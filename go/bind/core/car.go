@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	blocks "github.com/ipfs/go-block-format"
+	ipfs_cid "github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	ipfs_merkledag "github.com/ipfs/go-merkledag"
+	carv2 "github.com/ipld/go-car/v2"
+)
+
+// carImportBatchSize is the number of blocks written at once to the
+// blockstore when importing a CAR file
+const carImportBatchSize = 256
+
+// importCAR stores the blocks of the CAR (v1 or v2) file at path into the
+// blockstore, then recursively pins the roots of its header when pinRoots is
+// set. The garbage collector is blocked during the import.
+func (n *Node) importCAR(ctx context.Context, path string, pinRoots bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open car `%s`: %w", path, err)
+	}
+	defer f.Close()
+
+	inode := n.ipfsMobile.IpfsNode
+	unlocker := inode.Blockstore.PinLock(ctx)
+	defer unlocker.Unlock(ctx)
+
+	cr, err := carv2.NewBlockReader(f)
+	if err != nil {
+		return fmt.Errorf("invalid car `%s`: %w", path, err)
+	}
+
+	batch := make([]blocks.Block, 0, carImportBatchSize)
+	for {
+		blk, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read car `%s`: %w", path, err)
+		}
+
+		if batch = append(batch, blk); len(batch) == carImportBatchSize {
+			if err := inode.Blockstore.PutMany(ctx, batch); err != nil {
+				return fmt.Errorf("unable to store blocks of car `%s`: %w", path, err)
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := inode.Blockstore.PutMany(ctx, batch); err != nil {
+		return fmt.Errorf("unable to store blocks of car `%s`: %w", path, err)
+	}
+
+	if !pinRoots {
+		return nil
+	}
+
+	for _, root := range cr.Roots {
+		if err := n.pinRoot(ctx, root); err != nil {
+			return fmt.Errorf("unable to pin root `%s` of car `%s`: %w", root, path, err)
+		}
+	}
+	return inode.Pinning.Flush(ctx)
+}
+
+// pinRoot recursively pins the node c, the caller must hold the pin lock.
+// The DAG is first walked in the blockstore only, so a root with missing
+// blocks fails right away instead of fetching them from the network.
+func (n *Node) pinRoot(ctx context.Context, c ipfs_cid.Cid) error {
+	inode := n.ipfsMobile.IpfsNode
+
+	var root ipld.Node
+	getLinks := func(ctx context.Context, c ipfs_cid.Cid) ([]*ipld.Link, error) {
+		blk, err := inode.Blockstore.Get(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+
+		nd, err := ipld.Decode(blk)
+		if err != nil {
+			return nil, err
+		}
+
+		if root == nil {
+			root = nd
+		}
+		return nd.Links(), nil
+	}
+
+	visited := ipfs_cid.NewSet()
+	if err := ipfs_merkledag.Walk(ctx, getLinks, c, visited.Visit); err != nil {
+		return fmt.Errorf("incomplete dag: %w", err)
+	}
+
+	return inode.Pinning.Pin(ctx, root, true)
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+	ipld "github.com/ipfs/go-ipld-format"
+	ipfs_options "github.com/ipfs/interface-go-ipfs-core/options"
+	car "github.com/ipld/go-car"
+)
+
+// testingCAR exports a file to a CAR and returns its path and root, only the
+// root block is exported when rootOnly is set.
+func testingCAR(t *testing.T, dir string, content []byte, rootOnly bool) (string, ipfs_cid.Cid) {
+	t.Helper()
+
+	path, clean := testingTempDir(t, "car_repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	api, err := node.coreAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	resolved, err := api.Unixfs().Add(ctx, ipfs_files.NewBytesFile(content), ipfs_options.Unixfs.Pin(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.CreateTemp(dir, "*.car")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	walk := func(nd ipld.Node) ([]*ipld.Link, error) {
+		if rootOnly {
+			return nil, nil
+		}
+		return nd.Links(), nil
+	}
+
+	root := resolved.Cid()
+	if err := car.WriteCarWithWalker(ctx, node.ipfsMobile.IpfsNode.DAG, []ipfs_cid.Cid{root}, f, walk); err != nil {
+		t.Fatal(err)
+	}
+	carPath := f.Name()
+
+	return carPath, root
+}
+
+func TestNodePreloadCAR(t *testing.T) {
+	dir, clean := testingTempDir(t, "car")
+	defer clean()
+
+	carPath, root := testingCAR(t, dir, []byte("hello preload\n"), false)
+
+	t.Run("pin roots", func(t *testing.T) {
+		path, clean := testingTempDir(t, "repo")
+		defer clean()
+
+		repo, clean := testingRepo(t, path)
+		defer clean()
+
+		config := NewNodeConfig()
+		config.AddPreloadCAR(carPath)
+		config.SetPreloadPinRoots(true)
+
+		node, err := NewNode(repo, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer node.Close()
+
+		has, err := node.ipfsMobile.IpfsNode.Blockstore.Has(context.Background(), root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !has {
+			t.Fatal("the root of the car should be stored")
+		}
+
+		status, err := node.IsPinned(root.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != PinStatusRecursive {
+			t.Errorf("expected pin status `%s` got `%s`", PinStatusRecursive, status)
+		}
+	})
+
+	t.Run("incomplete dag", func(t *testing.T) {
+		path, clean := testingTempDir(t, "repo")
+		defer clean()
+
+		repo, clean := testingRepo(t, path)
+		defer clean()
+
+		// a file larger than a chunk, its leaves are not exported
+		partialPath, _ := testingCAR(t, dir, bytes.Repeat([]byte("a"), 1<<20), true)
+
+		config := NewNodeConfig()
+		config.AddPreloadCAR(partialPath)
+		config.SetPreloadPinRoots(true)
+
+		done := make(chan error, 1)
+		go func() {
+			node, err := NewNode(repo, config)
+			if err == nil {
+				node.Close()
+			}
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Fatal("expected an error pinning an incomplete dag")
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("pinning an incomplete dag didn't fail")
+		}
+	})
+
+	t.Run("missing car", func(t *testing.T) {
+		path, clean := testingTempDir(t, "repo")
+		defer clean()
+
+		repo, clean := testingRepo(t, path)
+		defer clean()
+
+		config := NewNodeConfig()
+		config.AddPreloadCAR(filepath.Join(dir, "missing.car"))
+
+		if node, err := NewNode(repo, config); err == nil {
+			node.Close()
+			t.Fatal("expected an error with a missing car")
+		}
+	})
+
+	t.Run("lenient", func(t *testing.T) {
+		path, clean := testingTempDir(t, "repo")
+		defer clean()
+
+		repo, clean := testingRepo(t, path)
+		defer clean()
+
+		config := NewNodeConfig()
+		config.AddPreloadCAR(filepath.Join(dir, "missing.car"))
+		config.SetPreloadLenient(true)
+
+		node, err := NewNode(repo, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.Close()
+	})
+}
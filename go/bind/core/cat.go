@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+	ipfs_path "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// DefaultMaxCatSize is the default maximum size in bytes of the content
+// returned by Cat, 32MiB
+const DefaultMaxCatSize = 32 << 20
+
+// Cat returns the content of the unixfs file at the given path, e.g.
+// `/ipfs/<cid>/file.txt` or a bare cid. It fails when the path points to a
+// directory or when the file is larger than the maximum set with
+// SetMaxCatSize, GetToFile should be used for large files. It waits for the
+// content for at most timeoutSeconds (no timeout when not positive), so a
+// missing block doesn't block the caller.
+func (n *Node) Cat(cidPath string, timeoutSeconds int64) ([]byte, error) {
+	ctx, cancel := n.catContext(timeoutSeconds)
+	defer cancel()
+
+	f, p, err := n.catFile(ctx, cidPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	limit := n.getMaxCatSize()
+	if size, err := f.Size(); err == nil && size > limit {
+		return nil, fmt.Errorf("`%s` is %d bytes, more than the maximum of %d", p, size, limit)
+	}
+
+	// the size is read from the root node, don't trust it
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read `%s`: %w", p, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("`%s` is larger than the maximum of %d bytes", p, limit)
+	}
+	return data, nil
+}
+
+// GetToFile writes the content of the unixfs file at the given path to
+// destPath, streaming it so large files are never held in memory. The file is
+// written next to destPath then renamed, destPath is left untouched when the
+// fetch fails. It waits for the content for at most timeoutSeconds (no
+// timeout when not positive).
+func (n *Node) GetToFile(cidPath string, destPath string, timeoutSeconds int64) error {
+	ctx, cancel := n.catContext(timeoutSeconds)
+	defer cancel()
+
+	f, p, err := n.catFile(ctx, cidPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(destPath), "."+filepath.Base(destPath)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to create `%s`: %w", destPath, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, f); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to get `%s`: %w", p, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write `%s`: %w", destPath, err)
+	}
+
+	if err := os.Rename(tmp.Name(), destPath); err != nil {
+		return fmt.Errorf("unable to write `%s`: %w", destPath, err)
+	}
+	return nil
+}
+
+// SetMaxCatSize sets the maximum size in bytes of the content returned by Cat
+// (default DefaultMaxCatSize).
+func (n *Node) SetMaxCatSize(size int64) error {
+	if size < 1 {
+		return fmt.Errorf("invalid max cat size %d", size)
+	}
+
+	atomic.StoreInt64(&n.maxCatSize, size)
+	return nil
+}
+
+func (n *Node) getMaxCatSize() int64 {
+	if size := atomic.LoadInt64(&n.maxCatSize); size > 0 {
+		return size
+	}
+	return DefaultMaxCatSize
+}
+
+func (n *Node) catContext(timeoutSeconds int64) (context.Context, context.CancelFunc) {
+	if timeoutSeconds > 0 {
+		return context.WithTimeout(n.ctx, time.Duration(timeoutSeconds)*time.Second)
+	}
+	return context.WithCancel(n.ctx)
+}
+
+// catFile returns the unixfs file at the given path, failing for a directory.
+func (n *Node) catFile(ctx context.Context, cidPath string) (ipfs_files.File, ipfs_path.Path, error) {
+	p, err := parsePath(cidPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nd, err := api.Unixfs().Get(ctx, p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get `%s`: %w", p, err)
+	}
+
+	f := ipfs_files.ToFile(nd)
+	if f == nil {
+		nd.Close()
+		if ipfs_files.ToDir(nd) != nil {
+			return nil, nil, fmt.Errorf("`%s` is a directory", p)
+		}
+		return nil, nil, fmt.Errorf("`%s` is not a file", p)
+	}
+	return f, p, nil
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNodeCat(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	content := []byte("content to cat")
+	c, err := node.AddBytes(content, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{c, "/ipfs/" + c} {
+		data, err := node.Cat(p, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, content) {
+			t.Errorf("expected `%s` got `%s`", content, data)
+		}
+	}
+
+	if err := node.SetMaxCatSize(0); err == nil {
+		t.Error("expected an error for an empty max size")
+	}
+	if err := node.SetMaxCatSize(4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node.Cat(c, 1); err == nil {
+		t.Error("expected an error for content larger than the max size")
+	}
+
+	dir := filepath.Join(path, "dir")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	dirCid, err := node.AddFile(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node.Cat(dirCid, 1); err == nil {
+		t.Error("expected an error for a directory")
+	}
+
+	// not available locally nor from any peer
+	missing := "bafkreigh2akiscaildcqabsyg3dfr6chu3fgpregiymsck7e7aqa4s52zy"
+	if _, err := node.Cat(missing, 1); err == nil {
+		t.Error("expected an error once the timeout expires")
+	}
+}
+
+func TestNodeGetToFile(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	content := bytes.Repeat([]byte("large content "), 100000)
+	c, err := node.AddBytes(content, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(path, "out.bin")
+	if err := node.GetToFile(c, dest, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Error("written content differs from the added one")
+	}
+
+	missing := "bafkreigh2akiscaildcqabsyg3dfr6chu3fgpregiymsck7e7aqa4s52zy"
+	missingDest := filepath.Join(path, "missing.bin")
+	if err := node.GetToFile(missing, missingDest, 1); err == nil {
+		t.Error("expected an error once the timeout expires")
+	}
+	if _, err := os.Stat(missingDest); !os.IsNotExist(err) {
+		t.Error("expected no file for a failed fetch")
+	}
+
+	tmps, err := filepath.Glob(filepath.Join(path, ".missing.bin.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tmps) != 0 {
+		t.Errorf("unexpected temporary files `%v`", tmps)
+	}
+}
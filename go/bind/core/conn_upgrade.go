@@ -0,0 +1,77 @@
+package core
+
+import (
+	"github.com/libp2p/go-libp2p/core/network"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// upgradeEventBufferSize is the number of pending upgrade events a slow
+// UpgradeHandler can lag behind before events start being dropped
+const upgradeEventBufferSize = 64
+
+// UpgradeHandler is notified when a relayed connection to a peer is upgraded
+// to a direct one (hole punching), it is called from a dedicated goroutine.
+type UpgradeHandler interface {
+	OnConnectionUpgraded(peerID string, directAddr string)
+}
+
+type upgradeEvent struct {
+	peer string
+	addr string
+}
+
+// SubscribeConnectionUpgrades registers a handler notified every time a
+// direct connection is opened to a peer we were only reachable through a
+// relay, and returns the id of the subscription, see CancelSubscription. The
+// handler goroutine stops when the node is closed.
+func (n *Node) SubscribeConnectionUpgrades(handler UpgradeHandler) string {
+	id, ctx, done := n.newSubscription(SubscriptionKindConnectionUpgrades)
+	events := make(chan upgradeEvent, upgradeEventBufferSize)
+
+	notifiee := &network.NotifyBundle{
+		ConnectedF: func(net network.Network, conn network.Conn) {
+			if isRelayedAddr(conn.RemoteMultiaddr()) {
+				return
+			}
+
+			// a direct connection while a relayed one is still open means
+			// the connection has been upgraded
+			pid := conn.RemotePeer()
+			for _, c := range net.ConnsToPeer(pid) {
+				if c == conn || !isRelayedAddr(c.RemoteMultiaddr()) {
+					continue
+				}
+
+				select {
+				case events <- upgradeEvent{peer: pid.String(), addr: conn.RemoteMultiaddr().String()}:
+				default:
+				}
+				return
+			}
+		},
+	}
+
+	net := n.ipfsMobile.PeerHost().Network()
+	net.Notify(notifiee)
+
+	go func() {
+		defer done()
+		defer net.StopNotify(notifiee)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt := <-events:
+				handler.OnConnectionUpgraded(evt.peer, evt.addr)
+			}
+		}
+	}()
+
+	return id
+}
+
+func isRelayedAddr(maddr ma.Multiaddr) bool {
+	_, err := maddr.ValueForProtocol(ma.P_CIRCUIT)
+	return err == nil
+}
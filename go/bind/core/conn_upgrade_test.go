@@ -0,0 +1,26 @@
+package core
+
+import (
+	"testing"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestIsRelayedAddr(t *testing.T) {
+	cases := map[string]bool{
+		"/ip4/1.2.3.4/tcp/4001":      false,
+		"/ip4/1.2.3.4/udp/4001/quic": false,
+		"/ip4/1.2.3.4/tcp/4001/p2p/12D3KooWDpJ7As7BWAwRMfu1VU2WCqNjvq387JEYKDBj4kx6nXTN/p2p-circuit": true,
+	}
+
+	for addr, expected := range cases {
+		maddr, err := ma.NewMultiaddr(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if relayed := isRelayedAddr(maddr); relayed != expected {
+			t.Errorf("`%s`: expected relayed to be %t got %t", addr, expected, relayed)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	ipfs_log "github.com/ipfs/go-log/v2"
+)
+
+// debugLogFile writes the ipfs logs to a file, rotated once it reaches
+// maxBytes. The rotated files are named `<path>.1` (the most recent) to
+// `<path>.<maxFiles>`.
+type debugLogFile struct {
+	path     string
+	maxBytes int64
+	maxFiles int
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	closed bool
+	// failing is set while the writes fail, so the failure is reported once
+	failing bool
+
+	pipe      *ipfs_log.PipeReader
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// startDebugLog opens the log file at path and starts copying the ipfs logs
+// into it until closed.
+func startDebugLog(path string, maxBytes int64, maxFiles int) (*debugLogFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("unable to create the log directory: %w", err)
+	}
+
+	l := &debugLogFile{
+		path:     path,
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+		done:     make(chan struct{}),
+	}
+	if err := l.open(os.O_APPEND); err != nil {
+		return nil, err
+	}
+
+	l.pipe = ipfs_log.NewPipeReader(ipfs_log.PipeFormat(ipfs_log.PlaintextOutput))
+	go func() {
+		defer close(l.done)
+		l.drain()
+	}()
+
+	return l, nil
+}
+
+// drain copies the logs to the file until the pipe is closed. It keeps
+// reading when a write fails, the loggers of the process would otherwise
+// block on the pipe.
+func (l *debugLogFile) drain() {
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := l.pipe.Read(buf)
+		if n > 0 {
+			l.report(l.Write(buf[:n]))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// report logs the first of consecutive write failures, not through the ipfs
+// logs as they are the ones failing.
+func (l *debugLogFile) report(_ int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case err != nil && !l.failing:
+		log.Printf("unable to write the debug log, dropping logs: %s", err)
+		l.failing = true
+	case err == nil && l.failing:
+		log.Printf("debug log written again")
+		l.failing = false
+	}
+}
+
+func (l *debugLogFile) open(flag int) error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|flag, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to open log file: %w", err)
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("unable to open log file: %w", err)
+	}
+
+	l.f, l.size = f, stat.Size()
+	return nil
+}
+
+func (l *debugLogFile) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return 0, os.ErrClosed
+	}
+
+	// a failed rotation left no file, try again
+	if l.f == nil {
+		if err := l.open(os.O_APPEND); err != nil {
+			return 0, err
+		}
+	}
+
+	if l.size > 0 && l.size+int64(len(p)) > l.maxBytes {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the rotated files, dropping the oldest one, and starts a new
+// log file.
+func (l *debugLogFile) rotate() error {
+	l.f.Close()
+	l.f = nil
+
+	if l.maxFiles > 0 {
+		for i := l.maxFiles - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+		}
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return fmt.Errorf("unable to rotate log file: %w", err)
+		}
+	}
+
+	return l.open(os.O_TRUNC)
+}
+
+// Close stops writing the logs and closes the file, closing it again is a
+// no-op.
+func (l *debugLogFile) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		l.pipe.Close()
+		<-l.done
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.closed = true
+		if l.f != nil {
+			err = l.f.Close()
+			l.f = nil
+		}
+	})
+	return err
+}
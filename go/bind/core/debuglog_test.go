@@ -0,0 +1,138 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ipfs_log "github.com/ipfs/go-log/v2"
+)
+
+func TestDebugLogFileRotate(t *testing.T) {
+	dir, clean := testingTempDir(t, "logs")
+	defer clean()
+
+	path := filepath.Join(dir, "ipfs-debug.log")
+	l := &debugLogFile{path: path, maxBytes: 10, maxFiles: 2}
+	if err := l.open(os.O_APPEND); err != nil {
+		t.Fatal(err)
+	}
+	defer l.f.Close()
+
+	for _, line := range []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"} {
+		if _, err := l.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, expected := range map[string]string{
+		"ipfs-debug.log":   "line 4\n",
+		"ipfs-debug.log.1": "line 3\n",
+		"ipfs-debug.log.2": "line 2\n",
+	} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != expected {
+			t.Errorf("expected `%s` to hold `%q` got `%q`", name, expected, content)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("expected the oldest file to be dropped")
+	}
+}
+
+func TestDebugLogFileKeepsDraining(t *testing.T) {
+	dir, clean := testingTempDir(t, "logs")
+	defer clean()
+
+	// a non empty directory in the way of the rotated file makes the
+	// rotations fail
+	path := filepath.Join(dir, "ipfs-debug.log")
+	if err := os.MkdirAll(filepath.Join(path+".1", "busy"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := startDebugLog(path, 64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	logger := ipfs_log.Logger("test")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			logger.Errorf("line %d", i)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the loggers not to block once the writes fail")
+	}
+
+	// the logs are written again once the rotation works
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	logger.Error("written after the failures")
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "written after the failures") {
+		t.Errorf("expected the log line in `%s`", content)
+	}
+}
+
+func TestNodeDebugLogFile(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	config := NewNodeConfig()
+	if err := config.SetDebugLogFile("", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetDebugLogFile("ipfs.log", 0, 1); err == nil {
+		t.Error("expected an error without max size")
+	}
+
+	logPath := filepath.Join(path, "logs", "ipfs-debug.log")
+	if err := config.SetDebugLogFile(logPath, 1<<20, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ipfs_log.Logger("test").Error("written to the debug log")
+
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "written to the debug log") {
+		t.Errorf("expected the log line in `%s`", content)
+	}
+}
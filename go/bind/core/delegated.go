@@ -0,0 +1,85 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	ipfs_config "github.com/ipfs/kubo/config"
+)
+
+// delegatedRouterTimeout is the time given to each router of the delegated
+// routing to answer a query
+const delegatedRouterTimeout = time.Minute
+
+// parseDelegatedEndpoints parses a newline or comma separated list of http(s)
+// urls.
+func parseDelegatedEndpoints(endpoints string) ([]string, error) {
+	var out, invalid []string
+	for _, endpoint := range strings.FieldsFunc(endpoints, func(r rune) bool {
+		return r == '\n' || r == ','
+	}) {
+		endpoint = strings.TrimSpace(endpoint)
+		if endpoint == "" {
+			continue
+		}
+
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid = append(invalid, fmt.Sprintf("`%s`", endpoint))
+			continue
+		}
+		out = append(out, endpoint)
+	}
+
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid delegated routing endpoints, expected http(s) urls: %s", strings.Join(invalid, ", "))
+	}
+	return out, nil
+}
+
+// delegatedRoutingConfig returns the kubo custom routing config querying the
+// reframe endpoints, along with the DHT when withDHT is set, in parallel for
+// every method.
+func delegatedRoutingConfig(endpoints []string, withDHT bool) (ipfs_config.Routers, ipfs_config.Methods) {
+	const parallelRouter = "parallel"
+
+	routers := ipfs_config.Routers{}
+	var composed []ipfs_config.ConfigRouter
+	add := func(name string, router ipfs_config.Router) {
+		routers[name] = ipfs_config.RouterParser{Router: router}
+		composed = append(composed, ipfs_config.ConfigRouter{
+			RouterName:   name,
+			Timeout:      ipfs_config.Duration{Duration: delegatedRouterTimeout},
+			IgnoreErrors: true,
+		})
+	}
+
+	for i, endpoint := range endpoints {
+		add(fmt.Sprintf("delegated-%d", i), ipfs_config.Router{
+			Type:       ipfs_config.RouterTypeReframe,
+			Parameters: &ipfs_config.ReframeRouterParams{Endpoint: endpoint},
+		})
+	}
+	if withDHT {
+		add("dht", ipfs_config.Router{
+			Type: ipfs_config.RouterTypeDHT,
+			Parameters: &ipfs_config.DHTRouterParams{
+				Mode:            ipfs_config.DHTModeAuto,
+				PublicIPNetwork: true,
+			},
+		})
+	}
+
+	routers[parallelRouter] = ipfs_config.RouterParser{Router: ipfs_config.Router{
+		Type:       ipfs_config.RouterTypeParallel,
+		Parameters: &ipfs_config.ComposableRouterParams{Routers: composed},
+	}}
+
+	methods := ipfs_config.Methods{}
+	for _, method := range ipfs_config.MethodNameList {
+		methods[method] = ipfs_config.Method{RouterName: parallelRouter}
+	}
+	return routers, methods
+}
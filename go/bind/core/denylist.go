@@ -0,0 +1,127 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+	ipfs_core "github.com/ipfs/kubo/core"
+	ipfs_corehttp "github.com/ipfs/kubo/core/corehttp"
+)
+
+// gatewayDenylist holds the content the gateways refuse to serve, keyed by
+// multihash so both cid versions of a same content are blocked.
+type gatewayDenylist struct {
+	mu     sync.RWMutex
+	hashes map[string]struct{}
+}
+
+func newGatewayDenylist() *gatewayDenylist {
+	return &gatewayDenylist{hashes: make(map[string]struct{})}
+}
+
+func (d *gatewayDenylist) set(cids []string) error {
+	hashes := make(map[string]struct{}, len(cids))
+	for _, c := range cids {
+		dc, err := ipfs_cid.Decode(c)
+		if err != nil {
+			return fmt.Errorf("invalid cid `%s`: %w", c, err)
+		}
+		hashes[string(dc.Hash())] = struct{}{}
+	}
+
+	d.mu.Lock()
+	d.hashes = hashes
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *gatewayDenylist) denied(c string) bool {
+	dc, err := ipfs_cid.Decode(c)
+	if err != nil {
+		return false
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	_, ok := d.hashes[string(dc.Hash())]
+	return ok
+}
+
+// blocked reports whether r requests denied content, either through a path
+// `/ipfs/<cid>/...` or a subdomain `<cid>.ipfs.<host>`.
+func (d *gatewayDenylist) blocked(r *http.Request) bool {
+	d.mu.RLock()
+	empty := len(d.hashes) == 0
+	d.mu.RUnlock()
+	if empty {
+		return false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 3)
+	if len(parts) >= 2 && parts[0] == "ipfs" && d.denied(parts[1]) {
+		return true
+	}
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	labels := strings.SplitN(host, ".", 3)
+	return len(labels) >= 2 && labels[1] == "ipfs" && d.denied(labels[0])
+}
+
+// serveOption answers 410 Gone to the requests of denied content before
+// handing the others to the next options.
+func (d *gatewayDenylist) serveOption() ipfs_corehttp.ServeOption {
+	return func(_ *ipfs_core.IpfsNode, _ net.Listener, mux *http.ServeMux) (*http.ServeMux, error) {
+		childMux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if d.blocked(r) {
+				http.Error(w, "content blocked", http.StatusGone)
+				return
+			}
+			childMux.ServeHTTP(w, r)
+		})
+		return childMux, nil
+	}
+}
+
+// SetGatewayDenylist sets the cids the gateways answer 410 Gone for, a
+// newline or comma separated list replacing the previous one. It applies to
+// the running gateways right away, an empty list serves everything again.
+func (n *Node) SetGatewayDenylist(cids string) error {
+	return n.denylist.set(splitList(cids))
+}
+
+// SetGatewayDenylistFile sets the gateway denylist from a file holding one cid
+// per line, empty lines and lines starting with `#` are ignored. The file is
+// read once, call it again to reload it.
+func (n *Node) SetGatewayDenylistFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open denylist: %w", err)
+	}
+	defer f.Close()
+
+	var cids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cids = append(cids, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("unable to read denylist: %w", err)
+	}
+
+	return n.denylist.set(cids)
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/ipfsutil"
+	ds "github.com/ipfs/go-datastore"
+	ipfs_p2p "github.com/ipfs/kubo/core/node/libp2p"
+	p2p_dht "github.com/libp2p/go-libp2p-kad-dht"
+	p2p_dual "github.com/libp2p/go-libp2p-kad-dht/dual"
+	p2p_record "github.com/libp2p/go-libp2p-record"
+	p2p_host "github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+	p2p_routing "github.com/libp2p/go-libp2p/core/routing"
+)
+
+// dhtRoutingOption builds the default (auto mode) DHT routing on top of a
+// ReachabilityHost, handed to capture, so the mode of the DHT can be switched
+// at runtime by overriding the reachability it sees. The peers the DHT
+// connects to are recorded by tracker and the provides of the WAN DHT are
+// throttled by limiter.
+func dhtRoutingOption(tracker *peerTracker, limiter *provideLimiter, capture func(*ipfsutil.ReachabilityHost)) ipfs_p2p.RoutingOption {
+	return func(
+		ctx context.Context,
+		host p2p_host.Host,
+		dstore ds.Batching,
+		validator p2p_record.Validator,
+		bootstrapPeers ...p2p_peer.AddrInfo,
+	) (p2p_routing.Routing, error) {
+		rh := ipfsutil.NewReachabilityHost(host)
+		capture(rh)
+		th := &trackingHost{Host: rh, tracker: tracker}
+
+		wanStore, err := newThrottledProviderStore(ctx, th, dstore, limiter)
+		if err != nil {
+			return nil, err
+		}
+
+		// same options as the kubo DHTOption
+		return p2p_dual.New(
+			ctx, th,
+			p2p_dual.DHTOption(
+				p2p_dht.Concurrency(10),
+				p2p_dht.Mode(p2p_dht.ModeAuto),
+				p2p_dht.Datastore(dstore),
+				p2p_dht.Validator(validator)),
+			p2p_dual.WanDHTOption(
+				p2p_dht.BootstrapPeers(bootstrapPeers...),
+				p2p_dht.ProviderStore(wanStore)),
+		)
+	}
+}
+
+// DHT modes accepted by SetDHTMode
+const (
+	DHTModeAuto   = "auto"
+	DHTModeClient = "client"
+	DHTModeServer = "server"
+)
+
+// SetDHTServerMode switches the running DHT to server mode, answering the
+// queries of other peers, or to client mode when disabled. It overrides the
+// automatic mode selection based on the node reachability.
+func (n *Node) SetDHTServerMode(enabled bool) error {
+	if enabled {
+		return n.SetDHTMode(DHTModeServer)
+	}
+	return n.SetDHTMode(DHTModeClient)
+}
+
+// SetDHTMode switches the running DHT to `server` mode, answering the
+// queries of other peers, to `client` mode, or back to `auto` mode (default)
+// where the mode follows the reachability detected by AutoNAT. It fails when
+// the node runs without the DHT or with the accelerated DHT client.
+func (n *Node) SetDHTMode(mode string) error {
+	var reachability network.Reachability
+	switch mode {
+	case DHTModeAuto:
+		reachability = network.ReachabilityUnknown
+	case DHTModeClient:
+		reachability = network.ReachabilityPrivate
+	case DHTModeServer:
+		reachability = network.ReachabilityPublic
+	default:
+		return fmt.Errorf("invalid dht mode `%s`, expected `%s`, `%s` or `%s`",
+			mode, DHTModeAuto, DHTModeClient, DHTModeServer)
+	}
+
+	if n.dhtHost == nil || n.ipfsMobile.IpfsNode.DHT == nil {
+		return errors.New("dht is not initialized")
+	}
+
+	n.dhtHost.SetReachability(reachability)
+	return nil
+}
+
+// BootstrapDHT refreshes the routing table of the WAN DHT, looking up peers
+// close to the node, and waits for the refresh to complete or for
+// timeoutSeconds to expire (no timeout when not positive). It helps getting a
+// fresh routing table after a network change, before a critical fetch. It
+// fails when the DHT is disabled or runs in client mode.
+func (n *Node) BootstrapDHT(timeoutSeconds int64) error {
+	dual := n.ipfsMobile.IpfsNode.DHT
+	if dual == nil || dual.WAN == nil {
+		return errors.New("dht is disabled")
+	}
+
+	if dual.WAN.Mode() != p2p_dht.ModeServer {
+		return errors.New("dht runs in client mode, switch it to server mode to bootstrap it")
+	}
+
+	ctx := n.ctx
+	if timeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	select {
+	case err := <-dual.WAN.RefreshRoutingTable():
+		if err != nil {
+			return fmt.Errorf("unable to refresh the dht routing table: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("dht bootstrap did not complete: %w", ctx.Err())
+	}
+}
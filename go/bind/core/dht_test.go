@@ -0,0 +1,94 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+// dhtProtocol is only handled by the WAN DHT when running in server mode
+const dhtProtocol = "/ipfs/kad/1.0.0"
+
+func TestNodeSetDHTServerMode(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	handlesDHT := func() bool {
+		return handlesProtocol(node.ipfsMobile.PeerHost().Mux().Protocols(), dhtProtocol)
+	}
+
+	waitServerMode := func(expected bool) {
+		t.Helper()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for handlesDHT() != expected {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected dht server mode to be %t", expected)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	if err := node.SetDHTServerMode(true); err != nil {
+		t.Fatal(err)
+	}
+	waitServerMode(true)
+
+	if err := node.SetDHTServerMode(false); err != nil {
+		t.Fatal(err)
+	}
+	waitServerMode(false)
+}
+
+func TestNodeBootstrapDHT(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if err := node.SetDHTServerMode(false); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for handlesProtocol(node.ipfsMobile.PeerHost().Mux().Protocols(), dhtProtocol) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected dht to switch to client mode")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if err := node.BootstrapDHT(1); err == nil {
+		t.Fatal("expected an error in client mode")
+	}
+}
+
+func TestNodeSetDHTMode(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if err := node.SetDHTMode("fast"); err == nil {
+		t.Error("expected an invalid mode to be refused")
+	}
+
+	for mode, expected := range map[string]network.Reachability{
+		DHTModeServer: network.ReachabilityPublic,
+		DHTModeClient: network.ReachabilityPrivate,
+		DHTModeAuto:   network.ReachabilityUnknown,
+	} {
+		if err := node.SetDHTMode(mode); err != nil {
+			t.Fatal(err)
+		}
+		if got := node.dhtHost.Reachability(); got != expected {
+			t.Errorf("expected `%s` to override the reachability with `%s` got `%s`", mode, expected, got)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ExportDiagnostics writes a zip archive meant to be attached to bug reports
+// at destPath. It contains the following files:
+//   - `info.json`: the peer id, the node readiness state and the export date
+//   - `config.json`: the repo config, the private key of the identity and the
+//     remote pinning services keys are always removed
+//   - `peers.json`: the connected peers with their address and direction
+//   - `bandwidth.json`: the total bandwidth stats of the node
+//   - `protocols.json`: the bandwidth stats per protocol
+//   - `routing_table.json`: the peers of the WAN and LAN DHT routing tables
+//   - `logs.txt`: the recent log lines returned by GetRecentLogs
+func (n *Node) ExportDiagnostics(destPath string) error {
+	inode := n.ipfsMobile.IpfsNode
+
+	cfg, err := n.ipfsMobile.Repo.Config()
+	if err != nil {
+		return fmt.Errorf("unable to get config: %w", err)
+	}
+
+	// never leak secrets in the bundle
+	cfg, err = cfg.Clone()
+	if err != nil {
+		return fmt.Errorf("unable to copy config: %w", err)
+	}
+	cfg.Identity.PrivKey = ""
+	for name, service := range cfg.Pinning.RemoteServices {
+		service.API.Key = ""
+		cfg.Pinning.RemoteServices[name] = service
+	}
+
+	entries := []struct {
+		name  string
+		value interface{}
+	}{
+		{"info.json", map[string]string{
+			"PeerID":    inode.Identity.String(),
+			"Readiness": n.ReadinessState(),
+			"Date":      time.Now().UTC().Format(time.RFC3339),
+		}},
+		{"config.json", cfg},
+		{"peers.json", n.diagnosticsPeers()},
+		{"bandwidth.json", n.diagnosticsBandwidth()},
+		{"protocols.json", n.diagnosticsProtocols()},
+		{"routing_table.json", n.diagnosticsRoutingTable()},
+		{"logs.txt", []byte(strings.Join(recentLogs.last(0), "\n"))},
+	}
+
+	f, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("unable to create diagnostics file: %w", err)
+	}
+
+	zw := zip.NewWriter(f)
+	for _, entry := range entries {
+		w, err := zw.Create(entry.name)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("unable to add `%s`: %w", entry.name, err)
+		}
+
+		// raw entries are written as is
+		if raw, ok := entry.value.([]byte); ok {
+			_, err = w.Write(raw)
+		} else {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(entry.value)
+		}
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("unable to write `%s`: %w", entry.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write diagnostics archive: %w", err)
+	}
+
+	return f.Close()
+}
+
+func (n *Node) diagnosticsPeers() []map[string]string {
+	peers := []map[string]string{}
+
+	host := n.ipfsMobile.PeerHost()
+	if host == nil {
+		return peers
+	}
+
+	for _, conn := range host.Network().Conns() {
+		peers = append(peers, map[string]string{
+			"Peer":      conn.RemotePeer().String(),
+			"Addr":      conn.RemoteMultiaddr().String(),
+			"Direction": conn.Stat().Direction.String(),
+		})
+	}
+	return peers
+}
+
+func (n *Node) diagnosticsBandwidth() *BandwidthStats {
+	reporter := n.ipfsMobile.IpfsNode.Reporter
+	if reporter == nil {
+		return nil
+	}
+
+	return newBandwidthStats(reporter.GetBandwidthTotals())
+}
+
+func (n *Node) diagnosticsProtocols() map[string]*BandwidthStats {
+	protocols := map[string]*BandwidthStats{}
+
+	reporter := n.ipfsMobile.IpfsNode.Reporter
+	if reporter == nil {
+		return protocols
+	}
+
+	for proto, stats := range reporter.GetBandwidthByProtocol() {
+		protocols[string(proto)] = newBandwidthStats(stats)
+	}
+	return protocols
+}
+
+func (n *Node) diagnosticsRoutingTable() map[string][]string {
+	tables := map[string][]string{}
+
+	dht := n.ipfsMobile.IpfsNode.DHT
+	if dht == nil {
+		return tables
+	}
+
+	if dht.WAN != nil {
+		tables["WAN"] = peerIDStrings(dht.WAN.RoutingTable().ListPeers())
+	}
+	if dht.LAN != nil {
+		tables["LAN"] = peerIDStrings(dht.LAN.RoutingTable().ListPeers())
+	}
+	return tables
+}
+
+func peerIDStrings(pids []peer.ID) []string {
+	out := make([]string, len(pids))
+	for i, pid := range pids {
+		out[i] = pid.String()
+	}
+	return out
+}
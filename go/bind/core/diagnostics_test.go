@@ -0,0 +1,61 @@
+package core
+
+import (
+	"archive/zip"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNodeExportDiagnostics(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	dest := filepath.Join(path, "diagnostics.zip")
+	if err := node.ExportDiagnostics(dest); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := node.ipfsMobile.Repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Identity.PrivKey == "" {
+		t.Fatal("exporting diagnostics should not alter the repo config")
+	}
+
+	zr, err := zip.OpenReader(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	files := map[string]bool{}
+	for _, f := range zr.File {
+		files[f.Name] = true
+
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(string(content), cfg.Identity.PrivKey) {
+			t.Errorf("`%s` leaks the identity private key", f.Name)
+		}
+	}
+
+	for _, name := range []string{"info.json", "config.json", "peers.json", "bandwidth.json", "protocols.json", "routing_table.json", "logs.txt"} {
+		if !files[name] {
+			t.Errorf("`%s` is missing from the diagnostics archive", name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	p2p_swarm "github.com/libp2p/go-libp2p/p2p/net/swarm"
+)
+
+// DefaultMaxConcurrentDials is the default maximum number of concurrent dials
+// of the swarm, libp2p allows 160 which is a lot for a phone.
+const DefaultMaxConcurrentDials = 32
+
+// swarmDialLimitEnv is read by the swarm when it is constructed, it is the
+// only way to set its dial limit: go-libp2p v0.23 has no swarm option for it
+// and doesn't let the host take swarm options.
+const swarmDialLimitEnv = "LIBP2P_SWARM_FD_LIMIT"
+
+// muSwarmDialLimit serializes all the nodes constructions, so a swarm never
+// reads the dial limit of a node constructed concurrently.
+var muSwarmDialLimit sync.Mutex
+
+// useDialLimit makes the next swarm constructed use the given dial limit
+// until the returned function is called, 0 uses the default of libp2p.
+func useDialLimit(max int) (restore func()) {
+	muSwarmDialLimit.Lock()
+	prev, set := os.LookupEnv(swarmDialLimitEnv)
+	if max == 0 {
+		os.Unsetenv(swarmDialLimitEnv)
+	} else {
+		os.Setenv(swarmDialLimitEnv, fmt.Sprint(max))
+	}
+	return func() {
+		if set {
+			os.Setenv(swarmDialLimitEnv, prev)
+		} else {
+			os.Unsetenv(swarmDialLimitEnv)
+		}
+		muSwarmDialLimit.Unlock()
+	}
+}
+
+// DialStats is returned by Node.DialStats.
+type DialStats struct {
+	// MaxConcurrentDials is the limit of the swarm on the TCP and unix
+	// dials, 0 is the default of libp2p. QUIC dials are not limited.
+	MaxConcurrentDials int
+	// MaxDialsPerPeer is the maximum number of addresses of a same peer
+	// dialed at once.
+	MaxDialsPerPeer int
+	// Active is the number of outbound connections being established, -1
+	// when the resource manager is disabled.
+	Active int
+}
+
+// DialStats returns the JSON encoded DialStats of the swarm. The dials
+// waiting for a slot are not exposed by libp2p and can't be reported.
+func (n *Node) DialStats() (string, error) {
+	stats := &DialStats{
+		MaxConcurrentDials: n.maxDials,
+		MaxDialsPerPeer:    p2p_swarm.DefaultPerPeerRateLimit,
+		Active:             -1,
+	}
+
+	// connections being established are held by the transient scope until
+	// they are upgraded and attached to their peer
+	if mgr := n.ipfsMobile.IpfsNode.ResourceManager; mgr != nil {
+		err := mgr.ViewTransient(func(s network.ResourceScope) error {
+			if _, err := scopeUsage(s); err == nil {
+				stats.Active = s.Stat().NumConnsOutbound
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	out, err := json.Marshal(stats)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNodeDialStats(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	config := NewNodeConfig()
+	if err := config.SetMaxConcurrentDials(-1); err == nil {
+		t.Error("expected a negative limit to be refused")
+	}
+	if err := config.SetMaxConcurrentDials(4); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	if _, set := os.LookupEnv(swarmDialLimitEnv); set {
+		t.Errorf("expected `%s` to be restored", swarmDialLimitEnv)
+	}
+
+	out, err := node.DialStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stats DialStats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.MaxConcurrentDials != 4 {
+		t.Errorf("expected a limit of 4 got %d", stats.MaxConcurrentDials)
+	}
+
+	// the resource manager is disabled in the testing config
+	if stats.Active != -1 {
+		t.Errorf("expected unknown active dials got %d", stats.Active)
+	}
+}
+
+func TestUseDialLimit(t *testing.T) {
+	t.Setenv(swarmDialLimitEnv, "3")
+
+	restore := useDialLimit(0)
+	if v, set := os.LookupEnv(swarmDialLimitEnv); set {
+		t.Errorf("expected `%s` to be unset got `%s`", swarmDialLimitEnv, v)
+	}
+
+	// a concurrent construction waits for the first one to restore the limit
+	done := make(chan string)
+	go func() {
+		restore := useDialLimit(4)
+		done <- os.Getenv(swarmDialLimitEnv)
+		restore()
+	}()
+
+	select {
+	case v := <-done:
+		t.Fatalf("expected the second construction to wait, got limit `%s`", v)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	restore()
+	if v := <-done; v != "4" {
+		t.Errorf("expected limit `4` got `%s`", v)
+	}
+
+	muSwarmDialLimit.Lock()
+	v := os.Getenv(swarmDialLimitEnv)
+	muSwarmDialLimit.Unlock()
+	if v != "3" {
+		t.Errorf("expected `%s` to be restored to `3` got `%s`", swarmDialLimitEnv, v)
+	}
+}
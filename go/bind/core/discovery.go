@@ -0,0 +1,259 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	p2p_host "github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+	p2p_mdns "github.com/libp2p/go-libp2p/p2p/discovery/mdns"
+)
+
+// Discovery sources of the peers returned by DiscoveredPeers
+const (
+	DiscoverySourceMDNS      = "mdns"
+	DiscoverySourceDHT       = "dht"
+	DiscoverySourceBootstrap = "bootstrap"
+	DiscoverySourcePeering   = "peering"
+)
+
+const (
+	// maxDiscoveredPeers is the number of discovered peers kept, the least
+	// recently seen peer is dropped first.
+	maxDiscoveredPeers = 256
+	// discoveredPeerTTL is the time after which a peer that hasn't been
+	// seen again is dropped.
+	discoveredPeerTTL = time.Hour
+)
+
+// DiscoveredPeer is a peer returned by DiscoveredPeers.
+type DiscoveredPeer struct {
+	Peer   string
+	Source string
+	// LastSeen is the unix time in milliseconds at which the peer was last
+	// discovered.
+	LastSeen int64
+}
+
+type discoveredPeer struct {
+	source   string
+	lastSeen time.Time
+}
+
+// peerTracker records the recently discovered peers along with the source
+// that discovered them.
+type peerTracker struct {
+	mu     sync.Mutex
+	static map[p2p_peer.ID]string
+	peers  map[p2p_peer.ID]*discoveredPeer
+}
+
+func newPeerTracker() *peerTracker {
+	return &peerTracker{
+		static: make(map[p2p_peer.ID]string),
+		peers:  make(map[p2p_peer.ID]*discoveredPeer),
+	}
+}
+
+// addStatic registers peers known from the config, they are reported with
+// the given source however they got discovered.
+func (t *peerTracker) addStatic(peers []p2p_peer.AddrInfo, source string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, pi := range peers {
+		t.static[pi.ID] = source
+	}
+}
+
+func (t *peerTracker) found(pid p2p_peer.ID, source string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if s, ok := t.static[pid]; ok {
+		source = s
+	}
+
+	// keep the source that discovered the peer first
+	if dp, ok := t.peers[pid]; ok {
+		dp.lastSeen = time.Now()
+		return
+	}
+
+	if len(t.peers) >= maxDiscoveredPeers {
+		t.evictOldest()
+	}
+	t.peers[pid] = &discoveredPeer{source: source, lastSeen: time.Now()}
+}
+
+func (t *peerTracker) evictOldest() {
+	var oldest p2p_peer.ID
+	var oldestSeen time.Time
+	for pid, dp := range t.peers {
+		if oldest == "" || dp.lastSeen.Before(oldestSeen) {
+			oldest, oldestSeen = pid, dp.lastSeen
+		}
+	}
+	delete(t.peers, oldest)
+}
+
+// list returns the discovered peers, most recently seen first.
+func (t *peerTracker) list() []DiscoveredPeer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	expired := time.Now().Add(-discoveredPeerTTL)
+	peers := make([]DiscoveredPeer, 0, len(t.peers))
+	for pid, dp := range t.peers {
+		if dp.lastSeen.Before(expired) {
+			delete(t.peers, pid)
+			continue
+		}
+
+		peers = append(peers, DiscoveredPeer{
+			Peer:     pid.String(),
+			Source:   dp.source,
+			LastSeen: dp.lastSeen.UnixMilli(),
+		})
+	}
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].LastSeen > peers[j].LastSeen
+	})
+	return peers
+}
+
+// watchStatic records the bootstrap and peering peers when the host connects
+// to them, until ctx is done.
+func (t *peerTracker) watchStatic(ctx context.Context, h p2p_host.Host) {
+	sub, err := h.EventBus().Subscribe(new(event.EvtPeerConnectednessChanged))
+	if err != nil {
+		log.Printf("unable to track discovered peers: %s", err)
+		return
+	}
+	defer sub.Close()
+
+	foundStatic := func(pid p2p_peer.ID) {
+		t.mu.Lock()
+		source, ok := t.static[pid]
+		t.mu.Unlock()
+
+		if ok {
+			t.found(pid, source)
+		}
+	}
+
+	// peers connected before the subscription
+	for _, pid := range h.Network().Peers() {
+		foundStatic(pid)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-sub.Out():
+			if !ok {
+				return
+			}
+
+			evt := e.(event.EvtPeerConnectednessChanged)
+			if evt.Connectedness == network.Connected {
+				foundStatic(evt.Peer)
+			}
+		}
+	}
+}
+
+// MDNSPeerHandler is notified of the peers found on the local network by mDNS,
+// see Node.SetMDNSPeerHandler.
+type MDNSPeerHandler interface {
+	// OnPeerFound receives the id of the peer and its addresses separated by
+	// a newline.
+	OnPeerFound(peerID string, addrs string)
+}
+
+// mdnsNotifee records the peers found by mDNS before handing them to the
+// wrapped notifee, connecting to them, and to the handler of the node.
+type mdnsNotifee struct {
+	p2p_mdns.Notifee
+	tracker *peerTracker
+	node    *Node
+
+	// self is the id of the node, mDNS also finds it
+	self p2p_peer.ID
+}
+
+func (n *mdnsNotifee) HandlePeerFound(pi p2p_peer.AddrInfo) {
+	if pi.ID == n.self {
+		return
+	}
+
+	n.tracker.found(pi.ID, DiscoverySourceMDNS)
+
+	// the app learns about the peer before the dial, which may take up to
+	// the discovery timeout
+	if handler := n.node.getMDNSPeerHandler(); handler != nil {
+		addrs := make([]string, len(pi.Addrs))
+		for i, addr := range pi.Addrs {
+			addrs[i] = addr.String()
+		}
+		handler.OnPeerFound(pi.ID.String(), strings.Join(addrs, "\n"))
+	}
+
+	n.Notifee.HandlePeerFound(pi)
+}
+
+// SetMDNSPeerHandler sets the handler notified of the peers found by mDNS,
+// e.g. to show the nearby devices, nil removes it. The node connects to the
+// found peers either way. The handler is called from the mDNS goroutine and
+// should return quickly.
+func (n *Node) SetMDNSPeerHandler(handler MDNSPeerHandler) {
+	n.muMDNSPeerHandler.Lock()
+	n.mdnsPeerHandler = handler
+	n.muMDNSPeerHandler.Unlock()
+}
+
+func (n *Node) getMDNSPeerHandler() MDNSPeerHandler {
+	n.muMDNSPeerHandler.Lock()
+	defer n.muMDNSPeerHandler.Unlock()
+	return n.mdnsPeerHandler
+}
+
+// trackingHost records the peers the DHT establishes a connection to.
+type trackingHost struct {
+	p2p_host.Host
+	tracker *peerTracker
+}
+
+func (h *trackingHost) Connect(ctx context.Context, pi p2p_peer.AddrInfo) error {
+	if h.Network().Connectedness(pi.ID) == network.Connected {
+		return h.Host.Connect(ctx, pi)
+	}
+
+	if err := h.Host.Connect(ctx, pi); err != nil {
+		return err
+	}
+
+	h.tracker.found(pi.ID, DiscoverySourceDHT)
+	return nil
+}
+
+// DiscoveredPeers returns a JSON encoded list of DiscoveredPeer, the peers
+// recently discovered through mDNS, the DHT, or the bootstrap and peering
+// lists of the config, most recently seen first. Only the last 256 peers seen
+// within the last hour are kept.
+func (n *Node) DiscoveredPeers() (string, error) {
+	out, err := json.Marshal(n.discovered.list())
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
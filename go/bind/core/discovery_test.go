@@ -0,0 +1,163 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestPeerTracker(t *testing.T) {
+	tracker := newPeerTracker()
+
+	bootstrap := p2p_peer.ID("bootstrap")
+	tracker.addStatic([]p2p_peer.AddrInfo{{ID: bootstrap}}, DiscoverySourceBootstrap)
+
+	tracker.found(bootstrap, DiscoverySourceDHT)
+	tracker.found("nearby", DiscoverySourceMDNS)
+	// the first source is kept
+	tracker.found("nearby", DiscoverySourceDHT)
+
+	sources := make(map[string]string)
+	for _, dp := range tracker.list() {
+		sources[dp.Peer] = dp.Source
+	}
+
+	if src := sources[bootstrap.String()]; src != DiscoverySourceBootstrap {
+		t.Errorf("expected bootstrap peer source to be `%s` got `%s`", DiscoverySourceBootstrap, src)
+	}
+	if src := sources[p2p_peer.ID("nearby").String()]; src != DiscoverySourceMDNS {
+		t.Errorf("expected nearby peer source to be `%s` got `%s`", DiscoverySourceMDNS, src)
+	}
+
+	for i := 0; i < maxDiscoveredPeers; i++ {
+		tracker.found(p2p_peer.ID(fmt.Sprintf("peer-%d", i)), DiscoverySourceDHT)
+	}
+
+	if peers := tracker.list(); len(peers) != maxDiscoveredPeers {
+		t.Errorf("expected %d peers got %d", maxDiscoveredPeers, len(peers))
+	}
+}
+
+type testNotifee struct {
+	found []p2p_peer.ID
+}
+
+func (n *testNotifee) HandlePeerFound(pi p2p_peer.AddrInfo) { n.found = append(n.found, pi.ID) }
+
+type testMDNSPeerHandler struct {
+	peers, addrs []string
+}
+
+func (h *testMDNSPeerHandler) OnPeerFound(peerID string, addrs string) {
+	h.peers = append(h.peers, peerID)
+	h.addrs = append(h.addrs, addrs)
+}
+
+func TestMDNSPeerHandler(t *testing.T) {
+	node := &Node{}
+	connect := &testNotifee{}
+	notifee := &mdnsNotifee{
+		Notifee: connect,
+		tracker: newPeerTracker(),
+		node:    node,
+	}
+
+	pid, err := p2p_peer.Decode(testRelayPeer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pi := p2p_peer.AddrInfo{
+		ID: pid,
+		Addrs: []ma.Multiaddr{
+			ma.StringCast("/ip4/192.168.1.2/tcp/4001"),
+			ma.StringCast("/ip4/192.168.1.2/udp/4001/quic"),
+		},
+	}
+
+	// without handler the peer is still handed to the wrapped notifee
+	notifee.HandlePeerFound(pi)
+
+	handler := &testMDNSPeerHandler{}
+	node.SetMDNSPeerHandler(handler)
+	notifee.HandlePeerFound(pi)
+
+	node.SetMDNSPeerHandler(nil)
+	notifee.HandlePeerFound(pi)
+
+	if len(connect.found) != 3 {
+		t.Errorf("expected the wrapped notifee to get 3 peers got %d", len(connect.found))
+	}
+
+	if len(handler.peers) != 1 || handler.peers[0] != testRelayPeer {
+		t.Fatalf("expected the handler to get `%s` once got `%v`", testRelayPeer, handler.peers)
+	}
+	if expected := "/ip4/192.168.1.2/tcp/4001\n/ip4/192.168.1.2/udp/4001/quic"; handler.addrs[0] != expected {
+		t.Errorf("expected addrs `%s` got `%s`", expected, handler.addrs[0])
+	}
+}
+
+// connectNotifee stands for the notifee dialing the found peers, it records
+// how many peers the handler got when it is called.
+type connectNotifee struct {
+	handler *testMDNSPeerHandler
+	seen    []int
+}
+
+func (n *connectNotifee) HandlePeerFound(p2p_peer.AddrInfo) {
+	n.seen = append(n.seen, len(n.handler.peers))
+}
+
+func TestMDNSPeerHandlerBeforeConnect(t *testing.T) {
+	node := &Node{}
+	handler := &testMDNSPeerHandler{}
+	node.SetMDNSPeerHandler(handler)
+
+	connect := &connectNotifee{handler: handler}
+	notifee := &mdnsNotifee{
+		Notifee: connect,
+		tracker: newPeerTracker(),
+		node:    node,
+	}
+
+	pid, err := p2p_peer.Decode(testRelayPeer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifee.HandlePeerFound(p2p_peer.AddrInfo{ID: pid})
+
+	if len(connect.seen) != 1 || connect.seen[0] != 1 {
+		t.Errorf("expected the handler to be called before the connection, got `%v`", connect.seen)
+	}
+}
+
+func TestMDNSNotifeeSkipsSelf(t *testing.T) {
+	self, err := p2p_peer.Decode(testRelayPeer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := &Node{}
+	handler := &testMDNSPeerHandler{}
+	node.SetMDNSPeerHandler(handler)
+
+	connect := &testNotifee{}
+	notifee := &mdnsNotifee{
+		Notifee: connect,
+		tracker: newPeerTracker(),
+		node:    node,
+		self:    self,
+	}
+	notifee.HandlePeerFound(p2p_peer.AddrInfo{ID: self})
+
+	if len(handler.peers) != 0 {
+		t.Errorf("expected the handler not to be told about the node got `%v`", handler.peers)
+	}
+	if peers := notifee.tracker.list(); len(peers) != 0 {
+		t.Errorf("expected the node not to be tracked got `%v`", peers)
+	}
+	if len(connect.found) != 0 {
+		t.Errorf("expected the node not to be handed to the wrapped notifee got `%v`", connect.found)
+	}
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+// systemResolver is the resolver of the process before any node replaced it,
+// restored by the nodes using the system DNS.
+var systemResolver = net.DefaultResolver
+
+// parseDNSServers parses a comma separated list of `host:port` entries.
+func parseDNSServers(servers string) ([]string, error) {
+	var out []string
+	for _, server := range strings.Split(servers, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+
+		host, port, err := net.SplitHostPort(server)
+		if err != nil || host == "" || port == "" {
+			return nil, fmt.Errorf("invalid dns server `%s`, expected `host:port`", server)
+		}
+		out = append(out, server)
+	}
+	return out, nil
+}
+
+// dnsDialer dials the configured DNS servers in turn, falling back to the
+// next ones when a dial fails.
+type dnsDialer struct {
+	servers []string
+	next    uint32
+
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+func newDNSDialer(servers []string) *dnsDialer {
+	var dialer net.Dialer
+	return &dnsDialer{
+		servers: servers,
+		dial:    dialer.DialContext,
+	}
+}
+
+func (d *dnsDialer) DialContext(ctx context.Context, network, _ string) (net.Conn, error) {
+	start := int(atomic.AddUint32(&d.next, 1) - 1)
+
+	var err error
+	for i := range d.servers {
+		server := d.servers[(start+i)%len(d.servers)]
+
+		var conn net.Conn
+		if conn, err = d.dial(ctx, network, server); err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("unable to dial dns servers: %w", err)
+}
+
+// newDNSResolver returns a resolver querying the given servers, bypassing
+// the resolver of the system.
+func newDNSResolver(servers []string) *net.Resolver {
+	return &net.Resolver{
+		// only the go resolver uses the dial function
+		PreferGo: true,
+		Dial:     newDNSDialer(servers).DialContext,
+	}
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestParseDNSServers(t *testing.T) {
+	servers, err := parseDNSServers("1.1.1.1:53, [2606:4700::1111]:53,,dns.example:5353")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"1.1.1.1:53", "[2606:4700::1111]:53", "dns.example:5353"}
+	if !reflect.DeepEqual(servers, expected) {
+		t.Errorf("expected `%v` got `%v`", expected, servers)
+	}
+
+	for _, invalid := range []string{"1.1.1.1", ":53", "1.1.1.1:"} {
+		if _, err := parseDNSServers(invalid); err == nil {
+			t.Errorf("expected `%s` to be refused", invalid)
+		}
+	}
+}
+
+func TestDNSDialer(t *testing.T) {
+	var dialed []string
+	d := newDNSDialer([]string{"10.0.0.1:53", "10.0.0.2:53"})
+	d.dial = func(_ context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, network+" "+addr)
+		if addr == "10.0.0.1:53" {
+			return nil, errors.New("unreachable")
+		}
+
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		conn, err := d.DialContext(ctx, "udp", "8.8.8.8:53")
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}
+
+	// the first dial fails over to the second server, the next one starts
+	// with it
+	expected := []string{"udp 10.0.0.1:53", "udp 10.0.0.2:53", "udp 10.0.0.2:53"}
+	if !reflect.DeepEqual(dialed, expected) {
+		t.Errorf("expected dials `%v` got `%v`", expected, dialed)
+	}
+
+	d.servers = d.servers[:1]
+	if _, err := d.DialContext(ctx, "udp", "8.8.8.8:53"); err == nil {
+		t.Error("expected an error when no server can be dialed")
+	}
+}
+
+func TestNodeDNSResolver(t *testing.T) {
+	defer func() { net.DefaultResolver = systemResolver }()
+
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	config := NewNodeConfig()
+	if err := config.SetDNSResolver("127.0.0.1:53"); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.Close()
+
+	if net.DefaultResolver == systemResolver || !net.DefaultResolver.PreferGo {
+		t.Error("expected the configured resolver to be installed")
+	}
+
+	// the default is the system dns, closing the node closed its repo
+	path2, clean := testingTempDir(t, "repo2")
+	defer clean()
+
+	repo2, clean := testingRepo(t, path2)
+	defer clean()
+
+	node, err = NewNode(repo2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.Close()
+
+	if net.DefaultResolver != systemResolver {
+		t.Error("expected the system resolver to be restored")
+	}
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	ipfs_log "github.com/ipfs/go-log/v2"
+)
+
+// logDriverBufferSize is the number of log entries queued for the native log
+// driver, the entries logged while the queue is full are dropped
+const logDriverBufferSize = 1000
+
+// NativeLogDriver receives the ipfs logs, see NodeConfig.SetLogDriver. level
+// is `debug`, `info`, `warn`, `error`, `dpanic`, `panic` or `fatal`,
+// subsystem is the name of the logger (e.g. `dht`).
+type NativeLogDriver interface {
+	Log(level string, subsystem string, msg string)
+}
+
+type logEntry struct {
+	Level     string `json:"level"`
+	Subsystem string `json:"logger"`
+	Message   string `json:"msg"`
+}
+
+// logDriver forwards the ipfs logs to a native log driver. The entries are
+// queued by a reader of the log pipe and delivered by another goroutine, so a
+// slow driver drops entries instead of blocking the loggers.
+type logDriver struct {
+	driver NativeLogDriver
+
+	pipe    *ipfs_log.PipeReader
+	entries chan logEntry
+	dropped uint64
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func startLogDriver(driver NativeLogDriver) *logDriver {
+	d := &logDriver{
+		driver:  driver,
+		pipe:    ipfs_log.NewPipeReader(ipfs_log.PipeFormat(ipfs_log.JSONOutput)),
+		entries: make(chan logEntry, logDriverBufferSize),
+		done:    make(chan struct{}),
+	}
+
+	go d.read(d.pipe)
+	go d.deliver()
+
+	return d
+}
+
+// read decodes the entries of the log pipe until it is closed.
+func (d *logDriver) read(rd io.Reader) {
+	defer close(d.entries)
+
+	br := bufio.NewReader(rd)
+	for {
+		line, err := br.ReadBytes('\n')
+		var entry logEntry
+		if len(line) > 0 && json.Unmarshal(line, &entry) == nil {
+			d.queue(entry)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (d *logDriver) queue(entry logEntry) {
+	select {
+	case d.entries <- entry:
+	default:
+		atomic.AddUint64(&d.dropped, 1)
+	}
+}
+
+func (d *logDriver) deliver() {
+	defer close(d.done)
+
+	for entry := range d.entries {
+		if dropped := atomic.SwapUint64(&d.dropped, 0); dropped > 0 {
+			d.driver.Log("warn", "core", fmt.Sprintf("%d log entries dropped, the log driver is too slow", dropped))
+		}
+		d.driver.Log(entry.Level, entry.Subsystem, entry.Message)
+	}
+}
+
+// Close stops forwarding the logs once the queued entries are delivered,
+// closing it again is a no-op.
+func (d *logDriver) Close() error {
+	var err error
+	d.closeOnce.Do(func() {
+		err = d.pipe.Close()
+		<-d.done
+	})
+	return err
+}
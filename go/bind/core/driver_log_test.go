@@ -0,0 +1,42 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testLogDriver struct {
+	entries []logEntry
+}
+
+func (d *testLogDriver) Log(level string, subsystem string, msg string) {
+	d.entries = append(d.entries, logEntry{level, subsystem, msg})
+}
+
+func TestLogDriver(t *testing.T) {
+	driver := &testLogDriver{}
+	d := &logDriver{
+		driver:  driver,
+		entries: make(chan logEntry, 1),
+		done:    make(chan struct{}),
+	}
+
+	// the queue holds a single entry and nothing delivers it yet, the next
+	// ones are dropped
+	d.read(strings.NewReader(
+		`{"level":"info","logger":"dht","msg":"first"}` + "\n" +
+			"not json\n" +
+			`{"level":"warn","logger":"bitswap","msg":"second"}` + "\n" +
+			`{"level":"error","logger":"dht","msg":"third"}`,
+	))
+	d.deliver()
+
+	expected := []logEntry{
+		{"warn", "core", "2 log entries dropped, the log driver is too slow"},
+		{"info", "dht", "first"},
+	}
+	if !reflect.DeepEqual(driver.entries, expected) {
+		t.Errorf("expected entries `%v` got `%v`", expected, driver.entries)
+	}
+}
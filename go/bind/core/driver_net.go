@@ -1,38 +1,157 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"strings"
+	"sync"
 
 	"go.uber.org/zap"
 )
 
+// netDriverMaxFailures is the number of consecutive failures of the native
+// net driver after which the NetDriverErrorHandler is notified
+const netDriverMaxFailures = 3
+
 type NativeNetDriver interface {
 	InterfaceAddrs() (*NetAddrs, error)
 	Interfaces() (*NetInterfaces, error)
 }
 
+// NetDriverErrorHandler is notified when the native net driver fails
+// netDriverMaxFailures times in a row, see NodeConfig.SetNetDriverErrorHandler.
+type NetDriverErrorHandler interface {
+	OnNetDriverError(message string)
+}
+
 type inet struct {
 	net    NativeNetDriver
 	logger *zap.Logger
+
+	// errHandler is notified of repeated failures, may be nil
+	errHandler NetDriverErrorHandler
+	// fallback uses the interfaces detected by the go runtime when the
+	// native driver fails
+	fallback bool
+
+	muFailures sync.Mutex
+	failures   int
 }
 
 func (ia *inet) Interfaces() ([]net.Interface, error) {
 	ifaces, err := ia.net.Interfaces()
+	if err == nil && ifaces == nil {
+		err = errors.New("net driver returned no interfaces")
+	}
 	if err != nil {
+		ia.failed(err)
+		if ia.fallback {
+			return net.Interfaces()
+		}
 		return nil, err
 	}
 
+	ia.succeeded()
 	return ifaces.Interfaces(), nil
 }
 
 func (ia *inet) InterfaceAddrs() ([]net.Addr, error) {
 	na, err := ia.net.InterfaceAddrs()
+	if err == nil && na == nil {
+		err = errors.New("net driver returned no addresses")
+	}
 	if err != nil {
+		ia.failed(err)
+		if ia.fallback {
+			return net.InterfaceAddrs()
+		}
 		return nil, err
 	}
 
+	ia.succeeded()
+	return ia.parseAddrs(na), nil
+}
+
+func (ia *inet) InterfaceAddrsByName(name string) ([]net.Addr, error) {
+	ifaces, err := ia.net.Interfaces()
+	if err == nil && ifaces == nil {
+		err = errors.New("net driver returned no interfaces")
+	}
+	if err != nil {
+		ia.failed(err)
+		if ia.fallback {
+			iface, err := net.InterfaceByName(name)
+			if err != nil {
+				return nil, err
+			}
+			return iface.Addrs()
+		}
+		return nil, err
+	}
+
+	ia.succeeded()
+	for _, iface := range ifaces.ifaces {
+		if iface.Name != name {
+			continue
+		}
+
+		if iface.Addrs == nil {
+			return []net.Addr{}, nil
+		}
+		return ia.parseAddrs(iface.Addrs), nil
+	}
+
+	return nil, fmt.Errorf("interface `%s` not found", name)
+}
+
+// check calls the native driver directly, without falling back.
+func (ia *inet) check() error {
+	if ifaces, err := ia.net.Interfaces(); err != nil {
+		return fmt.Errorf("unable to list interfaces: %w", err)
+	} else if ifaces == nil {
+		return errors.New("net driver returned no interfaces")
+	}
+
+	if na, err := ia.net.InterfaceAddrs(); err != nil {
+		return fmt.Errorf("unable to list interface addresses: %w", err)
+	} else if na == nil {
+		return errors.New("net driver returned no addresses")
+	}
+
+	return nil
+}
+
+// CheckNetDriver calls the native net driver set in the NodeConfig and
+// returns its error, if any. It always succeeds when no native driver is set.
+func (n *Node) CheckNetDriver() error {
+	if n.netDriver == nil {
+		return nil
+	}
+	return n.netDriver.check()
+}
+
+func (ia *inet) failed(err error) {
+	ia.muFailures.Lock()
+	ia.failures++
+	failures := ia.failures
+	ia.muFailures.Unlock()
+
+	ia.logger.Warn("net driver failure", zap.Int("failures", failures), zap.Error(err), zap.Bool("fallback", ia.fallback))
+
+	// notify once per streak of failures
+	if failures == netDriverMaxFailures && ia.errHandler != nil {
+		ia.errHandler.OnNetDriverError(fmt.Sprintf("net driver failed %d times in a row: %s", failures, err))
+	}
+}
+
+func (ia *inet) succeeded() {
+	ia.muFailures.Lock()
+	ia.failures = 0
+	ia.muFailures.Unlock()
+}
+
+func (ia *inet) parseAddrs(na *NetAddrs) []net.Addr {
 	addrs := []net.Addr{}
 	for _, addr := range na.addrs {
 		if addr == "" {
@@ -63,7 +182,7 @@ func (ia *inet) InterfaceAddrs() ([]net.Addr, error) {
 	}
 
 	ia.logger.Debug("driver interface resolved addrs", zap.Strings("addrs", fields))
-	return addrs, nil
+	return addrs
 }
 
 type NetInterfaces struct {
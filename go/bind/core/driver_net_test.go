@@ -0,0 +1,52 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type failingNetDriver struct{}
+
+func (failingNetDriver) InterfaceAddrs() (*NetAddrs, error) {
+	return nil, errors.New("netlink denied")
+}
+
+func (failingNetDriver) Interfaces() (*NetInterfaces, error) {
+	return nil, errors.New("netlink denied")
+}
+
+type testNetDriverErrorHandler struct {
+	errors int
+}
+
+func (h *testNetDriverErrorHandler) OnNetDriverError(string) { h.errors++ }
+
+func TestNetDriverFailures(t *testing.T) {
+	handler := &testNetDriverErrorHandler{}
+	ia := &inet{
+		net:        failingNetDriver{},
+		logger:     zap.NewNop(),
+		errHandler: handler,
+	}
+
+	for i := 0; i < netDriverMaxFailures*2; i++ {
+		if _, err := ia.InterfaceAddrs(); err == nil {
+			t.Fatal("expected the driver error without fallback")
+		}
+	}
+
+	if handler.errors != 1 {
+		t.Errorf("expected the handler to be notified once got %d", handler.errors)
+	}
+
+	if err := ia.check(); err == nil {
+		t.Error("expected the check to fail")
+	}
+
+	ia.fallback = true
+	if _, err := ia.Interfaces(); err != nil {
+		t.Errorf("expected the fallback to succeed: %s", err)
+	}
+}
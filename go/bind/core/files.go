@@ -0,0 +1,343 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	gopath "path"
+
+	ipfs_merkledag "github.com/ipfs/go-merkledag"
+	ipfs_mfs "github.com/ipfs/go-mfs"
+	ipfs_unixfs "github.com/ipfs/go-unixfs"
+)
+
+// FilesStatResult is returned by FilesStat.
+type FilesStatResult struct {
+	// Hash is the cid of the file or directory.
+	Hash string
+	// Size is the size of the content of a file, 0 for a directory.
+	Size uint64
+	// CumulativeSize is the total size of the blocks of the DAG.
+	CumulativeSize uint64
+	// Type is `file` or `directory`.
+	Type string
+}
+
+// FilesEntry is an entry returned by FilesLs.
+type FilesEntry struct {
+	Name string
+	// Type is `file` or `directory`.
+	Type string
+	// Size is the size of the content of a file, 0 for a directory.
+	Size int64
+	Hash string
+}
+
+// FilesWrite writes data to the MFS file at the given absolute path, from its
+// start. The file is created when create is set and it doesn't exist, its
+// parent directory must exist. With truncate the previous content is
+// discarded, otherwise only the written range is replaced. The changes are
+// flushed up to the MFS root.
+func (n *Node) FilesWrite(path string, data []byte, create bool, truncate bool) error {
+	path, err := checkFilesPath(path)
+	if err != nil {
+		return err
+	}
+
+	n.muFiles.Lock()
+	defer n.muFiles.Unlock()
+
+	fi, err := n.filesFile(path, create)
+	if err != nil {
+		return err
+	}
+
+	wfd, err := fi.Open(ipfs_mfs.Flags{Write: true, Sync: true})
+	if err != nil {
+		return fmt.Errorf("unable to open `%s`: %w", path, err)
+	}
+
+	if truncate {
+		if err := wfd.Truncate(0); err != nil {
+			wfd.Close()
+			return fmt.Errorf("unable to truncate `%s`: %w", path, err)
+		}
+	}
+
+	if _, err := wfd.Write(data); err != nil {
+		wfd.Close()
+		return fmt.Errorf("unable to write to `%s`: %w", path, err)
+	}
+
+	// closing a synced descriptor flushes the file up to the root
+	return wfd.Close()
+}
+
+// FilesRead returns the content of the MFS file at the given absolute path.
+// Like Cat, it fails when the file is larger than the maximum set with
+// SetMaxCatSize.
+func (n *Node) FilesRead(path string) ([]byte, error) {
+	path, err := checkFilesPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fsn, err := ipfs_mfs.Lookup(n.ipfsMobile.IpfsNode.FilesRoot, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find `%s`: %w", path, err)
+	}
+	fi, ok := fsn.(*ipfs_mfs.File)
+	if !ok {
+		return nil, fmt.Errorf("`%s` is not a file", path)
+	}
+
+	limit := n.getMaxCatSize()
+	if size, err := fi.Size(); err == nil && size > limit {
+		return nil, fmt.Errorf("`%s` is %d bytes, more than the maximum of %d", path, size, limit)
+	}
+
+	rfd, err := fi.Open(ipfs_mfs.Flags{Read: true})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open `%s`: %w", path, err)
+	}
+	defer rfd.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rfd, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read `%s`: %w", path, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("`%s` is larger than the maximum of %d bytes", path, limit)
+	}
+	return data, nil
+}
+
+// FilesLs returns the JSON encoded list of FilesEntry of the MFS directory at
+// the given absolute path, sorted by name, or the entry of the file at path.
+func (n *Node) FilesLs(path string) (string, error) {
+	path, err := checkFilesPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	fsn, err := ipfs_mfs.Lookup(n.ipfsMobile.IpfsNode.FilesRoot, path)
+	if err != nil {
+		return "", fmt.Errorf("unable to find `%s`: %w", path, err)
+	}
+
+	entries := []FilesEntry{}
+	switch v := fsn.(type) {
+	case *ipfs_mfs.Directory:
+		listing, err := v.List(n.ctx)
+		if err != nil {
+			return "", fmt.Errorf("unable to list `%s`: %w", path, err)
+		}
+		for _, l := range listing {
+			entry := FilesEntry{Name: l.Name, Type: "file", Size: l.Size, Hash: l.Hash}
+			if ipfs_mfs.NodeType(l.Type) == ipfs_mfs.TDir {
+				entry.Type = "directory"
+			}
+			entries = append(entries, entry)
+		}
+	case *ipfs_mfs.File:
+		nd, err := v.GetNode()
+		if err != nil {
+			return "", err
+		}
+		size, err := v.Size()
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, FilesEntry{
+			Name: gopath.Base(path),
+			Type: "file",
+			Size: size,
+			Hash: nd.Cid().String(),
+		})
+	default:
+		return "", fmt.Errorf("`%s` is not a file or a directory", path)
+	}
+
+	out, err := json.Marshal(entries)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// FilesMkdir creates the MFS directory at the given absolute path, along with
+// its missing parents when parents is set. With parents, an existing
+// directory is not an error.
+func (n *Node) FilesMkdir(path string, parents bool) error {
+	path, err := checkFilesPath(path)
+	if err != nil {
+		return err
+	}
+	if path == "/" {
+		if parents {
+			return nil
+		}
+		return errors.New("the mfs root already exists")
+	}
+
+	n.muFiles.Lock()
+	defer n.muFiles.Unlock()
+
+	err = ipfs_mfs.Mkdir(n.ipfsMobile.IpfsNode.FilesRoot, path, ipfs_mfs.MkdirOpts{
+		Mkparents: parents,
+		Flush:     true,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create `%s`: %w", path, err)
+	}
+	return nil
+}
+
+// FilesAppend appends data at the end of the MFS file at the given absolute
+// path. Only the last blocks of the file are rewritten, the files written
+// through MFS are trickle DAGs made for appending. The file is created when
+// create is set and it doesn't exist, its parent directory must exist. The
+// changes are flushed up to the MFS root, the new cid of the file is returned
+// by FilesStat.
+func (n *Node) FilesAppend(path string, data []byte, create bool) error {
+	path, err := checkFilesPath(path)
+	if err != nil {
+		return err
+	}
+
+	n.muFiles.Lock()
+	defer n.muFiles.Unlock()
+
+	fi, err := n.filesFile(path, create)
+	if err != nil {
+		return err
+	}
+
+	wfd, err := fi.Open(ipfs_mfs.Flags{Write: true, Sync: true})
+	if err != nil {
+		return fmt.Errorf("unable to open `%s`: %w", path, err)
+	}
+
+	if _, err := wfd.Seek(0, io.SeekEnd); err != nil {
+		wfd.Close()
+		return fmt.Errorf("unable to seek `%s`: %w", path, err)
+	}
+
+	if _, err := wfd.Write(data); err != nil {
+		wfd.Close()
+		return fmt.Errorf("unable to append to `%s`: %w", path, err)
+	}
+
+	// closing a synced descriptor flushes the file up to the root
+	return wfd.Close()
+}
+
+// FilesStat returns the JSON encoded FilesStatResult of the MFS file or
+// directory at the given absolute path.
+func (n *Node) FilesStat(path string) (string, error) {
+	path, err := checkFilesPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	fsn, err := ipfs_mfs.Lookup(n.ipfsMobile.IpfsNode.FilesRoot, path)
+	if err != nil {
+		return "", fmt.Errorf("unable to find `%s`: %w", path, err)
+	}
+
+	nd, err := fsn.GetNode()
+	if err != nil {
+		return "", err
+	}
+
+	cumulSize, err := nd.Size()
+	if err != nil {
+		return "", err
+	}
+
+	res := &FilesStatResult{
+		Hash:           nd.Cid().String(),
+		CumulativeSize: cumulSize,
+		Type:           "file",
+	}
+
+	switch v := nd.(type) {
+	case *ipfs_merkledag.ProtoNode:
+		fsnode, err := ipfs_unixfs.FSNodeFromBytes(v.Data())
+		if err != nil {
+			return "", err
+		}
+
+		switch fsnode.Type() {
+		case ipfs_unixfs.TDirectory, ipfs_unixfs.THAMTShard:
+			res.Type = "directory"
+		default:
+			res.Size = fsnode.FileSize()
+		}
+	case *ipfs_merkledag.RawNode:
+		res.Size = cumulSize
+	default:
+		return "", fmt.Errorf("`%s` is not a unixfs node", path)
+	}
+
+	out, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// filesFile returns the MFS file at path, creating an empty one in its
+// existing parent directory when create is set.
+func (n *Node) filesFile(path string, create bool) (*ipfs_mfs.File, error) {
+	root := n.ipfsMobile.IpfsNode.FilesRoot
+
+	fsn, err := ipfs_mfs.Lookup(root, path)
+	if err == nil {
+		fi, ok := fsn.(*ipfs_mfs.File)
+		if !ok {
+			return nil, fmt.Errorf("`%s` is not a file", path)
+		}
+		return fi, nil
+	}
+	if !create || !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("unable to find `%s`: %w", path, err)
+	}
+
+	dirname, fname := gopath.Split(path)
+	parent, err := ipfs_mfs.Lookup(root, dirname)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the directory of `%s`: %w", path, err)
+	}
+	pdir, ok := parent.(*ipfs_mfs.Directory)
+	if !ok {
+		return nil, fmt.Errorf("`%s` is not a directory", dirname)
+	}
+
+	nd := ipfs_merkledag.NodeWithData(ipfs_unixfs.FilePBData(nil, 0))
+	nd.SetCidBuilder(pdir.GetCidBuilder())
+	if err := pdir.AddChild(fname, nd); err != nil {
+		return nil, fmt.Errorf("unable to create `%s`: %w", path, err)
+	}
+
+	fsn, err = pdir.Child(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, ok := fsn.(*ipfs_mfs.File)
+	if !ok {
+		return nil, fmt.Errorf("`%s` is not a file", path)
+	}
+	return fi, nil
+}
+
+// checkFilesPath returns the cleaned MFS path, it must be absolute.
+func checkFilesPath(path string) (string, error) {
+	if path == "" || path[0] != '/' {
+		return "", fmt.Errorf("invalid mfs path `%s`, expected an absolute path", path)
+	}
+	return gopath.Clean(path), nil
+}
@@ -0,0 +1,165 @@
+package core
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestNodeFilesAppend(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if err := node.FilesAppend("/journal.log", []byte("first\n"), false); err == nil {
+		t.Fatal("expected appending to a missing file to fail without create")
+	}
+
+	stat := func() FilesStatResult {
+		t.Helper()
+
+		out, err := node.FilesStat("/journal.log")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var res FilesStatResult
+		if err := json.Unmarshal([]byte(out), &res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	if err := node.FilesAppend("/journal.log", []byte("first\n"), true); err != nil {
+		t.Fatal(err)
+	}
+	first := stat()
+
+	if err := node.FilesAppend("/journal.log", []byte("second\n"), false); err != nil {
+		t.Fatal(err)
+	}
+	second := stat()
+
+	if second.Size != uint64(len("first\nsecond\n")) {
+		t.Errorf("expected a size of %d got %d", len("first\nsecond\n"), second.Size)
+	}
+
+	if second.Hash == first.Hash {
+		t.Error("expected the cid to change after an append")
+	}
+
+	if second.Type != "file" {
+		t.Errorf("expected type `file` got `%s`", second.Type)
+	}
+
+	if err := node.FilesAppend("/missing/journal.log", []byte("data"), true); err == nil {
+		t.Error("expected a missing parent directory to fail")
+	}
+
+	if _, err := node.FilesStat("journal.log"); err == nil {
+		t.Error("expected a relative path to fail")
+	}
+}
+
+func TestNodeFilesWriteRead(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if err := node.FilesMkdir("/sync/photos", false); err == nil {
+		t.Error("expected a missing parent directory to fail without parents")
+	}
+	if err := node.FilesMkdir("/sync/photos", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.FilesMkdir("/sync/photos", true); err != nil {
+		t.Errorf("expected an existing directory to be accepted with parents: %s", err)
+	}
+
+	if err := node.FilesWrite("/sync/notes.txt", []byte("hello world"), true, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.FilesWrite("/sync/notes.txt", []byte("HELLO"), false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := node.FilesRead("/sync/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "HELLO world" {
+		t.Errorf("expected `HELLO world` got `%s`", data)
+	}
+
+	if err := node.FilesWrite("/sync/notes.txt", []byte("bye"), false, true); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = node.FilesRead("/sync/notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "bye" {
+		t.Errorf("expected `bye` got `%s`", data)
+	}
+
+	if _, err := node.FilesRead("/sync"); err == nil {
+		t.Error("expected reading a directory to fail")
+	}
+
+	out, err := node.FilesLs("/sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []FilesEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatal(err)
+	}
+
+	var names, types []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+		types = append(types, e.Type)
+	}
+	if !reflect.DeepEqual(names, []string{"notes.txt", "photos"}) {
+		t.Errorf("unexpected entries `%v`", names)
+	}
+	if !reflect.DeepEqual(types, []string{"file", "directory"}) {
+		t.Errorf("unexpected entry types `%v`", types)
+	}
+	if entries[0].Size != 3 || entries[0].Hash == "" {
+		t.Errorf("unexpected file entry `%+v`", entries[0])
+	}
+}
+
+func TestNodeFilesConcurrentWrites(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := node.FilesAppend("/shared.log", []byte("line\n"), true); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := node.FilesRead("/shared.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 10*len("line\n") {
+		t.Errorf("expected %d bytes got %d", 10*len("line\n"), len(data))
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// GatewayURL returns a `http://127.0.0.1:<port>/ipfs/<cid>` url served by a
+// tcp gateway listener of the node reachable on loopback, the first one
+// started when there are several. The url is `https://` for the gateways
+// started with ServeTLSGateway.
+func (n *Node) GatewayURL(cid string) (string, error) {
+	c, err := ipfs_cid.Decode(cid)
+	if err != nil {
+		return "", fmt.Errorf("invalid cid `%s`: %w", cid, err)
+	}
+
+	n.muListeners.Lock()
+	defer n.muListeners.Unlock()
+
+	var first *Listener
+	var firstAddr *net.TCPAddr
+	for _, l := range n.listeners {
+		maddr := l.ml.Multiaddr()
+		if !l.gateway || !(manet.IsIPLoopback(maddr) || manet.IsIPUnspecified(maddr)) {
+			continue
+		}
+		if first != nil && first.seq < l.seq {
+			continue
+		}
+
+		addr, err := manet.ToNetAddr(maddr)
+		if err != nil {
+			continue
+		}
+
+		if tcpaddr, ok := addr.(*net.TCPAddr); ok {
+			first, firstAddr = l, tcpaddr
+		}
+	}
+
+	if first == nil {
+		return "", errors.New("no loopback gateway listener is running")
+	}
+
+	// a gateway listening on all the interfaces is reached on loopback
+	host := *firstAddr
+	if host.IP.IsUnspecified() {
+		if host.IP.To4() != nil {
+			host.IP = net.IPv4(127, 0, 0, 1)
+		} else {
+			host.IP = net.IPv6loopback
+		}
+	}
+
+	scheme := "http"
+	if first.secure {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/ipfs/%s", scheme, host.String(), c.String()), nil
+}
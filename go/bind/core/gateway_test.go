@@ -0,0 +1,228 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+	ipfs_coreapi "github.com/ipfs/kubo/core/coreapi"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+func TestNodeGatewayURL(t *testing.T) {
+	var testcontent = []byte("hello gateway\n")
+
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	api, err := ipfs_coreapi.NewCoreAPI(node.ipfsMobile.IpfsNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := api.Unixfs().Add(context.Background(), ipfs_files.NewBytesFile(testcontent))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := node.GatewayURL(resolved.Cid().String()); err == nil {
+		t.Fatal("expected an error without a running gateway")
+	}
+
+	first, err := node.ServeTCPGateway("0", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first gateway started is picked
+	for i := 0; i < 4; i++ {
+		if _, err := node.ServeTCPGateway("0", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	maddr, err := ma.NewMultiaddr(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := manet.ToNetAddr(maddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, err := node.GatewayURL(resolved.Cid().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "http://" + addr.String() + "/ipfs/" + resolved.Cid().String(); url != expected {
+		t.Errorf("expected `%s` got `%s`", expected, url)
+	}
+
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(b, testcontent) {
+		t.Fatalf("content `%s` are different from `%s`", b, testcontent)
+	}
+}
+
+func TestNodeGatewayDenylist(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	api, err := ipfs_coreapi.NewCoreAPI(node.ipfsMobile.IpfsNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := api.Unixfs().Add(context.Background(), ipfs_files.NewBytesFile([]byte("hello denylist\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cid := resolved.Cid()
+
+	if _, err := node.ServeTCPGateway("0", false); err != nil {
+		t.Fatal(err)
+	}
+
+	url, err := node.GatewayURL(cid.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := http.Client{Timeout: 5 * time.Second}
+	expectStatus := func(status int) {
+		t.Helper()
+
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != status {
+			t.Fatalf("expected status %d, got %d", status, resp.StatusCode)
+		}
+	}
+
+	expectStatus(http.StatusOK)
+
+	// the cid v1 of the content blocks it as well
+	if err := node.SetGatewayDenylist(ipfs_cid.NewCidV1(ipfs_cid.DagProtobuf, cid.Hash()).String()); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(http.StatusGone)
+
+	if err := node.SetGatewayDenylist(""); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(http.StatusOK)
+
+	if err := node.SetGatewayDenylist("not a cid"); err == nil {
+		t.Fatal("expected an error with an invalid cid")
+	}
+
+	dir, clean := testingTempDir(t, "denylist")
+	defer clean()
+
+	file := filepath.Join(dir, "denylist")
+	content := fmt.Sprintf("# blocked content\n\n%s\n", cid.String())
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := node.SetGatewayDenylistFile(file); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(http.StatusGone)
+}
+
+func TestNodeGatewayReadOnly(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	cid, err := node.AddBytes([]byte("hello read-only\n"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := http.Client{Timeout: 5 * time.Second}
+	request := func(method, url string) int {
+		t.Helper()
+
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, writable := range []bool{false, true} {
+		l, err := node.ServeGatewayMultiaddr("/ip4/127.0.0.1/tcp/0", writable)
+		if err != nil {
+			t.Fatal(err)
+		}
+		maddr, err := ma.NewMultiaddr(l.Multiaddr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr, err := manet.ToNetAddr(maddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		base := fmt.Sprintf("http://%s", addr)
+
+		if status := request(http.MethodGet, base+"/ipfs/"+cid); status != http.StatusOK {
+			t.Errorf("writable=%t: expected GET to succeed got %d", writable, status)
+		}
+
+		status := request(http.MethodPost, base+"/api/v0/cat?arg="+cid)
+		if refused := status == http.StatusMethodNotAllowed || status == http.StatusNotFound; refused == writable {
+			t.Errorf("writable=%t: unexpected status %d for the command api", writable, status)
+		}
+
+		if !writable {
+			for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+				if status := request(method, base+"/ipfs/"+cid); status != http.StatusMethodNotAllowed {
+					t.Errorf("expected %s to be refused got %d", method, status)
+				}
+			}
+		}
+
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
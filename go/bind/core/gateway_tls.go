@@ -0,0 +1,99 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+)
+
+// selfSignedCertValidity is the validity period of the certificates made by
+// GenerateSelfSignedCert.
+const selfSignedCertValidity = 365 * 24 * time.Hour
+
+// TLSCertificate is a PEM encoded certificate and its private key, returned
+// by GenerateSelfSignedCert.
+type TLSCertificate struct {
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// GenerateSelfSignedCert generates in memory a self-signed certificate valid
+// one year for host, an IP address or a hostname, for ServeTLSGateway. The
+// clients have to trust it explicitly, e.g. by pinning it, as no CA signs it.
+func GenerateSelfSignedCert(host string) (*TLSCertificate, error) {
+	if host == "" {
+		return nil, errors.New("empty host")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate serial number: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: host},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode key: %w", err)
+	}
+
+	return &TLSCertificate{
+		CertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		KeyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+// ServeTLSGateway serves the gateway over HTTPS on all the interfaces at the
+// given port (chosen by the system when `0`), so devices of the LAN can reach
+// it without exchanging plaintext, and returns the address it listens on.
+// certPEM and keyPEM are the PEM encoded certificate (chain) and its private
+// key, e.g. from GenerateSelfSignedCert, it fails before listening if they
+// don't match. See ServeGatewayLAN for the risks of a writable gateway.
+func (n *Node) ServeTLSGateway(port string, certPEM []byte, keyPEM []byte, writable bool) (string, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return "", fmt.Errorf("invalid tls certificate: %w", err)
+	}
+
+	l, err := n.serveGateway("/ip4/0.0.0.0/tcp/"+port, writable, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		return "", err
+	}
+	return l.Multiaddr(), nil
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestNodeServeTLSGateway(t *testing.T) {
+	testcontent := []byte("hello tls\n")
+
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	cert, err := GenerateSelfSignedCert("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateSelfSignedCert("localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := node.ServeTLSGateway("0", cert.CertPEM, other.KeyPEM, false); err == nil {
+		t.Fatal("expected mismatched certificate and key to be refused")
+	}
+
+	smaddr, err := node.ServeTLSGateway("0", cert.CertPEM, cert.KeyPEM, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maddr, err := ma.NewMultiaddr(smaddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := maddr.ValueForProtocol(ma.P_TCP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cid, err := node.AddBytes(testcontent, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the client trusts the self-signed certificate only
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(cert.CertPEM) {
+		t.Fatal("unable to parse the generated certificate")
+	}
+	client := http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}},
+	}
+
+	// the gateway url points to the tls listener
+	url, err := node.GatewayURL(cid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := fmt.Sprintf("https://127.0.0.1:%s/ipfs/%s", port, cid); url != expected {
+		t.Errorf("expected `%s` got `%s`", expected, url)
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, testcontent) {
+		t.Fatalf("content `%s` are different from `%s`", b, testcontent)
+	}
+
+	// plaintext requests are refused
+	plain := http.Client{Timeout: 5 * time.Second}
+	if resp, err := plain.Get(fmt.Sprintf("http://127.0.0.1:%s/ipfs/%s", port, cid)); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Error("expected plaintext requests to fail")
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ipfs_corerepo "github.com/ipfs/kubo/core/corerepo"
+)
+
+// gcEventBufferSize is the number of pending events a slow GCHandler can lag
+// behind before block removal events start being dropped
+const gcEventBufferSize = 256
+
+// GCHandler receives repo garbage collection events, it is called from a
+// dedicated goroutine so a slow handler doesn't block the collection.
+type GCHandler interface {
+	OnGCStarted()
+	OnBlockRemoved(cid string)
+	OnGCCompleted(blocksRemoved int64, bytesFreed int64)
+	OnGCError(message string)
+}
+
+type gcEventKind int
+
+const (
+	gcEventStarted gcEventKind = iota
+	gcEventBlockRemoved
+	gcEventCompleted
+	gcEventError
+)
+
+type gcEvent struct {
+	kind          gcEventKind
+	cid           string
+	blocksRemoved int64
+	bytesFreed    int64
+	message       string
+}
+
+type gcSubscription struct {
+	handler GCHandler
+	events  chan gcEvent
+	ctx     context.Context
+}
+
+// GCResult is the summary returned by RepoGC.
+type GCResult struct {
+	BlocksRemoved int64
+	BytesFreed    int64
+}
+
+// SubscribeGC registers a handler notified of every garbage collection run on
+// this node and returns the id of the subscription, see CancelSubscription.
+// The handler goroutine stops when the node is closed.
+func (n *Node) SubscribeGC(handler GCHandler) string {
+	id, ctx, done := n.newSubscription(SubscriptionKindGC)
+	sub := &gcSubscription{
+		handler: handler,
+		events:  make(chan gcEvent, gcEventBufferSize),
+		ctx:     ctx,
+	}
+
+	n.muGCSubs.Lock()
+	n.gcSubs = append(n.gcSubs, sub)
+	n.muGCSubs.Unlock()
+
+	go func() {
+		defer done()
+		defer n.removeGCSubscription(sub)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt := <-sub.events:
+				switch evt.kind {
+				case gcEventStarted:
+					sub.handler.OnGCStarted()
+				case gcEventBlockRemoved:
+					sub.handler.OnBlockRemoved(evt.cid)
+				case gcEventCompleted:
+					sub.handler.OnGCCompleted(evt.blocksRemoved, evt.bytesFreed)
+				case gcEventError:
+					sub.handler.OnGCError(evt.message)
+				}
+			}
+		}
+	}()
+
+	return id
+}
+
+func (n *Node) removeGCSubscription(sub *gcSubscription) {
+	n.muGCSubs.Lock()
+	defer n.muGCSubs.Unlock()
+
+	for i, s := range n.gcSubs {
+		if s == sub {
+			n.gcSubs = append(n.gcSubs[:i], n.gcSubs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (n *Node) emitGCEvent(evt gcEvent) {
+	n.muGCSubs.Lock()
+	subs := make([]*gcSubscription, len(n.gcSubs))
+	copy(subs, n.gcSubs)
+	n.muGCSubs.Unlock()
+
+	for _, sub := range subs {
+		if evt.kind == gcEventBlockRemoved {
+			// don't stall the collection on a slow handler
+			select {
+			case sub.events <- evt:
+			default:
+			}
+			continue
+		}
+
+		select {
+		case sub.events <- evt:
+		case <-sub.ctx.Done():
+		}
+	}
+}
+
+// GCProgress receives the progress of a garbage collection started with
+// RepoGCWithCallback, it is called from the goroutine of the request.
+type GCProgress interface {
+	OnProgress(blocksRemoved int64)
+	// OnComplete receives the JSON encoded GCResult.
+	OnComplete(result string)
+	OnError(message string)
+}
+
+// RepoGC runs a garbage collection over the blockstore, removing every
+// unpinned block, and returns a JSON encoded GCResult. The collection holds
+// the GC lock of the blockstore, pins added meanwhile wait for it to finish.
+func (n *Node) RepoGC() (string, error) {
+	res, err := n.repoGC(n.ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// RepoGCWithCallback runs a garbage collection like RepoGC in the background,
+// for large repos. It returns a handle that can be passed to CancelRequest,
+// the blocks removed before the cancellation stay removed. The number of
+// blocks removed so far and the outcome are reported to progress.
+func (n *Node) RepoGCWithCallback(progress GCProgress) (int64, error) {
+	id, ctx, done := n.newRequest()
+	go func() {
+		defer done()
+
+		var lastReport time.Time
+		res, err := n.repoGC(ctx, func(blocksRemoved int64) {
+			if time.Since(lastReport) >= progressReportInterval {
+				lastReport = time.Now()
+				progress.OnProgress(blocksRemoved)
+			}
+		})
+		if err != nil {
+			progress.OnError(err.Error())
+			return
+		}
+
+		out, err := json.Marshal(res)
+		if err != nil {
+			progress.OnError(err.Error())
+			return
+		}
+
+		progress.OnProgress(res.BlocksRemoved)
+		progress.OnComplete(string(out))
+	}()
+
+	return id, nil
+}
+
+// repoGC runs a garbage collection, onRemoved is called with the number of
+// blocks removed so far after each removal when not nil.
+func (n *Node) repoGC(ctx context.Context, onRemoved func(blocksRemoved int64)) (*GCResult, error) {
+	inode := n.ipfsMobile.IpfsNode
+
+	before, err := inode.Repo.GetStorageUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get repo storage usage: %w", err)
+	}
+
+	n.emitGCEvent(gcEvent{kind: gcEventStarted})
+
+	var res GCResult
+	var gcErr error
+	for r := range ipfs_corerepo.GarbageCollectAsync(inode, ctx) {
+		if r.Error != nil {
+			if gcErr == nil {
+				gcErr = r.Error
+			}
+			n.emitGCEvent(gcEvent{kind: gcEventError, message: r.Error.Error()})
+			continue
+		}
+
+		res.BlocksRemoved++
+		n.emitGCEvent(gcEvent{kind: gcEventBlockRemoved, cid: r.KeyRemoved.String()})
+		if onRemoved != nil {
+			onRemoved(res.BlocksRemoved)
+		}
+	}
+
+	// measured with the node context, ctx may be canceled
+	if after, err := inode.Repo.GetStorageUsage(n.ctx); err == nil && after < before {
+		res.BytesFreed = int64(before - after)
+	}
+
+	n.emitGCEvent(gcEvent{
+		kind:          gcEventCompleted,
+		blocksRemoved: res.BlocksRemoved,
+		bytesFreed:    res.BytesFreed,
+	})
+
+	if gcErr != nil {
+		return nil, fmt.Errorf("garbage collection failed: %w", gcErr)
+	}
+	return &res, nil
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+	ipfs_coreapi "github.com/ipfs/kubo/core/coreapi"
+)
+
+type testGCHandler struct {
+	removed   []string
+	completed chan int64
+}
+
+func (h *testGCHandler) OnGCStarted()              {}
+func (h *testGCHandler) OnBlockRemoved(cid string) { h.removed = append(h.removed, cid) }
+func (h *testGCHandler) OnGCCompleted(blocksRemoved int64, _ int64) {
+	h.completed <- blocksRemoved
+}
+func (h *testGCHandler) OnGCError(_ string) {}
+
+func TestNodeRepoGC(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	api, err := ipfs_coreapi.NewCoreAPI(node.ipfsMobile.IpfsNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := api.Unixfs().Add(context.Background(), ipfs_files.NewBytesFile([]byte("garbage")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &testGCHandler{completed: make(chan int64, 1)}
+	node.SubscribeGC(handler)
+
+	out, err := node.RepoGC()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res GCResult
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.BlocksRemoved == 0 {
+		t.Fatal("expected at least one block to be removed")
+	}
+
+	has, err := node.ipfsMobile.IpfsNode.Blockstore.Has(context.Background(), added.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if has {
+		t.Errorf("`%s` should have been garbage collected", added.Cid())
+	}
+
+	// the events are handled in order, the removed blocks are all reported
+	// before the completion unless more than the buffer size are pending
+	if res.BlocksRemoved >= gcEventBufferSize {
+		t.Fatalf("expected less than %d blocks to be removed, got %d", gcEventBufferSize, res.BlocksRemoved)
+	}
+
+	select {
+	case removed := <-handler.completed:
+		if removed != res.BlocksRemoved {
+			t.Errorf("expected %d blocks removed, got %d", res.BlocksRemoved, removed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for gc completed event")
+	}
+
+	if int64(len(handler.removed)) != res.BlocksRemoved {
+		t.Errorf("expected %d removed block events, got %d", res.BlocksRemoved, len(handler.removed))
+	}
+
+	// the blockstore is keyed by multihash, the cids are reported as raw
+	found := false
+	for _, removed := range handler.removed {
+		c, err := ipfs_cid.Decode(removed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found = found || c.Hash().String() == added.Cid().Hash().String()
+	}
+	if !found {
+		t.Errorf("expected a removed block event for `%s`, got %v", added.Cid(), handler.removed)
+	}
+}
+
+type testGCProgress struct {
+	result chan string
+	errors chan string
+}
+
+func (p *testGCProgress) OnProgress(_ int64)       {}
+func (p *testGCProgress) OnComplete(result string) { p.result <- result }
+func (p *testGCProgress) OnError(message string)   { p.errors <- message }
+
+func TestNodeRepoGCWithCallback(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if _, err := node.AddBytes([]byte("garbage"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	progress := &testGCProgress{result: make(chan string, 1), errors: make(chan string, 1)}
+	if _, err := node.RepoGCWithCallback(progress); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case out := <-progress.result:
+		var res GCResult
+		if err := json.Unmarshal([]byte(out), &res); err != nil {
+			t.Fatal(err)
+		}
+		if res.BlocksRemoved == 0 {
+			t.Error("expected at least one block to be removed")
+		}
+	case msg := <-progress.errors:
+		t.Fatalf("gc failed: %s", msg)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for gc to complete")
+	}
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	p2p_peerstore "github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/p2p/protocol/identify"
+)
+
+// PushIdentify pushes the current addresses of the node to every connected
+// peer through the identify push protocol, so they don't keep stale addresses
+// after a network switch until the next identify. It fails if the host
+// doesn't run the identify service.
+func (n *Node) PushIdentify() error {
+	h := n.ipfsMobile.PeerHost()
+
+	if !handlesProtocol(h.Mux().Protocols(), identify.IDPush) {
+		return errors.New("the host has no identify service")
+	}
+
+	// the identify service pushes to every peer on a local addresses update
+	evt := event.EvtLocalAddressesUpdated{}
+	for _, addr := range h.Addrs() {
+		evt.Current = append(evt.Current, event.UpdatedAddress{
+			Address: addr,
+			Action:  event.Maintained,
+		})
+	}
+	if cab, ok := p2p_peerstore.GetCertifiedAddrBook(h.Peerstore()); ok {
+		evt.SignedPeerRecord = cab.GetPeerRecord(h.ID())
+	}
+
+	em, err := h.EventBus().Emitter(new(event.EvtLocalAddressesUpdated))
+	if err != nil {
+		return fmt.Errorf("unable to push identify: %w", err)
+	}
+	defer em.Close()
+
+	return em.Emit(evt)
+}
+
+func handlesProtocol(protos []string, proto string) bool {
+	for _, p := range protos {
+		if p == proto {
+			return true
+		}
+	}
+	return false
+}
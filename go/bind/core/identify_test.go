@@ -0,0 +1,17 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestNodePushIdentify(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if err := node.PushIdentify(); err != nil {
+		t.Fatal(err)
+	}
+}
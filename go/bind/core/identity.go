@@ -0,0 +1,85 @@
+package core
+
+import (
+	"errors"
+	"strings"
+
+	p2p_crypto "github.com/libp2p/go-libp2p/core/crypto"
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// PublicKey returns the public key of the node identity, marshaled in the
+// libp2p protobuf key format.
+func (n *Node) PublicKey() ([]byte, error) {
+	priv := n.ipfsMobile.IpfsNode.PrivateKey
+	if priv == nil {
+		return nil, errors.New("node has no identity key")
+	}
+
+	return p2p_crypto.MarshalPublicKey(priv.GetPublic())
+}
+
+// PeerID returns the base58 encoded peer id of the node.
+func (n *Node) PeerID() string {
+	return n.ipfsMobile.PeerHost().ID().Pretty()
+}
+
+// ListenAddrs returns the current addresses of the node, one per line,
+// including the ones of listeners added since the node started.
+func (n *Node) ListenAddrs() string {
+	addrs := n.ipfsMobile.PeerHost().Addrs()
+
+	lines := make([]string, len(addrs))
+	for i, addr := range addrs {
+		lines[i] = addr.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ShareableAddr returns the address another device should use to connect to
+// the node, ending with the node peer id, e.g. to be shared as a QR code. A
+// direct public address is preferred over a LAN one, then over an address
+// through a relay. It fails when the node has no dialable address.
+func (n *Node) ShareableAddr() (string, error) {
+	h := n.ipfsMobile.PeerHost()
+
+	best, bestRank := ma.Multiaddr(nil), -1
+	for _, addr := range h.Addrs() {
+		rank := shareableAddrRank(addr)
+		if rank > bestRank {
+			best, bestRank = addr, rank
+		}
+	}
+
+	if best == nil {
+		return "", errors.New("node has no dialable address")
+	}
+
+	addrs, err := p2p_peer.AddrInfoToP2pAddrs(&p2p_peer.AddrInfo{
+		ID:    h.ID(),
+		Addrs: []ma.Multiaddr{best},
+	})
+	if err != nil {
+		return "", err
+	}
+	return addrs[0].String(), nil
+}
+
+// shareableAddrRank ranks the addresses of ShareableAddr, -1 for an address
+// other devices can't dial.
+func shareableAddrRank(addr ma.Multiaddr) int {
+	switch {
+	case isRelayedAddr(addr):
+		return 0
+	case manet.IsIPLoopback(addr), manet.IsIPUnspecified(addr), manet.IsIP6LinkLocal(addr):
+		return -1
+	case manet.IsPublicAddr(addr):
+		return 2
+	case manet.IsPrivateAddr(addr):
+		return 1
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	p2p_crypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestNodePublicKey(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	raw, err := node.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, err := p2p_crypto.UnmarshalPublicKey(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pid, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pid != node.ipfsMobile.IpfsNode.Identity {
+		t.Errorf("public key doesn't match node identity, expected `%s` got `%s`", node.ipfsMobile.IpfsNode.Identity, pid)
+	}
+}
+
+func TestShareableAddrRank(t *testing.T) {
+	cases := []struct {
+		addr string
+		rank int
+	}{
+		{"/ip4/127.0.0.1/tcp/4001", -1},
+		{"/ip6/::1/udp/4001/quic", -1},
+		{"/ip6/fe80::1/tcp/4001", -1},
+		{"/ip4/192.168.1.2/tcp/4001", 1},
+		{"/ip4/1.2.3.4/udp/4001/quic", 2},
+		{"/ip4/1.2.3.4/tcp/4001/p2p/QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN/p2p-circuit", 0},
+	}
+
+	for _, c := range cases {
+		if rank := shareableAddrRank(ma.StringCast(c.addr)); rank != c.rank {
+			t.Errorf("expected rank %d for `%s`, got %d", c.rank, c.addr, rank)
+		}
+	}
+}
+
+func TestNodeShareableAddr(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	saddr, err := node.ShareableAddr()
+	if err != nil {
+		t.Skipf("no dialable address: %s", err)
+	}
+
+	pi, err := peer.AddrInfoFromString(saddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pi.ID != node.ipfsMobile.IpfsNode.Identity {
+		t.Errorf("expected peer id `%s`, got `%s`", node.ipfsMobile.IpfsNode.Identity, pi.ID)
+	}
+
+	if shareableAddrRank(pi.Addrs[0]) < 0 {
+		t.Errorf("`%s` is not dialable", saddr)
+	}
+}
+
+func TestNodePeerIDAndListenAddrs(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	pid, err := peer.Decode(node.PeerID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid != node.ipfsMobile.IpfsNode.Identity {
+		t.Errorf("expected peer id `%s` got `%s`", node.ipfsMobile.IpfsNode.Identity, pid)
+	}
+
+	listen := func() []string {
+		t.Helper()
+
+		addrs := node.ListenAddrs()
+		if addrs == "" {
+			return nil
+		}
+
+		lines := strings.Split(addrs, "\n")
+		for _, line := range lines {
+			if _, err := ma.NewMultiaddr(line); err != nil {
+				t.Fatalf("invalid listen address `%s`: %s", line, err)
+			}
+		}
+		return lines
+	}
+
+	before := listen()
+
+	// listening on a new address is reflected right away
+	maddr, err := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.ipfsMobile.PeerHost().Network().Listen(maddr); err != nil {
+		t.Fatal(err)
+	}
+
+	if after := listen(); len(after) <= len(before) {
+		t.Errorf("expected a new listen address, got `%v` after `%v`", after, before)
+	}
+}
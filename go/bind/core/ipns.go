@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ipfs_namesys "github.com/ipfs/go-namesys"
+	ipfs_iface "github.com/ipfs/interface-go-ipfs-core"
+	ipfs_options "github.com/ipfs/interface-go-ipfs-core/options"
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// IPNSPublishResult is returned by PublishIPNS.
+type IPNSPublishResult struct {
+	// Name is the IPNS name the path got published under, the id of the
+	// key.
+	Name string
+	// Value is the published path.
+	Value string
+	// Routed is true when the record got put to the routing system, false
+	// when it is only stored locally and served to the peers asking the node.
+	Routed bool
+	// RoutingError is the reason the record didn't get put to the routing
+	// system when Routed is false.
+	RoutingError string
+}
+
+// PublishIPNS publishes the given path, e.g. `/ipfs/<cid>` or a bare cid,
+// under the key named keyName, the key of the node when empty. The record is
+// valid for lifetimeSeconds (24 hours when not positive). Putting the record
+// to the DHT can be slow on mobile, it is given at most timeoutSeconds (no
+// timeout when not positive): when the put fails or times out once the record is stored
+// locally, the publication is still a success, with Routed false. It returns
+// a JSON encoded IPNSPublishResult.
+func (n *Node) PublishIPNS(cidPath string, keyName string, lifetimeSeconds int64, timeoutSeconds int64) (string, error) {
+	p, err := parsePath(cidPath)
+	if err != nil {
+		return "", err
+	}
+
+	if keyName == "" {
+		keyName = "self"
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return "", err
+	}
+
+	pid, err := n.ipnsKeyID(keyName)
+	if err != nil {
+		return "", err
+	}
+
+	// the sequence number of the local record tells whether it got updated
+	// when the publication fails
+	publisher := ipfs_namesys.NewIpnsPublisher(n.ipfsMobile.IpfsNode.Routing, n.ipfsMobile.IpfsNode.Repo.Datastore())
+	previous, err := publisher.GetPublished(n.ctx, pid, false)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the ipns record of `%s`: %w", keyName, err)
+	}
+
+	opts := []ipfs_options.NamePublishOption{
+		ipfs_options.Name.Key(keyName),
+		ipfs_options.Name.AllowOffline(true),
+	}
+	if lifetimeSeconds > 0 {
+		opts = append(opts, ipfs_options.Name.ValidTime(time.Duration(lifetimeSeconds)*time.Second))
+	}
+
+	ctx := n.ctx
+	if timeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	res := &IPNSPublishResult{
+		Name:   ipfs_iface.FormatKeyID(pid),
+		Value:  p.String(),
+		Routed: true,
+	}
+
+	if _, err := api.Name().Publish(ctx, p, opts...); err != nil {
+		current, lerr := publisher.GetPublished(n.ctx, pid, false)
+		if lerr != nil || current == nil || string(current.GetValue()) != p.String() ||
+			(previous != nil && current.GetSequence() <= previous.GetSequence()) {
+			return "", fmt.Errorf("unable to publish `%s`: %w", p, err)
+		}
+
+		res.Routed = false
+		res.RoutingError = err.Error()
+	}
+
+	out, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// ResolveIPNS resolves the given IPNS name, with or without the `/ipns/`
+// prefix, to the path it points to, e.g. `/ipfs/<cid>`. It waits for at most
+// timeoutSeconds (no timeout when not positive).
+func (n *Node) ResolveIPNS(name string, timeoutSeconds int64) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty ipns name")
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return "", err
+	}
+
+	ctx := n.ctx
+	if timeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	p, err := api.Name().Resolve(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve `%s`: %w", name, err)
+	}
+	return p.String(), nil
+}
+
+// ipnsKeyID returns the peer id of the key named keyName.
+func (n *Node) ipnsKeyID(keyName string) (p2p_peer.ID, error) {
+	api, err := n.coreAPI()
+	if err != nil {
+		return "", err
+	}
+
+	if keyName == "self" {
+		key, err := api.Key().Self(n.ctx)
+		if err != nil {
+			return "", err
+		}
+		return key.ID(), nil
+	}
+
+	keys, err := api.Key().List(n.ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to list keys: %w", err)
+	}
+	for _, key := range keys {
+		if key.Name() == keyName {
+			return key.ID(), nil
+		}
+	}
+	return "", fmt.Errorf("no key named `%s`", keyName)
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNodeIPNS(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	c, err := node.AddBytes([]byte("latest content root"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without peers the record can only be stored locally
+	out, err := node.PublishIPNS(c, "", 3600, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res IPNSPublishResult
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Name == "" {
+		t.Error("expected an ipns name")
+	}
+	if res.Value != "/ipfs/"+c {
+		t.Errorf("expected value `/ipfs/%s` got `%s`", c, res.Value)
+	}
+	if !res.Routed && res.RoutingError == "" {
+		t.Error("expected the routing error of a local publication")
+	}
+
+	for _, name := range []string{res.Name, "/ipns/" + res.Name} {
+		resolved, err := node.ResolveIPNS(name, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resolved != "/ipfs/"+c {
+			t.Errorf("expected `/ipfs/%s` got `%s`", c, resolved)
+		}
+	}
+
+	if _, err := node.PublishIPNS(c, "missing", 0, 0); err == nil {
+		t.Error("expected an error for a missing key")
+	}
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+)
+
+// latencyPingTimeout is the time PeerLatencies waits for the ping of a peer
+// without a recent latency sample
+const latencyPingTimeout = 5 * time.Second
+
+// latencyRefreshInterval is the age after which PeerLatencies pings a peer
+// again, the moving average is otherwise only updated by the other services
+const latencyRefreshInterval = time.Minute
+
+// latencySamples records when PeerLatencies last measured each peer.
+type latencySamples struct {
+	mu sync.Mutex
+	at map[p2p_peer.ID]time.Time
+}
+
+// stale returns the peers never measured by PeerLatencies or measured more
+// than latencyRefreshInterval ago, the samples of the disconnected peers are
+// forgotten.
+func (s *latencySamples) stale(peers []p2p_peer.ID, now time.Time) []p2p_peer.ID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	connected := make(map[p2p_peer.ID]time.Time, len(peers))
+	var stale []p2p_peer.ID
+	for _, pid := range peers {
+		at, ok := s.at[pid]
+		if !ok || now.Sub(at) >= latencyRefreshInterval {
+			stale = append(stale, pid)
+			continue
+		}
+		connected[pid] = at
+	}
+	s.at = connected
+	return stale
+}
+
+func (s *latencySamples) record(pid p2p_peer.ID, at time.Time) {
+	s.mu.Lock()
+	s.at[pid] = at
+	s.mu.Unlock()
+}
+
+// PeerLatencies returns a JSON encoded map of the round trip time in
+// milliseconds to each connected peer, from the moving average recorded in
+// the peerstore. Peers without a sample newer than a minute are pinged
+// first, those not answering keep their previous average or are left out.
+// An empty map is returned when no peer is connected.
+func (n *Node) PeerLatencies() (string, error) {
+	h := n.ipfsMobile.PeerHost()
+	pstore := h.Peerstore()
+
+	// ping the peers not measured recently, the ping service records their
+	// latency
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithTimeout(n.ctx, latencyPingTimeout)
+	defer cancel()
+	for _, pid := range n.latencySamples.stale(h.Network().Peers(), time.Now()) {
+		wg.Add(1)
+		go func(pid p2p_peer.ID) {
+			defer wg.Done()
+			if res, ok := <-ping.Ping(ctx, h, pid); ok && res.Error == nil {
+				n.latencySamples.record(pid, time.Now())
+			}
+		}(pid)
+	}
+	wg.Wait()
+
+	latencies := make(map[string]int64)
+	for _, pid := range h.Network().Peers() {
+		if rtt := pstore.LatencyEWMA(pid); rtt > 0 {
+			latencies[pid.String()] = rtt.Milliseconds()
+		}
+	}
+
+	out, err := json.Marshal(latencies)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
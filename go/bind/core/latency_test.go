@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestNodePeerLatencies(t *testing.T) {
+	path1, clean := testingTempDir(t, "repo1")
+	defer clean()
+
+	node1, clean := testingNode(t, path1)
+	defer clean()
+
+	latencies := func() map[string]int64 {
+		t.Helper()
+
+		out, err := node1.PeerLatencies()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var m map[string]int64
+		if err := json.Unmarshal([]byte(out), &m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	if m := latencies(); m == nil || len(m) != 0 {
+		t.Fatalf("expected an empty map without peers, got %v", m)
+	}
+
+	path2, clean := testingTempDir(t, "repo2")
+	defer clean()
+
+	node2, clean := testingNode(t, path2)
+	defer clean()
+
+	h1, h2 := node1.ipfsMobile.PeerHost(), node2.ipfsMobile.PeerHost()
+	err := h2.Connect(context.Background(), p2p_peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := latencies()[h2.ID().String()]; !ok {
+		t.Fatalf("expected a latency for connected peer `%s`", h2.ID())
+	}
+
+	sampled := func() time.Time {
+		node1.latencySamples.mu.Lock()
+		defer node1.latencySamples.mu.Unlock()
+		return node1.latencySamples.at[h2.ID()]
+	}
+
+	// a recent sample is reused, an old one is measured again
+	first := sampled()
+	latencies()
+	if at := sampled(); !at.Equal(first) {
+		t.Errorf("expected the sample of %s to be reused, got a new one at %s", first, at)
+	}
+
+	node1.latencySamples.record(h2.ID(), first.Add(-latencyRefreshInterval))
+	latencies()
+	if at := sampled(); !at.After(first) {
+		t.Errorf("expected a sample newer than %s got %s", first, at)
+	}
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// Listener is an API or gateway endpoint served by the node, returned by
+// ServeAPIMultiaddr and ServeGatewayMultiaddr so it can be stopped without
+// closing the node.
+type Listener struct {
+	node    *Node
+	ml      manet.Listener
+	server  *http.Server
+	maddr   string
+	gateway bool
+	// secure is set for the gateways served over TLS
+	secure bool
+
+	// seq orders the listeners of the node by start
+	seq uint64
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Multiaddr returns the address the listener is bound to, with the port
+// chosen by the system when listening on port 0.
+func (l *Listener) Multiaddr() string { return l.maddr }
+
+// Close stops serving the endpoint, closing it again is a no-op. The
+// requests being served get up to defaultCloseTimeout to complete, then the
+// remaining connections, including the idle keep-alive ones, are closed.
+func (l *Listener) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloseTimeout)
+	defer cancel()
+
+	// the listener stays tracked until the server is shut down, so closing
+	// the node meanwhile waits for it as well
+	err := l.shutdown(ctx)
+	if err == context.DeadlineExceeded {
+		log.Printf("`%s` didn't drain in %s, closing its connections", l.maddr, defaultCloseTimeout)
+		err = nil
+	}
+
+	n := l.node
+	n.muListeners.Lock()
+	if n.listeners[l.maddr] == l {
+		delete(n.listeners, l.maddr)
+	}
+	n.muListeners.Unlock()
+
+	return err
+}
+
+func (l *Listener) close() error {
+	l.closeOnce.Do(func() {
+		l.closeErr = l.ml.Close()
+	})
+	return l.closeErr
+}
+
+// shutdown stops serving the endpoint and waits for the requests being served
+// to complete, until ctx is done, then closes the remaining connections.
+func (l *Listener) shutdown(ctx context.Context) error {
+	err := l.close()
+	if serr := l.server.Shutdown(ctx); serr != nil {
+		l.server.Close()
+		return serr
+	}
+	return err
+}
+
+// addListener tracks a served endpoint so Close stops it along with the node.
+func (n *Node) addListener(ml manet.Listener, server *http.Server, gateway bool, secure bool) *Listener {
+	l := &Listener{
+		node:    n,
+		ml:      ml,
+		server:  server,
+		maddr:   ml.Multiaddr().String(),
+		gateway: gateway,
+		secure:  secure,
+	}
+
+	n.muListeners.Lock()
+	if n.listeners == nil {
+		n.listeners = make(map[string]*Listener)
+	}
+	n.listenerSeq++
+	l.seq = n.listenerSeq
+	n.listeners[l.maddr] = l
+	n.muListeners.Unlock()
+
+	return l
+}
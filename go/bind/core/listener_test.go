@@ -0,0 +1,214 @@
+package core
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+func TestNodeListenerClose(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	// closed by the test
+	node, err := NewNode(repo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api, err := node.ServeAPIMultiaddr("/ip4/127.0.0.1/tcp/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gateway, err := node.ServeGatewayMultiaddr("/ip4/127.0.0.1/tcp/0", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dial := func(l *Listener) error {
+		t.Helper()
+
+		maddr, err := ma.NewMultiaddr(l.Multiaddr())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		conn, err := manet.Dial(maddr)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	// an idle keep-alive connection to the gateway
+	gaddr, err := ma.NewMultiaddr(gateway.Multiaddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := manet.ToNetAddr(gaddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{}
+	resp, err := client.Get("http://" + addr.String() + "/ipfs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if err := api.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// closing twice is a no-op
+	if err := api.Close(); err != nil {
+		t.Errorf("expected a second close to succeed, got %s", err)
+	}
+
+	if err := dial(api); err == nil {
+		t.Error("expected the api listener to be closed")
+	}
+
+	if err := dial(gateway); err != nil {
+		t.Errorf("expected the gateway to keep running: %s", err)
+	}
+
+	node.muListeners.Lock()
+	_, tracked := node.listeners[api.Multiaddr()]
+	node.muListeners.Unlock()
+	if tracked {
+		t.Error("expected the closed listener to be untracked")
+	}
+
+	if err := gateway.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the server got shut down along with its keep-alive connections
+	if resp, err := client.Get("http://" + addr.String() + "/ipfs/"); err == nil {
+		resp.Body.Close()
+		t.Error("expected the closed gateway not to serve requests anymore")
+	}
+
+	gateway, err = node.ServeGatewayMultiaddr("/ip4/127.0.0.1/tcp/0", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dial(gateway); err == nil {
+		t.Error("expected the gateway to be closed with the node")
+	}
+
+	// the handle of a listener closed with the node can still be closed
+	if err := gateway.Close(); err != nil {
+		t.Errorf("expected closing after the node to succeed, got %s", err)
+	}
+}
+
+func TestNodeMaxHTTPConns(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	cfg := NewNodeConfig()
+	if err := cfg.SetMaxHTTPConns(-1); err == nil {
+		t.Error("a negative max should be refused")
+	}
+	if err := cfg.SetMaxHTTPConns(2); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := NewNode(repo, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	// dial opens a connection to l, it's served once a request gets a response
+	dial := func(l *Listener) net.Conn {
+		t.Helper()
+
+		maddr, err := ma.NewMultiaddr(l.Multiaddr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn, err := manet.Dial(maddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+	served := func(conn net.Conn, wait time.Duration) bool {
+		t.Helper()
+
+		conn.SetDeadline(time.Now().Add(wait))
+		if _, err := io.WriteString(conn, "POST /api/v0/version HTTP/1.1\r\nHost: localhost\r\nContent-Length: 0\r\n\r\n"); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	}
+
+	api, err := node.ServeAPIMultiaddr("/ip4/127.0.0.1/tcp/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the keep-alive connections hold the slots
+	first, second := dial(api), dial(api)
+	defer second.Close()
+	if !served(first, 5*time.Second) || !served(second, 5*time.Second) {
+		t.Fatal("expected the connections below the max to be served")
+	}
+
+	waiting := dial(api)
+	defer waiting.Close()
+	waitingServed := make(chan bool)
+	go func() { waitingServed <- served(waiting, 10*time.Second) }()
+
+	select {
+	case <-waitingServed:
+		t.Fatal("expected the connection over the max to wait for a slot")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	first.Close()
+	if !<-waitingServed {
+		t.Error("expected the waiting connection to be served once a slot is freed")
+	}
+
+	// 0 means unlimited
+	node.maxHTTPConns = 0
+	unlimited, err := node.ServeAPIMultiaddr("/ip4/127.0.0.1/tcp/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		conn := dial(unlimited)
+		defer conn.Close()
+		if !served(conn, 5*time.Second) {
+			t.Fatalf("expected connection %d to be served without a max", i+1)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	ipfs_log "github.com/ipfs/go-log/v2"
+)
+
+// defaultLogBufferSize is the default number of log lines kept in memory
+const defaultLogBufferSize = 1000
+
+// recentLogs keeps the last lines logged through the ipfs logging system, it
+// captures every log emitted since the package got loaded.
+var recentLogs = newLogRing(defaultLogBufferSize)
+
+func init() {
+	pipe := ipfs_log.NewPipeReader(ipfs_log.PipeFormat(ipfs_log.PlaintextOutput))
+	go recentLogs.capture(pipe)
+}
+
+// logRing is a fixed size ring buffer of log lines.
+type logRing struct {
+	mu    sync.Mutex
+	lines []string
+	start int
+	count int
+}
+
+func newLogRing(size int) *logRing {
+	return &logRing{lines: make([]string, size)}
+}
+
+// capture reads the lines of r into the ring until r is closed.
+func (r *logRing) capture(rd io.Reader) {
+	br := bufio.NewReader(rd)
+	for {
+		line, err := br.ReadString('\n')
+		if line = strings.TrimRight(line, "\n"); line != "" {
+			r.add(line)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (r *logRing) add(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	size := len(r.lines)
+	if r.count < size {
+		r.lines[(r.start+r.count)%size] = line
+		r.count++
+		return
+	}
+
+	// full, overwrite the oldest line
+	r.lines[r.start] = line
+	r.start = (r.start + 1) % size
+}
+
+// last returns the last n lines, oldest first, every line if n <= 0.
+func (r *logRing) last(n int) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lastLocked(n)
+}
+
+func (r *logRing) lastLocked(n int) []string {
+	if n <= 0 || n > r.count {
+		n = r.count
+	}
+
+	out := make([]string, n)
+	size := len(r.lines)
+	for i := range out {
+		out[i] = r.lines[(r.start+r.count-n+i)%size]
+	}
+	return out
+}
+
+// resize changes the number of lines kept, keeping the most recent ones.
+func (r *logRing) resize(size int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.lastLocked(size)
+	r.lines = make([]string, size)
+	r.start = 0
+	r.count = copy(r.lines, kept)
+}
+
+// GetRecentLogs returns the last n log lines emitted by the ipfs and libp2p
+// loggers, oldest first and separated by a newline, every buffered line if n
+// is lower than 1. Only the lines enabled by the log level of each subsystem
+// are captured.
+func GetRecentLogs(n int) (string, error) {
+	return strings.Join(recentLogs.last(n), "\n"), nil
+}
+
+// SetLogBufferSize sets the number of log lines kept in memory for
+// GetRecentLogs (default 1000), the most recent lines are kept.
+func SetLogBufferSize(lines int) error {
+	if lines < 1 {
+		return fmt.Errorf("invalid log buffer size %d", lines)
+	}
+
+	recentLogs.resize(lines)
+	return nil
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestLogRing(t *testing.T) {
+	ring := newLogRing(3)
+
+	for i := 0; i < 5; i++ {
+		ring.add(fmt.Sprintf("line %d", i))
+	}
+
+	if lines := ring.last(0); !reflect.DeepEqual(lines, []string{"line 2", "line 3", "line 4"}) {
+		t.Errorf("unexpected lines `%v`", lines)
+	}
+
+	if lines := ring.last(2); !reflect.DeepEqual(lines, []string{"line 3", "line 4"}) {
+		t.Errorf("unexpected lines `%v`", lines)
+	}
+
+	ring.resize(2)
+	ring.add("line 5")
+	if lines := ring.last(0); !reflect.DeepEqual(lines, []string{"line 4", "line 5"}) {
+		t.Errorf("unexpected lines after resize `%v`", lines)
+	}
+
+	ring.resize(4)
+	ring.add("line 6")
+	if lines := ring.last(0); !reflect.DeepEqual(lines, []string{"line 4", "line 5", "line 6"}) {
+		t.Errorf("unexpected lines after resize `%v`", lines)
+	}
+}
+
+func TestSetLogBufferSize(t *testing.T) {
+	if err := SetLogBufferSize(0); err == nil {
+		t.Error("expected an error for an empty buffer")
+	}
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/ipfsutil"
+	"go.uber.org/zap"
+)
+
+// mdnsRecheckInterval is the interval at which the multicast interfaces are
+// checked when mDNS couldn't be started because none was available.
+var mdnsRecheckInterval = 30 * time.Second
+
+// multicastInterfaces lists the interfaces mDNS can run on.
+var multicastInterfaces = ipfsutil.GetMulticastInterfaces
+
+// StartMDNS starts the discovery of the peers on the local network via mDNS,
+// e.g. when the device joins a WiFi network, holding the mDNS locker until it
+// is stopped. When no multicast interface is available the service starts
+// once one shows up. Starting it again is a no-op. It is started by NewNode
+// when enabled in the repo config (Discovery.MDNS.Enabled).
+func (n *Node) StartMDNS() error {
+	n.muMDNS.Lock()
+	defer n.muMDNS.Unlock()
+
+	if n.mdnsService != nil {
+		return nil
+	}
+	if n.ctx.Err() != nil {
+		return fmt.Errorf("unable to start mdns: node is closed")
+	}
+
+	ifaces, err := multicastInterfaces()
+	if err != nil {
+		return fmt.Errorf("unable to get multicast interfaces: %w", err)
+	}
+
+	n.mdnsLocker.Lock()
+
+	logger, _ := zap.NewDevelopment()
+	h := n.ipfsMobile.PeerHost()
+	dh := &mdnsNotifee{
+		Notifee: ipfsutil.DiscoveryHandler(n.ctx, logger, h),
+		tracker: n.discovered,
+		node:    n,
+		self:    h.ID(),
+	}
+	service := ipfsutil.NewMdnsService(logger, h, ipfsutil.MDNSServiceName, dh)
+	if n.mdnsHidden {
+		service.SetAdvertise(false)
+	}
+
+	ctx, cancel := context.WithCancel(n.ctx)
+	if len(ifaces) > 0 {
+		logger.Info("starting mdns")
+		if err := service.Start(); err != nil {
+			cancel()
+			n.mdnsLocker.Unlock()
+			return fmt.Errorf("unable to start mdns service: %w", err)
+		}
+	} else {
+		// no multicast interface (e.g. cellular only), wait for one
+		logger.Warn("no multicast interfaces found, mdns service will start once one is available")
+		go n.watchMulticastInterfaces(ctx, service)
+	}
+
+	n.mdnsService = service
+	n.mdnsCancel = cancel
+	return nil
+}
+
+// StopMDNS stops the mDNS discovery and advertisement, e.g. on cellular
+// networks, and releases the mDNS locker. Stopping it again is a no-op.
+func (n *Node) StopMDNS() error {
+	n.muMDNS.Lock()
+	defer n.muMDNS.Unlock()
+
+	if n.mdnsService == nil {
+		return nil
+	}
+
+	n.mdnsCancel()
+	err := n.mdnsService.Close()
+	n.mdnsLocker.Unlock()
+
+	n.mdnsService = nil
+	n.mdnsCancel = nil
+
+	if err != nil {
+		return fmt.Errorf("unable to stop mdns service: %w", err)
+	}
+	return nil
+}
+
+// SetMDNSAdvertise starts or stops announcing this node on the local network
+// via mDNS, discovery of the other peers keeps running. The setting is kept
+// while mDNS is stopped or not started yet (no multicast interface found) and
+// applied once it starts.
+func (n *Node) SetMDNSAdvertise(enabled bool) error {
+	n.muMDNS.Lock()
+	defer n.muMDNS.Unlock()
+
+	n.mdnsHidden = !enabled
+	if n.mdnsService == nil {
+		return nil
+	}
+
+	if err := n.mdnsService.SetAdvertise(enabled); err != nil {
+		return fmt.Errorf("unable to set mdns advertisement: %w", err)
+	}
+	return nil
+}
+
+// watchMulticastInterfaces periodically checks for a multicast interface (e.g.
+// once the device joins a WiFi network) and starts the mDNS service when one
+// shows up. It returns once mDNS is started, or stopped along with ctx.
+func (n *Node) watchMulticastInterfaces(ctx context.Context, service ipfsutil.MdnsService) {
+	ticker := time.NewTicker(mdnsRecheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ifaces, err := multicastInterfaces()
+		if err != nil {
+			log.Printf("unable to get multicast interfaces: %s", err)
+			continue
+		}
+		if len(ifaces) == 0 {
+			continue
+		}
+
+		n.muMDNS.Lock()
+		if ctx.Err() != nil {
+			// mdns has been stopped meanwhile
+			n.muMDNS.Unlock()
+			return
+		}
+		err = service.Start()
+		n.muMDNS.Unlock()
+
+		if err != nil {
+			log.Printf("unable to start mdns service: %s", err)
+			continue
+		}
+
+		log.Printf("multicast interface found, mdns service started")
+		return
+	}
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testMdnsService struct {
+	started int32
+	closed  int32
+}
+
+func (s *testMdnsService) Start() error {
+	atomic.AddInt32(&s.started, 1)
+	return nil
+}
+
+func (s *testMdnsService) Close() error {
+	atomic.AddInt32(&s.closed, 1)
+	return nil
+}
+
+func (s *testMdnsService) SetAdvertise(bool) error { return nil }
+
+func TestWatchMulticastInterfaces(t *testing.T) {
+	defer func(interval time.Duration, ifaces func() ([]net.Interface, error)) {
+		mdnsRecheckInterval = interval
+		multicastInterfaces = ifaces
+	}(mdnsRecheckInterval, multicastInterfaces)
+
+	mdnsRecheckInterval = 10 * time.Millisecond
+	var available int32
+	multicastInterfaces = func() ([]net.Interface, error) {
+		if atomic.LoadInt32(&available) == 0 {
+			return nil, nil
+		}
+		return []net.Interface{{Name: "wlan0", Flags: net.FlagUp | net.FlagMulticast}}, nil
+	}
+
+	watch := func() (*Node, *testMdnsService, <-chan struct{}) {
+		t.Helper()
+
+		service := &testMdnsService{}
+		ctx, cancel := context.WithCancel(context.Background())
+		node := &Node{
+			mdnsLocker:  &sync.Mutex{},
+			mdnsService: service,
+			mdnsCancel:  cancel,
+		}
+		node.mdnsLocker.Lock()
+
+		done := make(chan struct{})
+		go func() {
+			node.watchMulticastInterfaces(ctx, service)
+			close(done)
+		}()
+		return node, service, done
+	}
+
+	// the service starts once an interface shows up
+	_, service, done := watch()
+
+	time.Sleep(5 * mdnsRecheckInterval)
+	if started := atomic.LoadInt32(&service.started); started != 0 {
+		t.Fatalf("expected the service to wait for an interface, started %d times", started)
+	}
+
+	atomic.StoreInt32(&available, 1)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the watcher to return once the service is started")
+	}
+	if started := atomic.LoadInt32(&service.started); started != 1 {
+		t.Errorf("expected the service to be started once got %d", started)
+	}
+
+	// stopping mdns while waiting cancels the watcher
+	atomic.StoreInt32(&available, 0)
+	node, service, done := watch()
+
+	if err := node.StopMDNS(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the watcher to be canceled by StopMDNS")
+	}
+
+	atomic.StoreInt32(&available, 1)
+	time.Sleep(5 * mdnsRecheckInterval)
+	if started := atomic.LoadInt32(&service.started); started != 0 {
+		t.Errorf("expected the stopped service not to be started, started %d times", started)
+	}
+	if closed := atomic.LoadInt32(&service.closed); closed != 1 {
+		t.Errorf("expected the service to be closed once got %d", closed)
+	}
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+// meteredProvideRate is the maximum number of provides per second while the
+// connection is metered
+const meteredProvideRate = 2
+
+// meteredState holds the settings replaced by SetMetered, restored when the
+// connection isn't metered anymore.
+type meteredState struct {
+	reprovideInterval time.Duration
+	provideInterval   time.Duration
+	reachability      network.Reachability
+}
+
+// SetMetered reduces the background traffic of the node while the connection
+// is metered, e.g. when the OS reports a cellular connection or a metered
+// WiFi. When enabled it:
+//   - pauses reproviding, as SetReprovideInterval(0) does;
+//   - switches the DHT to client mode, as SetDHTServerMode(false) does, so
+//     the node stops answering the queries of other peers;
+//   - limits the provides to 2 per second, as SetMaxProvideRate(2) does, a
+//     lower rate already set is kept.
+//
+// The subsystems disabled on this node are left alone. Disabling it restores
+// the settings in use when it was enabled, overriding the changes made to
+// them in between. In background mode it applies to the settings restored
+// by ExitBackgroundMode. The dials aren't affected, their concurrency can only
+// be bounded when the node is created with NodeConfig.SetMaxConcurrentDials.
+func (n *Node) SetMetered(metered bool) {
+	n.muMetered.Lock()
+	defer n.muMetered.Unlock()
+
+	if metered == (n.metered != nil) {
+		return
+	}
+
+	dhtEnabled := n.dhtHost != nil && n.ipfsMobile.IpfsNode.DHT != nil
+
+	if metered {
+		state := &meteredState{}
+
+		if n.reprovider != nil {
+			state.reprovideInterval = n.foregroundReprovideInterval()
+			n.setForegroundReprovideInterval(0)
+		}
+
+		if dhtEnabled {
+			state.reachability = n.foregroundReachability()
+			n.setForegroundReachability(network.ReachabilityPrivate)
+
+			state.provideInterval = n.provideLimiter.getInterval()
+			if limit := time.Second / meteredProvideRate; state.provideInterval < limit {
+				n.provideLimiter.setInterval(limit)
+			}
+		}
+
+		n.metered = state
+		return
+	}
+
+	state := n.metered
+	n.metered = nil
+
+	if n.reprovider != nil {
+		n.setForegroundReprovideInterval(state.reprovideInterval)
+	}
+
+	if dhtEnabled {
+		n.setForegroundReachability(state.reachability)
+		n.provideLimiter.setInterval(state.provideInterval)
+	}
+}
+
+// IsMetered returns whether the node runs in metered mode, see SetMetered.
+func (n *Node) IsMetered() bool {
+	n.muMetered.Lock()
+	defer n.muMetered.Unlock()
+	return n.metered != nil
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+func TestNodeSetMetered(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if node.reprovider == nil || node.dhtHost == nil {
+		t.Skip("reproviding and the dht should be enabled by the testing config")
+	}
+
+	if err := node.SetReprovideInterval(3600); err != nil {
+		t.Fatal(err)
+	}
+
+	node.SetMetered(true)
+	if !node.IsMetered() {
+		t.Fatal("expected the node to be metered")
+	}
+
+	if got := node.reprovider.getInterval(); got != 0 {
+		t.Errorf("expected reproviding to be paused got `%s`", got)
+	}
+	if got := node.dhtHost.Reachability(); got != network.ReachabilityPrivate {
+		t.Errorf("expected the dht to be in client mode got `%s`", got)
+	}
+	if got := node.provideLimiter.getInterval(); got != time.Second/meteredProvideRate {
+		t.Errorf("expected provides to be limited got `%s`", got)
+	}
+
+	// enabling it twice keeps the settings to restore
+	node.SetMetered(true)
+
+	node.SetMetered(false)
+	if node.IsMetered() {
+		t.Fatal("expected the node not to be metered")
+	}
+
+	if got := node.reprovider.getInterval(); got != time.Hour {
+		t.Errorf("expected reprovide interval to be `%s` got `%s`", time.Hour, got)
+	}
+	if got := node.dhtHost.Reachability(); got != network.ReachabilityUnknown {
+		t.Errorf("expected the dht mode to be automatic got `%s`", got)
+	}
+	if got := node.provideLimiter.getInterval(); got != 0 {
+		t.Errorf("expected provides to be unlimited got `%s`", got)
+	}
+}
@@ -23,11 +23,15 @@ package core
 
 import (
 	// 导入需要的包
-	"context" // 提供上下文控制，用于取消操作和设置超时
-	"fmt"     // 格式化输出
-	"log"     // 日志功能
-	"net"     // 网络操作
-	"sync"    // 并发控制
+	"context"     // 提供上下文控制，用于取消操作和设置超时
+	"crypto/tls"  // 网关的TLS监听
+	"fmt"         // 格式化输出
+	"log"         // 日志功能
+	"net"         // 网络操作
+	"net/http"    // API和网关的HTTP服务器
+	"sync"        // 并发控制
+	"sync/atomic" // 原子操作
+	"time"        // 关闭超时
 
 	// 项目内部包
 	ble "github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/ble-driver"               // 蓝牙驱动
@@ -35,27 +39,90 @@ import (
 	"github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/ipfsutil"                     // IPFS工具函数
 	proximity "github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/proximitytransport" // 近距离传输层
 	"go.uber.org/zap"                                                            // 高性能日志库
+	"golang.org/x/net/netutil"                                                   // 监听器连接数限制
 
 	// 第三方库
-	p2p_mdns "github.com/libp2p/go-libp2p/p2p/discovery/mdns" // mDNS服务发现
-	ma "github.com/multiformats/go-multiaddr"                 // 多地址处理
-	manet "github.com/multiformats/go-multiaddr/net"          // 多地址网络接口
+	ma "github.com/multiformats/go-multiaddr"        // 多地址处理
+	manet "github.com/multiformats/go-multiaddr/net" // 多地址网络接口
 
 	// IPFS核心组件
-	ipfs_config "github.com/ipfs/kubo/config"     // IPFS配置
-	ipfs_bs "github.com/ipfs/kubo/core/bootstrap" // IPFS引导节点
-	libp2p "github.com/libp2p/go-libp2p"          // P2P网络库
+	ipfs_iface "github.com/ipfs/interface-go-ipfs-core" // CoreAPI接口
+	ipfs_config "github.com/ipfs/kubo/config"           // IPFS配置
+	ipfs_bs "github.com/ipfs/kubo/core/bootstrap"       // IPFS引导节点
+	ipfs_coreapi "github.com/ipfs/kubo/core/coreapi"    // CoreAPI实现
+	ipfs_p2p "github.com/ipfs/kubo/core/node/libp2p"    // kubo的libp2p路由选项
+	libp2p "github.com/libp2p/go-libp2p"                // P2P网络库
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"    // 对等节点标识
+	"github.com/libp2p/go-libp2p/p2p/host/autorelay"    // 自动中继
 )
 
 // Node 结构体定义，代表一个IPFS节点
 type Node struct {
-	listeners   []manet.Listener // 网络监听器列表
-	muListeners sync.Mutex       // 保护listeners的互斥锁
-	mdnsLocker  sync.Locker      // mDNS锁，控制mDNS服务的访问
-	mdnsLocked  bool             // 标记mDNS是否被锁定
-	mdnsService p2p_mdns.Service // mDNS服务，用于本地网络发现
+	listeners    map[string]*Listener // API和网关的监听器，以监听地址为键，见listener.go
+	listenerSeq  uint64               // 最近启动的监听器的序号，用于按启动顺序选择监听器
+	muListeners  sync.Mutex           // 保护listeners和listenerSeq的互斥锁
+	maxHTTPConns int                  // API/网关的最大并发连接数，0表示不限制
+	maxDials     int                  // swarm的最大并发拨号数，0表示libp2p默认值
+	maxCatSize   int64                // Cat返回内容的最大字节数（原子访问），0表示默认值
+	mdnsLocker   sync.Locker          // mDNS锁，服务运行期间持有
+	mdnsService  ipfsutil.MdnsService // mDNS服务，用于本地网络发现，未运行时为nil
+	mdnsCancel   context.CancelFunc   // 停止等待多播接口的协程，见mdns.go
+	mdnsHidden   bool                 // 不在本地网络广播本节点，见SetMDNSAdvertise
+	muMDNS       sync.Mutex           // 保护mDNS服务的启动和关闭
+
+	mdnsPeerHandler   MDNSPeerHandler // mDNS发现节点时的回调，未设置时为nil，见discovery.go
+	muMDNSPeerHandler sync.Mutex      // 保护mdnsPeerHandler的互斥锁
 
 	ipfsMobile *ipfs_mobile.IpfsMobile // 移动平台IPFS节点实例
+	repo       *Repo                   // 节点使用的仓库
+
+	// 节点级上下文，Close时取消，用于停止绑定层启动的后台协程
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	gcSubs   []*gcSubscription // GC事件订阅者
+	muGCSubs sync.Mutex        // 保护gcSubs的互斥锁
+
+	phase         int32         // 节点生命周期阶段（原子访问），见readiness.go
+	bootstrapDone chan struct{} // 后台引导完成时关闭，未引导时为nil
+
+	dhtHost    *ipfsutil.ReachabilityHost // DHT使用的主机包装，用于运行时切换DHT模式
+	noRouting  bool                       // 受限模式：禁用DHT且未配置委托路由，无法发布和查找内容
+	discovered *peerTracker               // 最近发现的对等节点及其来源
+	relays     *relaySource               // AutoRelay的候选中继来源，未启用时为nil
+	netDriver  *inet                      // 原生网络驱动，未设置时为nil
+	denylist   *gatewayDenylist           // 网关拒绝提供的内容列表
+	apiCORS    *apiCORS                   // API服务允许的跨域请求，默认拒绝
+	apiAuth    *apiAuth                   // API服务要求的访问令牌，默认不要求
+	debugLog   *debugLogFile              // 文件日志，未启用时为nil
+	logDriver  *logDriver                 // 原生日志驱动，未设置时为nil
+
+	bleProtocol    string     // 蓝牙传输的协议名，未启用蓝牙时为空
+	bleDefaultAddr string     // 蓝牙传输的默认监听地址，用于运行时重新监听
+	bleEvents      *bleEvents // 蓝牙连接事件的转发，未启用蓝牙时为nil，见ble.go
+
+	provideLimiter *provideLimiter // DHT发布速率限制，见provide_rate.go
+
+	reprovider         *reprovideLoop     // 重新发布循环，未启用重新发布时为nil
+	provideQueueSample provideQueueSample // 上次采样的发布队列深度，用于计算速率
+	latencySamples     latencySamples     // 各对等节点上次测量延迟的时间，见latency.go
+
+	requests      map[int64]context.CancelFunc // 可取消的后台请求，见request.go
+	lastRequestID int64                        // 最后分配的请求句柄
+	muRequests    sync.Mutex                   // 保护requests的互斥锁
+
+	subscriptions      map[string]*subscription // 活跃的订阅，见subscriptions.go
+	lastSubscriptionID int64                    // 最后分配的订阅编号
+	muSubscriptions    sync.Mutex               // 保护subscriptions的互斥锁
+
+	metered    *meteredState    // 按流量计费模式下保存的各子系统设置，未启用时为nil，见metered.go
+	background *backgroundState // 后台模式下保存的各子系统设置，未启用时为nil，见background.go
+	muMetered  sync.Mutex       // 保护metered、background和relisten的互斥锁
+
+	relisten      context.CancelFunc // 停止重新监听仍被占用的地址，没有待监听地址时为nil
+	relistenAddrs []ma.Multiaddr     // 退出后台模式后仍在等待重新监听的地址
+
+	muFiles sync.Mutex // 串行化MFS的修改操作，见files.go
 }
 
 // NewNode 创建一个新的IPFS节点
@@ -65,42 +132,75 @@ func NewNode(r *Repo, config *NodeConfig) (*Node, error) {
 		config = NewNodeConfig()
 	}
 
-	// 设置DNS解析器，使用固定的DNS服务器
-	var dialer net.Dialer
-	net.DefaultResolver = &net.Resolver{
-		PreferGo: false, // 不使用Go的DNS解析器
-		Dial: func(context context.Context, _, _ string) (net.Conn, error) {
-			// 使用硬编码的DNS服务器(84.200.69.80是privacy-friendly的DNS服务器)
-			conn, err := dialer.DialContext(context, "udp", "84.200.69.80:53")
-			if err != nil {
-				return nil, err
+	// 设置DNS解析器：配置了DNS服务器时使用它们，否则使用系统DNS（见dns.go）
+	switch {
+	case config.useSystemDNS:
+		// 保持进程当前的解析器不变
+	case len(config.dnsServers) > 0:
+		net.DefaultResolver = newDNSResolver(config.dnsServers)
+	default:
+		net.DefaultResolver = systemResolver
+	}
+
+	// 启用文件日志（见debuglog.go），节点创建失败时关闭日志文件
+	var debugLog *debugLogFile
+	created := false
+	if config.debugLogPath != "" {
+		var err error
+		debugLog, err = startDebugLog(config.debugLogPath, config.debugLogMaxBytes, config.debugLogMaxFiles)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if !created {
+				debugLog.Close()
 			}
-			return conn, nil
-		},
+		}()
+	}
+
+	// 启用原生日志驱动（见driver_log.go），节点创建失败时停止转发
+	var nativeLog *logDriver
+	if config.logDriver != nil {
+		nativeLog = startLogDriver(config.logDriver)
+		defer func() {
+			if !created {
+				nativeLog.Close()
+			}
+		}()
 	}
 
 	// 创建上下文
 	ctx := context.Background()
 
 	// 加载IPFS插件
-	if _, err := loadPlugins(r.mr.Path); err != nil {
+	if _, err := loadPlugins(r.mr.Path, config.pluginLoadTimeout); err != nil {
 		return nil, err
 	}
 
 	// 设置自定义网络驱动（如果提供）
+	var netDriver *inet
 	if config.netDriver != nil {
 		logger, _ := zap.NewDevelopment()
 		inet := &inet{
-			net:    config.netDriver,
-			logger: logger,
+			net:        config.netDriver,
+			logger:     logger,
+			errHandler: config.netDriverErrorHandler,
+			fallback:   config.netDriverFallback,
 		}
+		netDriver = inet
 		// 配置自定义网络接口
 		ipfsutil.SetNetDriver(inet)
 		manet.SetNetInterface(inet)
 	}
 
+	// 将mDNS限制在指定网络接口（为空时使用所有多播接口）
+	ipfsutil.SetBindInterface(config.bindInterface)
+
 	// 蓝牙选项变量
 	var bleOpt libp2p.Option
+	// 蓝牙传输的协议名、默认地址和事件转发，未启用蓝牙时为空（见ble.go）
+	var bleProtocol, bleDefaultAddr string
+	var bleEvents *bleEvents
 
 	// 根据平台选择不同的蓝牙驱动实现
 	switch {
@@ -113,7 +213,10 @@ func NewNode(r *Repo, config *NodeConfig) (*Node, error) {
 			}
 		}()
 		// 使用传入的蓝牙驱动创建传输层
-		bleOpt = libp2p.Transport(proximity.NewTransport(ctx, logger, config.bleDriver))
+		bleProtocol, bleDefaultAddr = config.bleDriver.ProtocolName(), config.bleDriver.DefaultAddr()
+		bleEvents = newBLEEvents()
+		bleOpt = libp2p.Transport(proximity.NewTransport(ctx, logger, config.bleDriver,
+			proximity.WithEventHandler(bleEvents)))
 	// Go嵌入式驱动（iOS平台）
 	case ble.Supported:
 		logger := zap.NewExample()
@@ -123,7 +226,10 @@ func NewNode(r *Repo, config *NodeConfig) (*Node, error) {
 			}
 		}()
 		// 创建并使用iOS蓝牙驱动
-		bleOpt = libp2p.Transport(proximity.NewTransport(ctx, logger, ble.NewDriver(logger)))
+		bleProtocol, bleDefaultAddr = ble.ProtocolName, ble.DefaultAddr
+		bleEvents = newBLEEvents()
+		bleOpt = libp2p.Transport(proximity.NewTransport(ctx, logger, ble.NewDriver(logger),
+			proximity.WithEventHandler(bleEvents)))
 	default:
 		// 如果平台不支持蓝牙，输出日志
 		log.Printf("cannot enable BLE on an unsupported platform")
@@ -132,29 +238,297 @@ func NewNode(r *Repo, config *NodeConfig) (*Node, error) {
 	// 配置IPFS节点
 	ipfscfg := &ipfs_mobile.IpfsConfig{
 		HostConfig: &ipfs_mobile.HostConfig{
-			Options: []libp2p.Option{bleOpt}, // 添加蓝牙传输选项
+			Options: []libp2p.Option{
+				bleOpt,                      // 添加蓝牙传输选项
+				config.reachabilityOption(), // 强制可达性（auto模式下为nil）
+			},
 		},
-		RepoMobile: r.mr, // 设置仓库
+		RepoMobile: r.mr,            // 设置仓库
+		LogLevel:   config.logLevel, // 日志级别，为空时保持不变
 		ExtraOpts: map[string]bool{
-			"pubsub": true, // 默认启用实验性的pubsub功能
-			"ipnsps": true, // 默认启用通过pubsub分发IPNS记录
+			"pubsub": !config.disablePubSub, // 默认启用实验性的pubsub功能
+			"ipnsps": !config.disablePubSub, // 默认启用通过pubsub分发IPNS记录
 		},
 	}
 
+	// 添加应用注册的其他近距离传输层（如NFC），复用蓝牙的传输实现
+	if len(config.proximityDrivers) > 0 {
+		logger := zap.NewExample()
+		defer func() {
+			if err := logger.Sync(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+		for _, driver := range config.proximityDrivers {
+			ipfscfg.HostConfig.Options = append(ipfscfg.HostConfig.Options,
+				libp2p.Transport(proximity.NewTransport(ctx, logger, driver)))
+		}
+	}
+
+	// 设置gossipsub参数（仅在启用pubsub时生效）
+	if ipfscfg.ExtraOpts["pubsub"] {
+		applyGossipSubParams(config.gossipSub)
+	}
+
+	// 记录发现的对等节点及其来源（见discovery.go）
+	discovered := newPeerTracker()
+
+	// 使用可切换模式、可限制发布速率的DHT路由（见dht.go和provide_rate.go）
+	// 禁用DHT时不使用任何路由，内容只能从已连接的节点获取
+	var dhtHost *ipfsutil.ReachabilityHost
+	limiter := &provideLimiter{}
+	if config.disableDHT {
+		ipfscfg.RoutingOption = ipfs_p2p.NilRouterOption
+	} else {
+		ipfscfg.RoutingOption = dhtRoutingOption(discovered, limiter, func(h *ipfsutil.ReachabilityHost) {
+			dhtHost = h
+		})
+	}
+
 	// 获取仓库配置
 	cfg, err := r.mr.Config()
 	if err != nil {
 		panic(err)
 	}
 
+	// 引导节点：NodeConfig中的引导节点覆盖仓库配置，禁用引导时不使用任何引导节点
+	// 加入私有网络时公共引导节点不可用，未设置自定义引导节点时禁用引导
+	disableBootstrap := config.disableBootstrap || (config.swarmKey != nil && config.bootstrapPeers == nil)
+	bootstrapAddrs := cfg.Bootstrap
+	switch {
+	case disableBootstrap:
+		bootstrapAddrs = []string{}
+	case config.bootstrapPeers != nil:
+		bootstrapAddrs = config.bootstrapPeers
+	}
+	bootstrapPeers, err := ipfs_config.ParseBootstrapPeers(bootstrapAddrs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bootstrap peers: %w", err)
+	}
+
+	// 引导节点和peering节点按配置标记来源
+	discovered.addStatic(bootstrapPeers, DiscoverySourceBootstrap)
+	discovered.addStatic(cfg.Peering.Peers, DiscoverySourcePeering)
+
+	// AutoRelay：替换kubo的候选中继来源，以便运行时注入候选中继（见relay.go）
+	// 配置了静态中继时保留kubo的行为
+	var relays *relaySource
+	relayTransport := cfg.Swarm.Transports.Network.Relay.WithDefault(true)
+	relayClient := cfg.Swarm.RelayClient.Enabled
+	if config.relayClient != ipfs_config.Default {
+		relayClient = config.relayClient
+	}
+	if relayClient.WithDefault(relayTransport) && len(cfg.Swarm.RelayClient.StaticRelays) == 0 {
+		relays = newRelaySource()
+		ipfscfg.HostConfig.Options = append(ipfscfg.HostConfig.Options,
+			libp2p.EnableAutoRelay(autorelay.WithPeerSource(relays.peers, 0)))
+	}
+
+	// 临时配置补丁：仅在创建节点期间生效，节点创建后通过restorePatchs恢复原始配置
+	var transientPatchs, restorePatchs []ipfs_mobile.RepoConfigPatch
+
+	// 重新发布（reprovide）处理：由绑定层的循环接管，以便运行时调整间隔（见reprovider.go）
+	// 加速DHT客户端使用自己的批量发布系统，此时保留kubo的重新发布循环
+	acceleratedDHT := config.acceleratedDHT || cfg.Experimental.AcceleratedDHTClient
+	if acceleratedDHT && config.disableDHT {
+		return nil, fmt.Errorf("the accelerated dht client can't be enabled with the dht disabled")
+	}
+
+	// 委托路由：通过kubo的自定义路由并行查询reframe端点和DHT（见delegated.go）
+	if len(config.delegatedRouting) > 0 {
+		if acceleratedDHT {
+			return nil, fmt.Errorf("the accelerated dht client can't be enabled with delegated routing")
+		}
+
+		routers, methods := delegatedRoutingConfig(config.delegatedRouting, !config.disableDHT)
+		ipfscfg.RoutingOption = ipfs_p2p.ConstructDelegatedRouting(routers, methods,
+			cfg.Identity.PeerID, cfg.Addresses.Swarm, cfg.Identity.PrivKey)
+
+		// 路由配置仅在本次创建节点时生效
+		origRouting := cfg.Routing
+		transientPatchs = append(transientPatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Routing = ipfs_config.Routing{Type: "custom", Routers: routers, Methods: methods}
+			return nil
+		})
+		restorePatchs = append(restorePatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Routing = origRouting
+			return nil
+		})
+	}
+
+	reprovideInterval, err := configReprovideInterval(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// NodeConfig设置的间隔覆盖配置中的间隔，0表示不重新发布
+	if config.reprovideInterval != nil {
+		reprovideInterval = *config.reprovideInterval
+	}
+
+	// kubo重新发布循环在本次创建节点时使用的间隔，为空表示不修改配置
+	var kuboInterval string
+	switch {
+	case acceleratedDHT:
+		// 加速DHT客户端的批量发布系统按配置的间隔重新发布
+		if config.reprovideInterval != nil {
+			kuboInterval = formatReprovideInterval(reprovideInterval)
+		}
+		reprovideInterval = 0
+	case reprovideInterval > 0 || config.disableDHT || config.reprovideInterval != nil:
+		// 暂时禁用kubo的重新发布循环，禁用DHT时没有可发布的路由，两个循环都不运行
+		kuboInterval = "0"
+	}
+	if kuboInterval != "" {
+		origInterval := cfg.Reprovider.Interval
+		transientPatchs = append(transientPatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Reprovider.Interval = kuboInterval
+			return nil
+		})
+		restorePatchs = append(restorePatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Reprovider.Interval = origInterval
+			return nil
+		})
+	}
+
+	// 重新发布策略：决定重新发布哪些块，仅在本次创建节点时生效
+	if config.reprovideStrategy != "" {
+		origStrategy := cfg.Reprovider.Strategy
+		transientPatchs = append(transientPatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Reprovider.Strategy = config.reprovideStrategy
+			return nil
+		})
+		restorePatchs = append(restorePatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Reprovider.Strategy = origStrategy
+			return nil
+		})
+	}
+
+	// 加速DHT客户端（fullrt）：仅在本次创建节点时启用
+	if config.acceleratedDHT && !cfg.Experimental.AcceleratedDHTClient {
+		transientPatchs = append(transientPatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Experimental.AcceleratedDHTClient = true
+			return nil
+		})
+		restorePatchs = append(restorePatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Experimental.AcceleratedDHTClient = false
+			return nil
+		})
+	}
+
+	// 中继客户端和中继服务：覆盖仓库配置，仅在本次创建节点时生效
+	if config.relayClient != ipfs_config.Default || config.relayService != ipfs_config.Default {
+		origClient, origService := cfg.Swarm.RelayClient.Enabled, cfg.Swarm.RelayService.Enabled
+		transientPatchs = append(transientPatchs, func(cfg *ipfs_config.Config) error {
+			if config.relayClient != ipfs_config.Default {
+				cfg.Swarm.RelayClient.Enabled = config.relayClient
+			}
+			if config.relayService != ipfs_config.Default {
+				cfg.Swarm.RelayService.Enabled = config.relayService
+			}
+			return nil
+		})
+		restorePatchs = append(restorePatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Swarm.RelayClient.Enabled = origClient
+			cfg.Swarm.RelayService.Enabled = origService
+			return nil
+		})
+	}
+
+	// 连接管理器的水位线：仅在本次创建节点时生效
+	if config.connMgr != nil {
+		origConnMgr := cfg.Swarm.ConnMgr
+		connMgr := *config.connMgr
+		transientPatchs = append(transientPatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Swarm.ConnMgr = connMgr
+			return nil
+		})
+		restorePatchs = append(restorePatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Swarm.ConnMgr = origConnMgr
+			return nil
+		})
+	}
+
+	// 引导节点：DHT使用的引导节点，仅在本次创建节点时生效
+	if disableBootstrap || config.bootstrapPeers != nil {
+		origBootstrap := cfg.Bootstrap
+		transientPatchs = append(transientPatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Bootstrap = bootstrapAddrs
+			return nil
+		})
+		restorePatchs = append(restorePatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Bootstrap = origBootstrap
+			return nil
+		})
+	}
+
+	// Swarm监听地址：固定端口和/或绑定到指定网络接口
+	if config.swarmPort != 0 || config.bindInterface != "" {
+		origSwarm := cfg.Addresses.Swarm
+		swarm := origSwarm
+		if config.swarmPort != 0 {
+			if swarm, err = swarmAddrsWithPort(swarm, config.swarmPort); err != nil {
+				return nil, err
+			}
+		}
+		if config.bindInterface != "" {
+			if swarm, err = swarmAddrsForInterface(swarm, config.bindInterface); err != nil {
+				return nil, err
+			}
+		}
+		transientPatchs = append(transientPatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Addresses.Swarm = swarm
+			return nil
+		})
+		restorePatchs = append(restorePatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Addresses.Swarm = origSwarm
+			return nil
+		})
+	}
+
+	// 近距离传输层的默认地址：仅在本次创建节点时加入监听地址
+	var proximityAddrs []string
+	for _, driver := range config.proximityDrivers {
+		listening := false
+		for _, addr := range cfg.Addresses.Swarm {
+			listening = listening || addr == driver.DefaultAddr()
+		}
+		if !listening {
+			proximityAddrs = append(proximityAddrs, driver.DefaultAddr())
+		}
+	}
+	if len(proximityAddrs) > 0 {
+		origSwarm := cfg.Addresses.Swarm
+		transientPatchs = append(transientPatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Addresses.Swarm = append(append([]string{}, cfg.Addresses.Swarm...), proximityAddrs...)
+			return nil
+		})
+		restorePatchs = append(restorePatchs, func(cfg *ipfs_config.Config) error {
+			cfg.Addresses.Swarm = origSwarm
+			return nil
+		})
+	}
+
+	if len(transientPatchs) > 0 {
+		if err := r.mr.ApplyPatchs(transientPatchs...); err != nil {
+			return nil, fmt.Errorf("unable to ApplyPatchs to set transient config: %w", err)
+		}
+	}
+
 	// mDNS处理（多播DNS，用于本地网络发现）
-	mdnsLocked := false
-	if cfg.Discovery.MDNS.Enabled && config.mdnsLockerDriver != nil {
-		// 锁定mDNS（避免多个进程同时使用）
-		config.mdnsLockerDriver.Lock()
-		mdnsLocked = true
+	// 未设置锁驱动时使用空实现，仍由绑定层启动mDNS服务，并提示可能的问题
+	// cfg是仓库缓存的配置，下面临时禁用mDNS的补丁会修改它，因此先记录原始设置
+	mdnsEnabled := cfg.Discovery.MDNS.Enabled
+	mdnsLocker := config.mdnsLockerDriver
+	if mdnsLocker == nil {
+		if mdnsEnabled {
+			log.Printf("mdns is enabled but no mdns locker driver is set, starting mdns without a multicast lock: " +
+				"on Android local discovery won't work unless the app holds a WifiManager.MulticastLock")
+		}
+		mdnsLocker = &noopNativeMDNSLockerDriver{}
+	}
 
-		// 暂时禁用mDNS，避免ipfs_mobile.NewNode启动它
+	if mdnsEnabled {
+		// 暂时禁用mDNS，避免ipfs_mobile.NewNode启动它，由绑定层启动（见mdns.go）
 		err := r.mr.ApplyPatchs(func(cfg *ipfs_config.Config) error {
 			cfg.Discovery.MDNS.Enabled = false
 			return nil
@@ -164,92 +538,214 @@ func NewNode(r *Repo, config *NodeConfig) (*Node, error) {
 		}
 	}
 
-	// 创建移动IPFS节点
+	// 私有网络：kubo创建节点时从仓库读取共享密钥，仅在本次创建节点时生效
+	if config.swarmKey != nil {
+		r.mr.SetSwarmKey(config.swarmKey)
+	}
+
+	// 创建移动IPFS节点，swarm构造时读取拨号并发上限（见dial.go）
+	restoreDialLimit := useDialLimit(config.maxConcurrentDials)
 	mnode, err := ipfs_mobile.NewNode(ctx, ipfscfg)
-	if err != nil {
-		// 如果mDNS已锁定但创建节点失败，释放锁
-		if mdnsLocked {
-			config.mdnsLockerDriver.Unlock()
+	restoreDialLimit()
+	r.mr.SetSwarmKey(nil)
+
+	// 恢复临时修改的配置（无论节点是否创建成功）
+	if len(restorePatchs) > 0 {
+		if perr := r.mr.ApplyPatchs(restorePatchs...); perr != nil {
+			log.Printf("unable to ApplyPatchs to restore transient config: %s", perr)
 		}
-		return nil, err
 	}
 
-	// mDNS服务变量
-	var mdnsService p2p_mdns.Service = nil
-	if mdnsLocked {
-		// 恢复mDNS配置
-		err := r.mr.ApplyPatchs(func(cfg *ipfs_config.Config) error {
+	// 恢复mDNS配置（无论节点是否创建成功）
+	if mdnsEnabled {
+		perr := r.mr.ApplyPatchs(func(cfg *ipfs_config.Config) error {
 			cfg.Discovery.MDNS.Enabled = true
 			return nil
 		})
-		if err != nil {
-			return nil, fmt.Errorf("unable to ApplyPatchs to enable mDNS: %w", err)
+		if perr != nil && err == nil {
+			mnode.Close()
+			return nil, fmt.Errorf("unable to ApplyPatchs to enable mDNS: %w", perr)
 		}
+	}
 
-		// 获取对等节点主机
-		h := mnode.PeerHost()
-		mdnslogger, _ := zap.NewDevelopment()
+	if err != nil {
+		return nil, err
+	}
 
-		// 创建发现处理器和mDNS服务
-		dh := ipfsutil.DiscoveryHandler(ctx, mdnslogger, h)
-		mdnsService = ipfsutil.NewMdnsService(mdnslogger, h, ipfsutil.MDNSServiceName, dh)
+	// 创建节点级上下文
+	nodeCtx, cancel := context.WithCancel(context.Background())
+
+	// 创建节点
+	node := &Node{
+		ipfsMobile:     mnode,
+		repo:           r,
+		mdnsLocker:     mdnsLocker,
+		maxHTTPConns:   config.maxHTTPConns,
+		maxDials:       config.maxConcurrentDials,
+		dhtHost:        dhtHost,
+		noRouting:      config.disableDHT && len(config.delegatedRouting) == 0,
+		bleProtocol:    bleProtocol,
+		bleDefaultAddr: bleDefaultAddr,
+		bleEvents:      bleEvents,
+		discovered:     discovered,
+		relays:         relays,
+		netDriver:      netDriver,
+		denylist:       newGatewayDenylist(),
+		apiCORS:        &apiCORS{},
+		apiAuth:        &apiAuth{},
+		debugLog:       debugLog,
+		logDriver:      nativeLog,
+		provideLimiter: limiter,
+		ctx:            nodeCtx,
+		cancel:         cancel,
+	}
 
-		// 启动mDNS服务
-		// 获取多播接口
-		ifaces, err := ipfsutil.GetMulticastInterfaces()
-		if err != nil {
-			if mdnsLocked {
-				config.mdnsLockerDriver.Unlock()
-			}
+	// 候选中继不足时回退到peering节点和DHT节点
+	if relays != nil {
+		peering := cfg.Peering.Peers
+		relays.setFallback(func() []p2p_peer.AddrInfo {
+			return node.relayFallback(peering)
+		})
+	}
+
+	// 后台记录连接上的引导节点和peering节点
+	go discovered.watchStatic(nodeCtx, mnode.PeerHost())
+
+	// 可达性和连接状态变化通知，节点关闭时停止
+	if config.reachabilityHandler != nil {
+		watchReachability(nodeCtx, mnode.PeerHost(), config.reachabilityHandler)
+	}
+
+	// 转发蓝牙连接事件，节点关闭时停止
+	if bleEvents != nil {
+		go bleEvents.run(nodeCtx)
+	}
+
+	// 启动重新发布循环
+	if reprovideInterval > 0 && !config.disableDHT {
+		node.reprovider = newReprovideLoop(reprovideInterval)
+		go node.reprovider.run(nodeCtx, node.reprovide)
+	}
+
+	// 标记仓库正在被节点使用
+	atomic.AddInt32(&r.nodeRunning, 1)
+
+	// 启动mDNS服务，没有多播接口时在后台等待接口出现（见mdns.go）
+	if mdnsEnabled {
+		if err := node.StartMDNS(); err != nil {
+			node.Close()
 			return nil, err
 		}
+	}
 
-		// 如果找到多播接口，启动mDNS服务
-		if len(ifaces) > 0 {
-			mdnslogger.Info("starting mdns")
-			if err := mdnsService.Start(); err != nil {
-				if mdnsLocked {
-					config.mdnsLockerDriver.Unlock()
-				}
-				return nil, fmt.Errorf("unable to start mdns service: %w", err)
+	// 导入预加载的CAR文件（见car.go），宽松模式下仅记录失败
+	for _, path := range config.preloadCARs {
+		if err := node.importCAR(nodeCtx, path, config.preloadPinRoots); err != nil {
+			if config.preloadLenient {
+				log.Printf("unable to preload car: %s", err)
+				continue
 			}
-		} else {
-			mdnslogger.Error("unable to start mdns service, no multicast interfaces found")
+
+			node.Close()
+			return nil, fmt.Errorf("unable to preload car: %w", err)
 		}
 	}
 
-	// 使用默认配置引导节点
-	if err := mnode.IpfsNode.Bootstrap(ipfs_bs.DefaultBootstrapConfig); err != nil {
-		log.Printf("failed to bootstrap node: `%s`", err)
+	// 引导节点：默认使用仓库配置中的引导节点（可在运行时更新）
+	// 设置了自定义引导节点时固定使用这些节点，禁用引导时跳过
+	if !disableBootstrap {
+		bsCfg := ipfs_bs.DefaultBootstrapConfig
+		if config.bootstrapPeers != nil {
+			bsCfg = ipfs_bs.BootstrapConfigWithPeers(bootstrapPeers)
+		}
+
+		// 在后台引导，调用方可以观察到bootstrapping阶段
+		node.setPhase(phaseBootstrapping)
+		node.bootstrapDone = make(chan struct{})
+		go node.bootstrap(bsCfg)
+	} else {
+		node.setPhase(phaseReady)
 	}
 
 	// 返回创建的节点
-	return &Node{
-		ipfsMobile:  mnode,
-		mdnsLocker:  config.mdnsLockerDriver,
-		mdnsLocked:  mdnsLocked,
-		mdnsService: mdnsService,
-	}, nil
+	created = true
+	return node, nil
 }
 
-// Close 关闭节点并释放资源
+// defaultCloseTimeout Close等待API和网关正在处理的请求完成的时间
+const defaultCloseTimeout = 2 * time.Second
+
+// Close 关闭节点并释放资源，最多等待defaultCloseTimeout让正在处理的HTTP请求完成
 func (n *Node) Close() error {
-	// 关闭所有监听器
-	n.muListeners.Lock()
-	for _, l := range n.listeners {
-		l.Close()
+	return n.closeWithTimeout(defaultCloseTimeout)
+}
+
+// CloseWithTimeout 关闭节点并释放资源
+// 先停止API和网关接受新连接，最多等待timeoutSeconds秒（小于等于0时使用defaultCloseTimeout）
+// 让正在处理的请求完成，超时后强制关闭剩余的连接，然后关闭节点
+func (n *Node) CloseWithTimeout(timeoutSeconds int64) error {
+	timeout := defaultCloseTimeout
+	if timeoutSeconds > 0 {
+		timeout = time.Duration(timeoutSeconds) * time.Second
 	}
+	return n.closeWithTimeout(timeout)
+}
+
+func (n *Node) closeWithTimeout(timeout time.Duration) error {
+	// 标记节点已关闭，并记录节点是否已经关闭过
+	closed := atomic.SwapInt32(&n.phase, phaseClosed) == phaseClosed
+
+	// 关闭所有监听器，并发等待各服务器处理完请求
+	n.muListeners.Lock()
+	listeners := n.listeners
+	n.listeners = nil
 	n.muListeners.Unlock()
 
-	// 如果mDNS已锁定，关闭服务并释放锁
-	if n.mdnsLocked {
-		n.mdnsService.Close()
-		n.mdnsLocker.Unlock()
-		n.mdnsLocked = false
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, l := range listeners {
+		wg.Add(1)
+		go func(l *Listener) {
+			defer wg.Done()
+			if err := l.shutdown(ctx); err == context.DeadlineExceeded {
+				log.Printf("`%s` didn't drain in %s, closing its connections", l.maddr, timeout)
+			}
+		}(l)
 	}
+	wg.Wait()
+
+	// 取消节点上下文，停止所有后台协程（如GC事件分发）
+	n.cancel()
+
+	// 停止mDNS服务并释放锁
+	n.StopMDNS()
 
 	// 关闭IPFS节点
-	return n.ipfsMobile.Close()
+	err := n.ipfsMobile.Close()
+
+	// 等待后台引导结束（主机关闭后很快失败），并停止周期性引导
+	if n.bootstrapDone != nil {
+		<-n.bootstrapDone
+		if b := n.ipfsMobile.IpfsNode.Bootstrapper; b != nil {
+			b.Close()
+		}
+	}
+
+	// 节点关闭后仓库不再被使用
+	if !closed {
+		atomic.AddInt32(&n.repo.nodeRunning, -1)
+	}
+
+	// 最后关闭日志文件和日志驱动，保留关闭过程的日志
+	if n.debugLog != nil {
+		n.debugLog.Close()
+	}
+	if n.logDriver != nil {
+		n.logDriver.Close()
+	}
+	return err
 }
 
 // ServeUnixSocketAPI 在Unix套接字上提供API服务
@@ -260,7 +756,11 @@ func (n *Node) ServeUnixSocketAPI(sockpath string) (err error) {
 
 // ServeTCPAPI 在指定端口上提供TCP API服务，并返回监听地址
 func (n *Node) ServeTCPAPI(port string) (string, error) {
-	return n.ServeAPIMultiaddr("/ip4/127.0.0.1/tcp/" + port)
+	l, err := n.ServeAPIMultiaddr("/ip4/127.0.0.1/tcp/" + port)
+	if err != nil {
+		return "", err
+	}
+	return l.Multiaddr(), nil
 }
 
 // ServeConfig 根据配置提供API和网关服务
@@ -301,67 +801,154 @@ func (n *Node) ServeUnixSocketGateway(sockpath string, writable bool) (err error
 
 // ServeTCPGateway 在指定端口上提供TCP网关服务
 func (n *Node) ServeTCPGateway(port string, writable bool) (string, error) {
-	return n.ServeGatewayMultiaddr("/ip4/127.0.0.1/tcp/"+port, writable)
+	l, err := n.ServeGatewayMultiaddr("/ip4/127.0.0.1/tcp/"+port, writable)
+	if err != nil {
+		return "", err
+	}
+	return l.Multiaddr(), nil
 }
 
-// ServeGatewayMultiaddr 在指定多地址上提供网关服务
-func (n *Node) ServeGatewayMultiaddr(smaddr string, writable bool) (string, error) {
+// ServeGatewayLAN 在所有网络接口（0.0.0.0）的指定端口上提供网关服务，
+// 使同一局域网中的其他设备可以访问，返回局域网可访问的多地址
+// 警告：局域网中的任何设备都可以访问该网关，writable为true时它们还可以写入内容，
+// 在不受信任的网络（如公共WiFi）上不要启用可写网关
+func (n *Node) ServeGatewayLAN(port string, writable bool) (string, error) {
+	// 在监听前确定局域网地址
+	lanIP, err := lanIPv4()
+	if err != nil {
+		return "", err
+	}
+
+	l, err := n.ServeGatewayMultiaddr("/ip4/0.0.0.0/tcp/"+port, writable)
+	if err != nil {
+		return "", err
+	}
+
+	// 将通配地址替换为局域网地址
+	maddr, err := ma.NewMultiaddr(l.Multiaddr())
+	if err != nil {
+		return "", err
+	}
+	tcpPort, err := maddr.ValueForProtocol(ma.P_TCP)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("/ip4/%s/tcp/%s", lanIP, tcpPort), nil
+}
+
+// lanIPv4 返回本机的第一个私有IPv4地址
+func lanIPv4() (net.IP, error) {
+	addrs, err := manet.InterfaceMultiaddrs()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list interface addresses: %w", err)
+	}
+
+	for _, addr := range addrs {
+		ip, err := manet.ToIP(addr)
+		if err != nil {
+			continue
+		}
+		if ip4 := ip.To4(); ip4 != nil && ip4.IsPrivate() {
+			return ip4, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no LAN address found, the device may not be connected to a local network")
+}
+
+// ServeGatewayMultiaddr 在指定多地址上提供网关服务，返回的监听器可单独关闭
+// writable为false时网关只读：仅处理获取内容的GET/HEAD请求，其他方法返回405，且不挂载命令API（/api/v0）
+// 可写网关的命令API需要SetAPIAuth设置的访问令牌
+func (n *Node) ServeGatewayMultiaddr(smaddr string, writable bool) (*Listener, error) {
+	return n.serveGateway(smaddr, writable, nil)
+}
+
+// serveGateway 在指定多地址上提供网关服务，tlsConfig不为nil时通过TLS提供（见gateway_tls.go）
+func (n *Node) serveGateway(smaddr string, writable bool, tlsConfig *tls.Config) (*Listener, error) {
 	// 解析多地址
 	maddr, err := ma.NewMultiaddr(smaddr)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// 在该地址上监听
 	ml, err := manet.Listen(maddr)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// 保存监听器
-	n.muListeners.Lock()
-	n.listeners = append(n.listeners, ml)
-	n.muListeners.Unlock()
+	// 创建网关服务器，节点关闭时可等待正在处理的请求完成
+	nl := n.limitListener(manet.NetListener(ml))
+	if tlsConfig != nil {
+		nl = tls.NewListener(nl, tlsConfig)
+	}
+	// 设置了API访问令牌时，/api/下的请求（可写网关的命令API）同样需要令牌，内容请求不受影响
+	server, err := n.ipfsMobile.GatewayServer(nl, writable, n.denylist.serveOption(), n.apiAuth.commandsServeOption())
+	if err != nil {
+		ml.Close()
+		return nil, err
+	}
+
+	// 保存监听器，节点关闭时一并关闭
+	l := n.addListener(ml, server, true, tlsConfig != nil)
 
 	// 启动网关服务（在新协程中）
-	go func(l net.Listener) {
-		if err := n.ipfsMobile.ServeGateway(l, writable); err != nil {
+	go func() {
+		if err := server.Serve(nl); err != nil && err != http.ErrServerClosed {
 			log.Printf("serve error: %s", err.Error())
 		}
-	}(manet.NetListener(ml))
+	}()
 
-	// 返回实际监听的地址
-	return ml.Multiaddr().String(), nil
+	return l, nil
 }
 
-// ServeAPIMultiaddr 在指定多地址上提供API服务
-func (n *Node) ServeAPIMultiaddr(smaddr string) (string, error) {
+// ServeAPIMultiaddr 在指定多地址上提供API服务，返回的监听器可单独关闭
+func (n *Node) ServeAPIMultiaddr(smaddr string) (*Listener, error) {
 	// 解析多地址
 	maddr, err := ma.NewMultiaddr(smaddr)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// 在该地址上监听
 	ml, err := manet.Listen(maddr)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// 保存监听器
-	n.muListeners.Lock()
-	n.listeners = append(n.listeners, ml)
-	n.muListeners.Unlock()
+	// 创建API服务器，节点关闭时可等待正在处理的请求完成
+	nl := n.limitListener(manet.NetListener(ml))
+	// 跨域预检请求不携带令牌，先于鉴权处理
+	server, err := n.ipfsMobile.CoreHTTPServer(nl, n.apiCORS.serveOption(), n.apiAuth.serveOption())
+	if err != nil {
+		ml.Close()
+		return nil, err
+	}
+
+	// 保存监听器，节点关闭时一并关闭
+	l := n.addListener(ml, server, false, false)
 
 	// 启动API服务（在新协程中）
-	go func(l net.Listener) {
-		if err := n.ipfsMobile.ServeCoreHTTP(l); err != nil {
+	go func() {
+		if err := server.Serve(nl); err != nil && err != http.ErrServerClosed {
 			log.Printf("serve error: %s", err.Error())
 		}
-	}(manet.NetListener(ml))
+	}()
+
+	return l, nil
+}
+
+// limitListener 限制监听器的最大并发连接数（0表示不限制）
+func (n *Node) limitListener(l net.Listener) net.Listener {
+	if n.maxHTTPConns == 0 {
+		return l
+	}
+	return netutil.LimitListener(l, n.maxHTTPConns)
+}
 
-	// 返回实际监听的地址
-	return ml.Multiaddr().String(), nil
+// coreAPI 返回节点的CoreAPI接口
+func (n *Node) coreAPI() (ipfs_iface.CoreAPI, error) {
+	return ipfs_coreapi.NewCoreAPI(n.ipfsMobile.IpfsNode)
 }
 
 // init 是Go的特殊函数，在包初始化时自动执行
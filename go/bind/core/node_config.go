@@ -1,17 +1,677 @@
 package core
 
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	ble "github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/ble-driver"
+	ipfs_mobile "github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/ipfsmobile"
+	"github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/ipfsutil"
+	proximity "github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/proximitytransport"
+	ipfs_config "github.com/ipfs/kubo/config"
+	libp2p "github.com/libp2p/go-libp2p"
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+	p2p_pnet "github.com/libp2p/go-libp2p/core/pnet"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// DefaultMaxHTTPConns is the default maximum number of concurrent connections
+// accepted by each API and gateway listener.
+const DefaultMaxHTTPConns = 64
+
+// Default connection manager settings of the nodes, lower than the ones of
+// the repo config to save the memory and the battery of phones.
+const (
+	DefaultConnMgrLowWater           = 20
+	DefaultConnMgrHighWater          = 60
+	DefaultConnMgrGracePeriodSeconds = 20
+)
+
+// Reachability modes accepted by NodeConfig.SetForceReachability
+const (
+	ReachabilityAuto    = "auto"
+	ReachabilityPrivate = "private"
+	ReachabilityPublic  = "public"
+)
+
+// Reprovider strategies accepted by NodeConfig.SetReproviderStrategy
+const (
+	ReproviderStrategyAll    = "all"
+	ReproviderStrategyPinned = "pinned"
+	ReproviderStrategyRoots  = "roots"
+)
+
 // Config is used in NewNode.
 type NodeConfig struct {
 	bleDriver        ProximityDriver
+	proximityDrivers []ProximityDriver
 	netDriver        NativeNetDriver
 	mdnsLockerDriver NativeMDNSLockerDriver
+
+	netDriverErrorHandler NetDriverErrorHandler
+	netDriverFallback     bool
+
+	maxHTTPConns      int
+	pluginLoadTimeout time.Duration
+	swarmPort         int
+	bindInterface     string
+	reachability      string
+	acceleratedDHT    bool
+	disableDHT        bool
+	disablePubSub     bool
+
+	relayClient  ipfs_config.Flag
+	relayService ipfs_config.Flag
+
+	maxConcurrentDials int
+
+	// connMgr overrides Swarm.ConnMgr of the repo config when set
+	connMgr *ipfs_config.ConnMgr
+
+	// bootstrapPeers overrides Bootstrap of the repo config when set
+	bootstrapPeers   []string
+	disableBootstrap bool
+
+	// swarmKey is the pre-shared key of the private network the node joins
+	swarmKey []byte
+
+	delegatedRouting []string
+
+	// reprovideInterval overrides Reprovider.Interval of the repo config
+	// when set
+	reprovideInterval *time.Duration
+	reprovideStrategy string
+
+	dnsServers   []string
+	useSystemDNS bool
+
+	logLevel  string
+	logDriver NativeLogDriver
+
+	reachabilityHandler ReachabilityHandler
+
+	debugLogPath     string
+	debugLogMaxBytes int64
+	debugLogMaxFiles int
+
+	preloadCARs     []string
+	preloadPinRoots bool
+	preloadLenient  bool
+
+	gossipSub gossipSubParams
 }
 
 func NewNodeConfig() *NodeConfig {
 	return &NodeConfig{
+		maxHTTPConns:      DefaultMaxHTTPConns,
+		pluginLoadTimeout: defaultPluginLoadTimeout,
+		reachability:      ReachabilityAuto,
+
+		maxConcurrentDials: DefaultMaxConcurrentDials,
+
+		connMgr: &ipfs_config.ConnMgr{
+			Type:        ConnMgrTypeBasic,
+			LowWater:    DefaultConnMgrLowWater,
+			HighWater:   DefaultConnMgrHighWater,
+			GracePeriod: (DefaultConnMgrGracePeriodSeconds * time.Second).String(),
+		},
 	}
 }
 
 func (c *NodeConfig) SetBleDriver(driver ProximityDriver)         { c.bleDriver = driver }
 func (c *NodeConfig) SetNetDriver(driver NativeNetDriver)         { c.netDriver = driver }
 func (c *NodeConfig) SetMDNSLocker(driver NativeMDNSLockerDriver) { c.mdnsLockerDriver = driver }
+
+// AddProximityDriver adds a proximity transport (e.g. NFC or ultrasonic)
+// backed by the given native driver, BLE is set with SetBleDriver. The
+// multiaddr protocol of the driver (`/<ProtocolName>/<peer id>` with the code
+// ProtocolCode) is registered and the node listens on its DefaultAddr. It
+// fails if the protocol name or code is already used by another protocol or
+// driver.
+func (c *NodeConfig) AddProximityDriver(driver ProximityDriver) error {
+	if driver == nil {
+		return fmt.Errorf("proximity driver is nil")
+	}
+
+	name := driver.ProtocolName()
+	if name == ble.ProtocolName {
+		return fmt.Errorf("use SetBleDriver to set the `%s` driver", name)
+	}
+	for _, d := range c.proximityDrivers {
+		if d.ProtocolName() == name || d.ProtocolCode() == driver.ProtocolCode() {
+			return fmt.Errorf("a proximity driver is already set for `%s`", d.ProtocolName())
+		}
+	}
+
+	if err := proximity.RegisterProtocol(name, driver.ProtocolCode()); err != nil {
+		return fmt.Errorf("unable to register proximity protocol: %w", err)
+	}
+
+	if _, err := ma.NewMultiaddr(driver.DefaultAddr()); err != nil {
+		return fmt.Errorf("invalid default address `%s`: %w", driver.DefaultAddr(), err)
+	}
+
+	c.proximityDrivers = append(c.proximityDrivers, driver)
+	return nil
+}
+
+// SetNetDriverErrorHandler sets the handler notified when the native net
+// driver fails several times in a row.
+func (c *NodeConfig) SetNetDriverErrorHandler(handler NetDriverErrorHandler) {
+	c.netDriverErrorHandler = handler
+}
+
+// SetNetDriverFallback makes the node use the interfaces detected by the go
+// runtime when the native net driver fails, instead of failing. On Android
+// the runtime detection may be restricted and return fewer interfaces.
+func (c *NodeConfig) SetNetDriverFallback(enabled bool) { c.netDriverFallback = enabled }
+
+// SetLogLevel sets the level of the ipfs logs applied by NewNode: a level
+// for every subsystem (`debug`, `info`, `warn` or `error`), levels of some
+// subsystems (e.g. `dht=info,bitswap=warn`), or both (`error,dht=info`).
+// When not set the levels are left untouched, go-log logs errors only unless
+// the GOLOG_LOG_LEVEL environment variable says otherwise, an empty level
+// unsets it.
+func (c *NodeConfig) SetLogLevel(level string) error {
+	if level == "" {
+		c.logLevel = ""
+		return nil
+	}
+
+	if _, err := ipfs_mobile.ParseLogLevel(level); err != nil {
+		return err
+	}
+
+	c.logLevel = level
+	return nil
+}
+
+// SetLogDriver makes the node forward the ipfs logs, at the levels set with
+// SetLogLevel, to driver until it is closed. The driver is called from a
+// single goroutine off the logging path: when it can't keep up the entries
+// are dropped, and the number of dropped entries is logged once it catches
+// up. A nil driver disables it (default).
+func (c *NodeConfig) SetLogDriver(driver NativeLogDriver) { c.logDriver = driver }
+
+// SetReachabilityHandler makes the node notify handler when its reachability
+// changes (public, private) and when it gains its first peer or loses its
+// last one, from its start until it is closed. A nil handler disables it
+// (default).
+func (c *NodeConfig) SetReachabilityHandler(handler ReachabilityHandler) {
+	c.reachabilityHandler = handler
+}
+
+// SetDebugLogFile makes the node write the ipfs logs, at the levels set with
+// SetLogLevel, to the file at path until it is closed. Once the file reaches
+// maxBytes it is renamed `<path>.1`, the previous rotated files being shifted
+// up to `<path>.<maxFiles>`, so the logs never take more than about
+// (maxFiles+1)*maxBytes. With maxFiles 0 the file is truncated instead. An
+// empty path disables file logging (default).
+func (c *NodeConfig) SetDebugLogFile(path string, maxBytes int64, maxFiles int) error {
+	if path != "" && maxBytes <= 0 {
+		return fmt.Errorf("invalid max log file size %d", maxBytes)
+	}
+	if maxFiles < 0 {
+		return fmt.Errorf("invalid max rotated log files %d", maxFiles)
+	}
+
+	c.debugLogPath = path
+	c.debugLogMaxBytes = maxBytes
+	c.debugLogMaxFiles = maxFiles
+	return nil
+}
+
+// SetMaxHTTPConns sets the maximum number of concurrent connections accepted
+// by each API and gateway listener, 0 means unlimited. Connections idle for a
+// minute, or not sending their request within 10 seconds, are closed so they
+// don't hold a slot.
+func (c *NodeConfig) SetMaxHTTPConns(max int) error {
+	if max < 0 {
+		return fmt.Errorf("invalid max http connections %d", max)
+	}
+
+	c.maxHTTPConns = max
+	return nil
+}
+
+// SetPluginLoadTimeout sets the maximum time in milliseconds NewNode waits for
+// the plugins to be initialized, 0 means no timeout.
+func (c *NodeConfig) SetPluginLoadTimeout(ms int64) {
+	c.pluginLoadTimeout = time.Duration(ms) * time.Millisecond
+}
+
+// SetSwarmPort makes the node listen on the given port for every tcp and udp
+// swarm address of the config instead of the configured (usually random) ones,
+// 0 keeps the configured ports.
+func (c *NodeConfig) SetSwarmPort(port int) error {
+	if port < 0 || port > 65535 {
+		return fmt.Errorf("invalid swarm port %d, expected a value between 1 and 65535", port)
+	}
+
+	c.swarmPort = port
+	return nil
+}
+
+// SetBindInterface makes the node listen only on the addresses of the named
+// interface (e.g. the tun interface of an Android VPN) and restricts mDNS to
+// it, an empty name uses every interface. NewNode fails if the interface
+// doesn't exist.
+func (c *NodeConfig) SetBindInterface(name string) { c.bindInterface = name }
+
+// SetDNSResolver makes the node resolve names with the given DNS servers, a
+// comma separated list of `host:port` entries, e.g. `1.1.1.1:53,9.9.9.9:53`.
+// The servers are used in turn, a server that can't be dialed is skipped for
+// the next one. An empty list uses the DNS of the system (default). The
+// resolver is process wide: it replaces the default resolver of go.
+func (c *NodeConfig) SetDNSResolver(servers string) error {
+	parsed, err := parseDNSServers(servers)
+	if err != nil {
+		return err
+	}
+
+	c.dnsServers = parsed
+	return nil
+}
+
+// SetUseSystemDNS makes NewNode leave the resolver of the process untouched,
+// ignoring the servers set with SetDNSResolver, for apps installing their own
+// resolver.
+func (c *NodeConfig) SetUseSystemDNS(enabled bool) { c.useSystemDNS = enabled }
+
+// SetForceReachability sets the reachability of the node: `auto` (default)
+// lets AutoNAT detect it, `private` and `public` force it, e.g. `public` on a
+// phone with a public IP, so the node advertises its public addresses and the
+// DHT runs in server mode.
+func (c *NodeConfig) SetForceReachability(mode string) error {
+	switch mode {
+	case ReachabilityAuto, ReachabilityPrivate, ReachabilityPublic:
+	default:
+		return fmt.Errorf("invalid reachability `%s`, expected `%s`, `%s` or `%s`",
+			mode, ReachabilityAuto, ReachabilityPrivate, ReachabilityPublic)
+	}
+
+	c.reachability = mode
+	return nil
+}
+
+// SetEnableRelayClient overrides Swarm.RelayClient.Enabled of the repo config
+// for the node: the client reserves slots on relays through AutoRelay when the
+// node is not publicly reachable, and dials the peers only reachable through
+// a relay. It requires the relay transport (Swarm.Transports.Network.Relay).
+// When not set the repo config applies, enabled by default.
+func (c *NodeConfig) SetEnableRelayClient(enabled bool) { c.relayClient = relayFlag(enabled) }
+
+// SetEnableRelayService overrides Swarm.RelayService.Enabled of the repo
+// config for the node: the service relays the connections of other peers once
+// the node is publicly reachable, see SetForceReachability. When not set the
+// repo config applies, enabled by default.
+func (c *NodeConfig) SetEnableRelayService(enabled bool) { c.relayService = relayFlag(enabled) }
+
+func relayFlag(enabled bool) ipfs_config.Flag {
+	if enabled {
+		return ipfs_config.True
+	}
+	return ipfs_config.False
+}
+
+// SetAcceleratedDHT makes the node use the accelerated DHT client of kubo
+// (fullrt) instead of the standard one, speeding up provides and lookups a
+// lot. It keeps a routing table of the whole network, crawled every hour: it
+// costs tens of megabytes of memory and bursts of thousands of connections,
+// so it is disabled by default and shouldn't be enabled on low-end devices or
+// on cellular networks. As it can't be switched at runtime, apps should only
+// enable it when the node is created on WiFi. Reproviding is then handled by
+// the accelerated client and SetReprovideInterval is not available.
+func (c *NodeConfig) SetAcceleratedDHT(enabled bool) { c.acceleratedDHT = enabled }
+
+// SetReproviderInterval overrides the interval, in seconds, at which the node
+// announces again its content to the DHT (`Reprovider.Interval` of the repo
+// config, 12 hours by default). Longer intervals save battery and bandwidth,
+// but content may become unreachable between two reprovides. A positive
+// interval can still be changed at runtime with Node.SetReprovideInterval,
+// while 0 disables reproviding for the lifetime of the node.
+func (c *NodeConfig) SetReproviderInterval(seconds int64) error {
+	if seconds < 0 {
+		return fmt.Errorf("invalid reprovider interval %ds", seconds)
+	}
+
+	d := time.Duration(seconds) * time.Second
+	c.reprovideInterval = &d
+	return nil
+}
+
+// SetReproviderStrategy overrides the blocks announced again to the DHT
+// (`Reprovider.Strategy` of the repo config): `all` (default), `pinned` for
+// the pinned blocks only, or `roots` for the roots of the pins only, so only
+// the content the user explicitly keeps is announced. Added blocks are still
+// provided once. An empty strategy keeps the one of the repo config.
+func (c *NodeConfig) SetReproviderStrategy(strategy string) error {
+	switch strategy {
+	case "", ReproviderStrategyAll, ReproviderStrategyPinned, ReproviderStrategyRoots:
+	default:
+		return fmt.Errorf("invalid reprovider strategy `%s`, expected `%s`, `%s` or `%s`",
+			strategy, ReproviderStrategyAll, ReproviderStrategyPinned, ReproviderStrategyRoots)
+	}
+
+	c.reprovideStrategy = strategy
+	return nil
+}
+
+// SetEnableDHT enables the DHT (default). Without it the node has no
+// routing: content is only fetched from the connected peers, e.g. found with
+// mDNS, and nothing is provided. It can't be disabled along with
+// SetAcceleratedDHT.
+func (c *NodeConfig) SetEnableDHT(enabled bool) { c.disableDHT = !enabled }
+
+// SetEnablePubSub enables pubsub along with the IPNS records published over
+// pubsub (default). Disabling it saves the bandwidth and the connections of
+// the topic meshes, the PubSub methods of the node then fail.
+func (c *NodeConfig) SetEnablePubSub(enabled bool) { c.disablePubSub = !enabled }
+
+// SetConnMgr makes the node use the basic connection manager with the given
+// watermarks, overriding Swarm.ConnMgr of the repo config for the node: once
+// more than high connections are open, the least useful ones are closed until
+// low remain, sparing the connections younger than gracePeriodSeconds. Nodes
+// use DefaultConnMgrLowWater, DefaultConnMgrHighWater and
+// DefaultConnMgrGracePeriodSeconds unless set. Setting both watermarks to 0
+// lets the repo config apply instead, basic with 100 and 200 for the repos
+// initialized by this package.
+func (c *NodeConfig) SetConnMgr(low int, high int, gracePeriodSeconds int64) error {
+	if low == 0 && high == 0 {
+		c.connMgr = nil
+		return nil
+	}
+
+	if low < 0 || high < 1 || low > high {
+		return fmt.Errorf("invalid connection manager watermarks %d and %d", low, high)
+	}
+	if gracePeriodSeconds < 0 {
+		return fmt.Errorf("invalid connection manager grace period %ds", gracePeriodSeconds)
+	}
+
+	c.connMgr = &ipfs_config.ConnMgr{
+		Type:        ConnMgrTypeBasic,
+		LowWater:    low,
+		HighWater:   high,
+		GracePeriod: (time.Duration(gracePeriodSeconds) * time.Second).String(),
+	}
+	return nil
+}
+
+// SetBootstrapPeers sets the peers the node bootstraps from instead of the
+// Bootstrap peers of the repo config, e.g. the peers of a private network.
+// multiaddrs is a newline or comma separated list of multiaddrs ending with a
+// `/p2p/<peer id>` component, an empty list restores the repo peers. It fails
+// listing every invalid multiaddr.
+func (c *NodeConfig) SetBootstrapPeers(multiaddrs string) error {
+	var peers, invalid []string
+	for _, addr := range splitList(multiaddrs) {
+		if _, err := p2p_peer.AddrInfoFromString(addr); err != nil {
+			invalid = append(invalid, fmt.Sprintf("`%s`", addr))
+			continue
+		}
+		peers = append(peers, addr)
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid bootstrap peers, expected multiaddrs with a `/p2p/` component: %s", strings.Join(invalid, ", "))
+	}
+
+	c.bootstrapPeers = peers
+	return nil
+}
+
+// splitList splits a newline or comma separated list, skipping the empty
+// entries.
+func splitList(list string) []string {
+	var entries []string
+	for _, entry := range strings.FieldsFunc(list, func(r rune) bool {
+		return r == '\n' || r == ','
+	}) {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// SetDisableBootstrap disables the bootstrap of the node: it doesn't connect
+// to any bootstrap peer, the DHT doesn't use them either. The node only
+// finds peers through local discovery, the peering peers and explicit
+// connections.
+func (c *NodeConfig) SetDisableBootstrap(disable bool) {
+	c.disableBootstrap = disable
+}
+
+// SetSwarmKey makes the node join the private network of the given
+// pre-shared key, the content of a `swarm.key` file, instead of the public
+// network. It overrides the swarm.key of the repo, an empty key restores it.
+// The node only connects to the peers of the private network: the public
+// bootstrap peers are disabled unless SetBootstrapPeers is used, and QUIC is
+// disabled as it doesn't support private networks. It fails on a malformed
+// key.
+func (c *NodeConfig) SetSwarmKey(key []byte) error {
+	if len(key) == 0 {
+		c.swarmKey = nil
+		return nil
+	}
+
+	if _, err := p2p_pnet.DecodeV1PSK(bytes.NewReader(key)); err != nil {
+		return fmt.Errorf("invalid swarm key: %w", err)
+	}
+
+	c.swarmKey = append([]byte{}, key...)
+	return nil
+}
+
+// SetDelegatedRouting makes the node query the given reframe routing
+// endpoints (e.g. `https://cid.contact/reframe`), a newline or comma
+// separated list of http(s) urls, to find providers, peers and IPNS records
+// and to provide, so constrained devices find content without crawling the
+// DHT. The endpoints are queried in parallel, along with a DHT unless it is
+// disabled with SetEnableDHT(false). That DHT is built by kubo: it can't be
+// switched at runtime with SetDHTMode and can't be the accelerated DHT
+// client. An empty list restores the default routing. It fails listing
+// every invalid url.
+func (c *NodeConfig) SetDelegatedRouting(endpoints string) error {
+	parsed, err := parseDelegatedEndpoints(endpoints)
+	if err != nil {
+		return err
+	}
+
+	c.delegatedRouting = parsed
+	return nil
+}
+
+// SetMaxConcurrentDials sets the maximum number of TCP and unix socket
+// addresses (websockets included) the swarm dials at once, default
+// DefaultMaxConcurrentDials, 0 uses the default of libp2p (160). Only the
+// dials consuming a file descriptor are limited: QUIC dials are not counted
+// and never wait for a slot. Apps may lower it on cellular networks, where
+// many concurrent dials during bootstrap spike the CPU and radio usage. The
+// swarm doesn't implement happy eyeballs: it dials every address of a peer at
+// once, up to 8 per peer, so a low limit is quickly taken by a few peers with
+// many addresses, the other dials wait for a slot. Relay dials don't count in
+// the limit, the dial to the relay itself does. The limit only applies to
+// the node it configures, the LIBP2P_SWARM_FD_LIMIT environment variable is
+// ignored.
+func (c *NodeConfig) SetMaxConcurrentDials(max int) error {
+	if max < 0 {
+		return fmt.Errorf("invalid max concurrent dials %d", max)
+	}
+
+	c.maxConcurrentDials = max
+	return nil
+}
+
+// AddPreloadCAR adds a CAR file imported into the blockstore by NewNode, so
+// content bundled with the app is available offline right away. The files
+// are imported on every start.
+func (c *NodeConfig) AddPreloadCAR(path string) {
+	c.preloadCARs = append(c.preloadCARs, path)
+}
+
+// SetPreloadPinRoots makes NewNode recursively pin the roots of the preloaded
+// CAR files, so they are never garbage collected.
+func (c *NodeConfig) SetPreloadPinRoots(enabled bool) { c.preloadPinRoots = enabled }
+
+// SetPreloadLenient makes NewNode only log the failures to preload a CAR
+// file instead of failing.
+func (c *NodeConfig) SetPreloadLenient(enabled bool) { c.preloadLenient = enabled }
+
+// reachabilityOption returns the libp2p option forcing the reachability, nil
+// in auto mode.
+func (c *NodeConfig) reachabilityOption() libp2p.Option {
+	switch c.reachability {
+	case ReachabilityPrivate:
+		return libp2p.ForceReachabilityPrivate()
+	case ReachabilityPublic:
+		return libp2p.ForceReachabilityPublic()
+	default:
+		return nil
+	}
+}
+
+// swarmAddrsWithPort replaces the tcp and udp port of the given addresses.
+func swarmAddrsWithPort(addrs []string, port int) ([]string, error) {
+	out := make([]string, len(addrs))
+	for i, addr := range addrs {
+		maddr, err := ma.NewMultiaddr(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid swarm address `%s`: %w", addr, err)
+		}
+
+		var comps []ma.Multiaddr
+		ma.ForEach(maddr, func(c ma.Component) bool {
+			switch c.Protocol().Code {
+			case ma.P_TCP, ma.P_UDP:
+				pc, err := ma.NewComponent(c.Protocol().Name, fmt.Sprint(port))
+				if err == nil {
+					comps = append(comps, pc)
+					return true
+				}
+			}
+			comps = append(comps, &c)
+			return true
+		})
+
+		out[i] = ma.Join(comps...).String()
+	}
+	return out, nil
+}
+
+// swarmAddrsForInterface replaces the unspecified ip of the given addresses
+// by the ips of the named interface.
+func swarmAddrsForInterface(addrs []string, name string) ([]string, error) {
+	ifaddrs, err := ipfsutil.InterfaceAddrsByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bind interface: %w", err)
+	}
+
+	var ip4s, ip6s []net.IP
+	for _, addr := range ifaddrs {
+		var ip net.IP
+		switch v := addr.(type) {
+		case *net.IPNet:
+			ip = v.IP
+		case *net.IPAddr:
+			ip = v.IP
+		}
+
+		switch {
+		case ip == nil, ip.IsLinkLocalUnicast():
+			// link local addresses require a zone
+		case ip.To4() != nil:
+			ip4s = append(ip4s, ip)
+		default:
+			ip6s = append(ip6s, ip)
+		}
+	}
+
+	out := []string{}
+	for _, addr := range addrs {
+		maddr, err := ma.NewMultiaddr(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid swarm address `%s`: %w", addr, err)
+		}
+
+		first, rest := ma.SplitFirst(maddr)
+		if first == nil {
+			continue
+		}
+
+		var ips []net.IP
+		switch first.Protocol().Code {
+		case ma.P_IP4:
+			ips = ip4s
+		case ma.P_IP6:
+			ips = ip6s
+		default:
+			out = append(out, addr)
+			continue
+		}
+
+		if !net.ParseIP(first.Value()).IsUnspecified() {
+			out = append(out, addr)
+			continue
+		}
+
+		for _, ip := range ips {
+			ipc, err := ma.NewComponent(first.Protocol().Name, ip.String())
+			if err != nil {
+				return nil, err
+			}
+
+			bound := ma.Multiaddr(ipc)
+			if rest != nil {
+				bound = bound.Encapsulate(rest)
+			}
+			out = append(out, bound.String())
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("interface `%s` has no usable address", name)
+	}
+	return out, nil
+}
+
+// SetPubSubHeartbeatInterval sets the gossipsub heartbeat interval in
+// milliseconds (default 1000). The heartbeat maintains the mesh and emits
+// gossip: a longer interval saves battery and bandwidth but slows down the
+// mesh repair after a peer loss. 0 keeps the default.
+func (c *NodeConfig) SetPubSubHeartbeatInterval(ms int64) {
+	c.gossipSub.heartbeatInterval = time.Duration(ms) * time.Millisecond
+}
+
+// SetPubSubMeshDegree sets the gossipsub mesh degree: d is the number of
+// peers a message is forwarded to (default 6), dlo and dhi the bounds under
+// and over which the mesh gets grafted or pruned (default 5 and 12). Small
+// mobile meshes benefit from lower values, reducing duplicated messages at
+// the cost of resilience. 0 keeps the defaults.
+func (c *NodeConfig) SetPubSubMeshDegree(d, dlo, dhi int) error {
+	if d != 0 || dlo != 0 || dhi != 0 {
+		if dlo <= 0 || dlo > d || d > dhi {
+			return fmt.Errorf("invalid mesh degree, expected 0 < dlo (%d) <= d (%d) <= dhi (%d)", dlo, d, dhi)
+		}
+	}
+
+	c.gossipSub.d, c.gossipSub.dlo, c.gossipSub.dhi = d, dlo, dhi
+	return nil
+}
+
+// SetPubSubMessageCacheLength sets the number of heartbeats messages are kept
+// in the gossipsub message cache (default 5). A bigger cache lets peers
+// recover messages they missed for longer, which matters for large messages
+// or flaky connections, at the cost of memory. 0 keeps the default.
+func (c *NodeConfig) SetPubSubMessageCacheLength(length int) error {
+	if length < 0 {
+		return fmt.Errorf("invalid message cache length %d", length)
+	}
+
+	c.gossipSub.historyLength = length
+	return nil
+}
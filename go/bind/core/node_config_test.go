@@ -0,0 +1,464 @@
+package core
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	ble "github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/ble-driver"
+	proximity "github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/proximitytransport"
+	p2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+	p2p_connmgr "github.com/libp2p/go-libp2p/p2p/net/connmgr"
+)
+
+func TestNodeConfigSetSwarmPort(t *testing.T) {
+	cfg := NewNodeConfig()
+
+	for _, port := range []int{-1, 65536} {
+		if err := cfg.SetSwarmPort(port); err == nil {
+			t.Errorf("port %d should be refused", port)
+		}
+	}
+
+	if err := cfg.SetSwarmPort(4001); err != nil {
+		t.Fatal(err)
+	}
+
+	addrs, err := swarmAddrsWithPort([]string{
+		"/ip4/0.0.0.0/tcp/0",
+		"/ip6/::/tcp/0",
+		"/ip4/0.0.0.0/udp/0/quic",
+	}, cfg.swarmPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"/ip4/0.0.0.0/tcp/4001",
+		"/ip6/::/tcp/4001",
+		"/ip4/0.0.0.0/udp/4001/quic",
+	}
+	for i := range expected {
+		if addrs[i] != expected[i] {
+			t.Errorf("expected `%s` got `%s`", expected[i], addrs[i])
+		}
+	}
+}
+
+func TestSwarmAddrsForInterface(t *testing.T) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var loopback string
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			loopback = iface.Name
+			break
+		}
+	}
+	if loopback == "" {
+		t.Skip("no loopback interface found")
+	}
+
+	addrs, err := swarmAddrsForInterface([]string{"/ip4/0.0.0.0/tcp/0"}, loopback)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, addr := range addrs {
+		if addr == "/ip4/127.0.0.1/tcp/0" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected `/ip4/127.0.0.1/tcp/0` in `%v`", addrs)
+	}
+
+	if _, err := swarmAddrsForInterface([]string{"/ip4/0.0.0.0/tcp/0"}, "doesnotexist0"); err == nil {
+		t.Error("binding to an unknown interface should fail")
+	}
+}
+
+func TestNodeConfigGossipSubParams(t *testing.T) {
+	cfg := NewNodeConfig()
+
+	if err := cfg.SetPubSubMeshDegree(3, 4, 6); err == nil {
+		t.Error("dlo greater than d should be refused")
+	}
+
+	if err := cfg.SetPubSubMeshDegree(3, 2, 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetPubSubMessageCacheLength(10); err != nil {
+		t.Fatal(err)
+	}
+
+	applyGossipSubParams(cfg.gossipSub)
+	defer applyGossipSubParams(gossipSubParams{})
+
+	if p2p_pubsub.GossipSubD != 3 || p2p_pubsub.GossipSubDlo != 2 || p2p_pubsub.GossipSubDhi != 4 {
+		t.Errorf("unexpected mesh degree %d/%d/%d", p2p_pubsub.GossipSubD, p2p_pubsub.GossipSubDlo, p2p_pubsub.GossipSubDhi)
+	}
+	if p2p_pubsub.GossipSubDout >= p2p_pubsub.GossipSubDlo {
+		t.Errorf("Dout (%d) should be lower than Dlo (%d)", p2p_pubsub.GossipSubDout, p2p_pubsub.GossipSubDlo)
+	}
+	if p2p_pubsub.GossipSubHistoryLength != 10 {
+		t.Errorf("expected message cache length 10 got %d", p2p_pubsub.GossipSubHistoryLength)
+	}
+}
+
+func TestNodeConfigSetForceReachability(t *testing.T) {
+	cfg := NewNodeConfig()
+
+	if cfg.reachabilityOption() != nil {
+		t.Error("reachability shouldn't be forced by default")
+	}
+
+	if err := cfg.SetForceReachability("always"); err == nil {
+		t.Error("expected an error for an unknown reachability")
+	}
+
+	if err := cfg.SetForceReachability(ReachabilityPublic); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.reachabilityOption() == nil {
+		t.Error("expected an option forcing the reachability")
+	}
+}
+
+func TestNodeConfigAddProximityDriver(t *testing.T) {
+	const (
+		testProtocolName = "nfc-test"
+		testProtocolCode = 0x0300
+		testDefaultAddr  = "/nfc-test/Qmeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+	)
+
+	cfg := NewNodeConfig()
+
+	driver := proximity.NewNoopProximityDriver(testProtocolCode, testProtocolName, testDefaultAddr)
+	if err := cfg.AddProximityDriver(driver); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfg.AddProximityDriver(driver); err == nil {
+		t.Error("expected an error adding a driver twice")
+	}
+
+	conflict := proximity.NewNoopProximityDriver(testProtocolCode, "other-test", "/other-test/Qmeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee")
+	if err := NewNodeConfig().AddProximityDriver(conflict); err == nil {
+		t.Error("expected an error with a protocol code already registered")
+	}
+
+	bleDriver := proximity.NewNoopProximityDriver(ble.ProtocolCode, ble.ProtocolName, ble.DefaultAddr)
+	if err := NewNodeConfig().AddProximityDriver(bleDriver); err == nil {
+		t.Error("expected an error with the ble driver")
+	}
+
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	node, err := NewNode(repo, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	listening := false
+	for _, addr := range node.ipfsMobile.PeerHost().Network().ListenAddresses() {
+		if _, err := addr.ValueForProtocol(testProtocolCode); err == nil {
+			listening = true
+		}
+	}
+	if !listening {
+		t.Errorf("node should listen on the `%s` transport", testProtocolName)
+	}
+}
+
+func TestNodeConfigWifiDirectDriver(t *testing.T) {
+	cfg := NewNodeConfig()
+	cfg.SetBleDriver(proximity.NewNoopProximityDriver(ble.ProtocolCode, ble.ProtocolName, ble.DefaultAddr))
+
+	wifi := proximity.NewNoopProximityDriver(WifiDirectProtocolCode, WifiDirectProtocolName, WifiDirectDefaultAddr)
+	if err := cfg.AddProximityDriver(wifi); err != nil {
+		t.Fatal(err)
+	}
+
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	node, err := NewNode(repo, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	// both proximity transports run side by side
+	for _, name := range []string{ble.ProtocolName, WifiDirectProtocolName} {
+		if GetProximityTransport(name) == nil {
+			t.Errorf("expected the `%s` transport to be running", name)
+		}
+	}
+	if GetProximityTransport("unknown") != nil {
+		t.Error("expected no transport for an unknown protocol")
+	}
+}
+
+func TestNodeConfigSetLogLevel(t *testing.T) {
+	config := NewNodeConfig()
+
+	for _, level := range []string{"error", "DEBUG", "dht=info,bitswap=warn", "error, dht=info"} {
+		if err := config.SetLogLevel(level); err != nil {
+			t.Errorf("expected `%s` to be accepted: %s", level, err)
+		}
+	}
+
+	for _, level := range []string{"loud", "dht=verbose", "=info", "dht=", ","} {
+		if err := config.SetLogLevel(level); err == nil {
+			t.Errorf("expected `%s` to be refused", level)
+		}
+	}
+
+	// an invalid level keeps the previous one
+	if config.logLevel != "error, dht=info" {
+		t.Errorf("expected the last valid level to be kept, got `%s`", config.logLevel)
+	}
+
+	if err := config.SetLogLevel(""); err != nil || config.logLevel != "" {
+		t.Errorf("expected an empty level to unset it, got `%s` (%v)", config.logLevel, err)
+	}
+}
+
+func TestNodeConfigDisableDHTAndPubSub(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	config := NewNodeConfig()
+	config.SetEnableDHT(false)
+	config.SetEnablePubSub(false)
+
+	config.SetAcceleratedDHT(true)
+	if _, err := NewNode(repo, config); err == nil {
+		t.Fatal("expected an error with the accelerated dht client and the dht disabled")
+	}
+	config.SetAcceleratedDHT(false)
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	if node.ipfsMobile.IpfsNode.DHT != nil {
+		t.Error("expected the dht to be disabled")
+	}
+	if err := node.SetDHTServerMode(true); err == nil {
+		t.Error("expected an error switching a disabled dht")
+	}
+
+	if _, err := node.PubSubTopics(); !errors.Is(err, errPubSubDisabled) {
+		t.Errorf("expected a pubsub disabled error got `%v`", err)
+	}
+}
+
+func TestNodeConfigSetConnMgr(t *testing.T) {
+	config := NewNodeConfig()
+	for _, wm := range [][2]int{{-1, 10}, {10, 5}, {5, 0}} {
+		if err := config.SetConnMgr(wm[0], wm[1], 1); err == nil {
+			t.Errorf("expected watermarks `%v` to be refused", wm)
+		}
+	}
+	if err := config.SetConnMgr(1, 2, -1); err == nil {
+		t.Error("expected a negative grace period to be refused")
+	}
+
+	if err := config.SetConnMgr(10, 30, 20); err != nil {
+		t.Fatal(err)
+	}
+
+	// both watermarks to 0 let the repo config apply
+	repoConfig := NewNodeConfig()
+	if err := repoConfig.SetConnMgr(0, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		config    *NodeConfig
+		low, high int
+	}{
+		{"default", NewNodeConfig(), DefaultConnMgrLowWater, DefaultConnMgrHighWater},
+		{"set", config, 10, 30},
+		{"repo", repoConfig, defaultConnMgrLowWater, defaultConnMgrHighWater},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path, clean := testingTempDir(t, "repo")
+			defer clean()
+
+			repo, clean := testingRepo(t, path)
+			defer clean()
+
+			node, err := NewNode(repo, tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer node.Close()
+
+			cm, ok := node.ipfsMobile.PeerHost().ConnManager().(*p2p_connmgr.BasicConnMgr)
+			if !ok {
+				t.Fatalf("expected a basic connection manager got `%T`", node.ipfsMobile.PeerHost().ConnManager())
+			}
+			if info := cm.GetInfo(); info.LowWater != tc.low || info.HighWater != tc.high {
+				t.Errorf("expected watermarks %d and %d got %d and %d", tc.low, tc.high, info.LowWater, info.HighWater)
+			}
+
+			// the override only applies to the node
+			cfg, err := repo.mr.Config()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Swarm.ConnMgr.LowWater != defaultConnMgrLowWater || cfg.Swarm.ConnMgr.HighWater != defaultConnMgrHighWater {
+				t.Errorf("expected the repo config to be restored got `%+v`", cfg.Swarm.ConnMgr)
+			}
+		})
+	}
+}
+
+func TestNodeConfigSetBootstrapPeers(t *testing.T) {
+	const bootstrapPeer = "/ip4/127.0.0.1/tcp/4001/p2p/QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN"
+
+	config := NewNodeConfig()
+	err := config.SetBootstrapPeers(bootstrapPeer + "\n/ip4/127.0.0.1/tcp/4001,not a multiaddr")
+	if err == nil {
+		t.Fatal("expected invalid bootstrap peers to be refused")
+	}
+	for _, addr := range []string{"`/ip4/127.0.0.1/tcp/4001`", "`not a multiaddr`"} {
+		if !strings.Contains(err.Error(), addr) {
+			t.Errorf("expected the error to list %s got `%s`", addr, err)
+		}
+	}
+
+	if err := config.SetBootstrapPeers(bootstrapPeer + "\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	// the bootstrap stores the addresses of the peers it dials
+	<-node.bootstrapDone
+	pid, err := p2p_peer.Decode("QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs := node.ipfsMobile.PeerHost().Peerstore().Addrs(pid)
+	if len(addrs) != 1 || addrs[0].String() != "/ip4/127.0.0.1/tcp/4001" {
+		t.Errorf("expected the custom bootstrap peer to be dialed got `%v`", addrs)
+	}
+
+	// the override only applies to the node
+	cfg, err := repo.mr.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Bootstrap) != 0 {
+		t.Errorf("expected the repo config to be restored got `%v`", cfg.Bootstrap)
+	}
+}
+
+func TestNodeConfigSetSwarmKey(t *testing.T) {
+	config := NewNodeConfig()
+	if err := config.SetSwarmKey([]byte("/key/swarm/psk/1.0.0/\n/base16/\nnot hex")); err == nil {
+		t.Fatal("expected a malformed swarm key to be refused")
+	}
+
+	key := "/key/swarm/psk/1.0.0/\n/base16/\n" + strings.Repeat("0f", 32) + "\n"
+	if err := config.SetSwarmKey([]byte(key)); err != nil {
+		t.Fatal(err)
+	}
+
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	if node.ipfsMobile.IpfsNode.PNetFingerprint == nil {
+		t.Error("expected the node to join a private network")
+	}
+
+	// the key only applies to the node
+	if key, err := repo.mr.SwarmKey(); err != nil || key != nil {
+		t.Errorf("expected no swarm key in the repo got `%s` (%v)", key, err)
+	}
+}
+
+func TestNodeConfigSetDelegatedRouting(t *testing.T) {
+	config := NewNodeConfig()
+	err := config.SetDelegatedRouting("https://127.0.0.1:1/reframe,ftp://127.0.0.1/reframe\nnot an url")
+	if err == nil {
+		t.Fatal("expected invalid endpoints to be refused")
+	}
+	for _, endpoint := range []string{"`ftp://127.0.0.1/reframe`", "`not an url`"} {
+		if !strings.Contains(err.Error(), endpoint) {
+			t.Errorf("expected the error to list %s got `%s`", endpoint, err)
+		}
+	}
+
+	if err := config.SetDelegatedRouting("http://127.0.0.1:1/reframe\nhttp://127.0.0.1:2/reframe"); err != nil {
+		t.Fatal(err)
+	}
+
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	if node.ipfsMobile.IpfsNode.Routing == nil {
+		t.Error("expected the node to have a router")
+	}
+
+	// the routing config only applies to the node
+	cfg, err := repo.mr.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Routing.Type == "custom" || len(cfg.Routing.Routers) != 0 {
+		t.Errorf("expected the repo config to be restored got `%+v`", cfg.Routing)
+	}
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -12,7 +13,10 @@ import (
 	"time"
 
 	ipfs_files "github.com/ipfs/go-ipfs-files"
+	ipfs_core "github.com/ipfs/kubo/core"
+	ipfs_bs "github.com/ipfs/kubo/core/bootstrap"
 	ipfs_coreapi "github.com/ipfs/kubo/core/coreapi"
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
 
 	ma "github.com/multiformats/go-multiaddr"
 	manet "github.com/multiformats/go-multiaddr/net"
@@ -34,6 +38,85 @@ func TestNode(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestNodeMDNSWithoutLocker(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	// mDNS is enabled by the default config
+	if node.mdnsService == nil {
+		t.Fatal("mdns service should be set up without a locker driver")
+	}
+
+	if err := node.SetMDNSAdvertise(false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type testMDNSLocker struct {
+	locked int
+}
+
+func (l *testMDNSLocker) Lock()   { l.locked++ }
+func (l *testMDNSLocker) Unlock() { l.locked-- }
+
+func TestNodeStartStopMDNS(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	locker := &testMDNSLocker{}
+	config := NewNodeConfig()
+	config.SetMDNSLocker(locker)
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if locker.locked != 1 {
+		t.Fatalf("expected the locker to be held once got %d", locker.locked)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := node.StopMDNS(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if node.mdnsService != nil || locker.locked != 0 {
+		t.Fatal("expected mdns to be stopped and the locker released")
+	}
+
+	// kept until mdns is started again
+	if err := node.SetMDNSAdvertise(false); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := node.StartMDNS(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if node.mdnsService == nil || locker.locked != 1 {
+		t.Fatal("expected mdns to be running and the locker held once")
+	}
+
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if locker.locked != 0 {
+		t.Error("expected the locker to be released when closing the node")
+	}
+	if err := node.StartMDNS(); err == nil {
+		t.Error("expected an error starting mdns on a closed node")
+	}
+}
+
 func TestNodeServeAPI(t *testing.T) {
 	t.Run("tpc api", func(t *testing.T) {
 		path, clean := testingTempDir(t, "tpc_repo")
@@ -209,4 +292,196 @@ func TestNodeServeGateway(t *testing.T) {
 			t.Fatalf("content `%s` are different from `%s`", b, testcontent)
 		}
 	})
+
+	t.Run("lan gateway", func(t *testing.T) {
+		if _, err := lanIPv4(); err != nil {
+			t.Skip(err)
+		}
+
+		path, clean := testingTempDir(t, "lan_repo")
+		defer clean()
+
+		node, clean := testingNode(t, path)
+		defer clean()
+
+		smaddr, err := node.ServeGatewayLAN("0", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		maddr, err := ma.NewMultiaddr(smaddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr, err := manet.ToNetAddr(maddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ip := addr.(*net.TCPAddr).IP; ip.IsUnspecified() || ip.IsLoopback() {
+			t.Fatalf("`%s` is not a LAN address", smaddr)
+		}
+
+		api, err := ipfs_coreapi.NewCoreAPI(node.ipfsMobile.IpfsNode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		file := ipfs_files.NewBytesFile(testcontent)
+		resolved, err := api.Unixfs().Add(context.Background(), file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		url := fmt.Sprintf("http://%s/ipfs/%s", addr.String(), resolved.Cid().String())
+		client := http.Client{Timeout: 5 * time.Second}
+
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(b, testcontent) {
+			t.Fatalf("content `%s` are different from `%s`", b, testcontent)
+		}
+	})
+}
+
+func TestNodeReadinessState(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	node, err := NewNode(repo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// testing repo has no bootstrap peers, so the node has no peers
+	<-node.bootstrapDone
+	if state := node.ReadinessState(); state != ReadinessDegraded {
+		t.Errorf("expected `%s` got `%s`", ReadinessDegraded, state)
+	}
+
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if state := node.ReadinessState(); state != ReadinessClosed {
+		t.Errorf("expected `%s` got `%s`", ReadinessClosed, state)
+	}
+}
+
+func TestNodeReadinessStateBootstrapFailed(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	release := make(chan struct{})
+	defer func(orig func(*ipfs_core.IpfsNode, ipfs_bs.BootstrapConfig) error) {
+		bootstrapNode = orig
+	}(bootstrapNode)
+	bootstrapNode = func(*ipfs_core.IpfsNode, ipfs_bs.BootstrapConfig) error {
+		<-release
+		return errors.New("bootstrap failed")
+	}
+
+	node, err := NewNode(repo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	if state := node.ReadinessState(); state != ReadinessBootstrapping {
+		t.Errorf("expected `%s` got `%s`", ReadinessBootstrapping, state)
+	}
+
+	close(release)
+	<-node.bootstrapDone
+
+	if state := node.ReadinessState(); state != ReadinessDegraded {
+		t.Errorf("expected `%s` got `%s`", ReadinessDegraded, state)
+	}
+
+	// a failed bootstrap is reported even with connected peers
+	path2, clean := testingTempDir(t, "repo2")
+	defer clean()
+
+	node2, clean := testingNode(t, path2)
+	defer clean()
+
+	h1, h2 := node.ipfsMobile.PeerHost(), node2.ipfsMobile.PeerHost()
+	if err := h2.Connect(context.Background(), p2p_peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+	if state := node.ReadinessState(); state != ReadinessDegraded {
+		t.Errorf("expected `%s` got `%s`", ReadinessDegraded, state)
+	}
+}
+
+func TestNodeCloseWithTimeout(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	node, err := NewNode(repo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	smaddr, err := node.ServeTCPGateway("0", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	maddr, err := ma.NewMultiaddr(smaddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := manet.ToNetAddr(maddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a request that never completes keeps the connection active
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET /ipfs/")); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := node.CloseWithTimeout(1); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected close to wait for the active connection, returned after %s", elapsed)
+	}
+
+	// the connection got closed once the timeout expired
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected the connection to be closed got `%v`", err)
+	}
+
+	if _, err := net.Dial("tcp", addr.String()); err == nil {
+		t.Error("expected the gateway to stop accepting connections")
+	}
 }
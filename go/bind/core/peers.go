@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// Peers returns the connections of the node, one `<peer id> @ <multiaddr>`
+// line per connection, so a peer connected through several addresses appears
+// several times. It is empty when no peer is connected, e.g. before the
+// bootstrap completes.
+func (n *Node) Peers() string {
+	conns := n.ipfsMobile.PeerHost().Network().Conns()
+
+	lines := make([]string, len(conns))
+	for i, conn := range conns {
+		lines[i] = fmt.Sprintf("%s @ %s", conn.RemotePeer(), conn.RemoteMultiaddr())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PeerCount returns the number of peers the node is connected to.
+func (n *Node) PeerCount() int {
+	return len(n.ipfsMobile.PeerHost().Network().Peers())
+}
+
+// Connect connects the node to the peer at the given address, which must end
+// with the peer id, e.g. `/ip4/192.168.1.2/tcp/4001/p2p/<peer id>`. It waits
+// for the connection for at most timeoutSeconds (no timeout when not
+// positive), so a stuck dial doesn't block the caller.
+func (n *Node) Connect(maddr string, timeoutSeconds int64) error {
+	addr, err := ma.NewMultiaddr(maddr)
+	if err != nil {
+		return fmt.Errorf("invalid multiaddr `%s`: %w", maddr, err)
+	}
+
+	if _, err := addr.ValueForProtocol(ma.P_P2P); err != nil {
+		return fmt.Errorf("multiaddr `%s` has no `/p2p/<peer id>` component", maddr)
+	}
+
+	ai, err := p2p_peer.AddrInfoFromP2pAddr(addr)
+	if err != nil {
+		return fmt.Errorf("invalid peer address `%s`: %w", maddr, err)
+	}
+
+	ctx := n.ctx
+	if timeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	if err := n.ipfsMobile.PeerHost().Connect(ctx, *ai); err != nil {
+		return fmt.Errorf("unable to connect to `%s`: %w", ai.ID, err)
+	}
+	return nil
+}
+
+// Disconnect closes all the connections of the node to the given peer.
+func (n *Node) Disconnect(peerID string) error {
+	pid, err := p2p_peer.Decode(peerID)
+	if err != nil {
+		return fmt.Errorf("invalid peer id `%s`: %w", peerID, err)
+	}
+
+	if err := n.ipfsMobile.PeerHost().Network().ClosePeer(pid); err != nil {
+		return fmt.Errorf("unable to disconnect from `%s`: %w", pid, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestNodePeers(t *testing.T) {
+	path1, clean := testingTempDir(t, "repo1")
+	defer clean()
+
+	node1, clean := testingNode(t, path1)
+	defer clean()
+
+	if peers := node1.Peers(); peers != "" {
+		t.Fatalf("expected no peers, got `%s`", peers)
+	}
+	if count := node1.PeerCount(); count != 0 {
+		t.Fatalf("expected no peers, got %d", count)
+	}
+
+	path2, clean := testingTempDir(t, "repo2")
+	defer clean()
+
+	node2, clean := testingNode(t, path2)
+	defer clean()
+
+	h1, h2 := node1.ipfsMobile.PeerHost(), node2.ipfsMobile.PeerHost()
+	err := h2.Connect(context.Background(), p2p_peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := node1.PeerCount(); count != 1 {
+		t.Errorf("expected 1 peer, got %d", count)
+	}
+
+	if peers := node1.Peers(); !strings.HasPrefix(peers, h2.ID().String()+" @ /") {
+		t.Errorf("expected a connection to `%s`, got `%s`", h2.ID(), peers)
+	}
+}
+
+func TestNodeConnect(t *testing.T) {
+	path1, clean := testingTempDir(t, "repo1")
+	defer clean()
+
+	node1, clean := testingNode(t, path1)
+	defer clean()
+
+	path2, clean := testingTempDir(t, "repo2")
+	defer clean()
+
+	node2, clean := testingNode(t, path2)
+	defer clean()
+
+	h2 := node2.ipfsMobile.PeerHost()
+	addrs, err := p2p_peer.AddrInfoToP2pAddrs(&p2p_peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := node1.Connect(h2.Addrs()[0].String(), 1); err == nil {
+		t.Error("expected an address without peer id to be refused")
+	}
+
+	if err := node1.Connect(addrs[0].String(), 10); err != nil {
+		t.Fatal(err)
+	}
+	if count := node1.PeerCount(); count != 1 {
+		t.Errorf("expected 1 peer, got %d", count)
+	}
+
+	if err := node1.Disconnect(h2.ID().String()); err != nil {
+		t.Fatal(err)
+	}
+	if count := node1.PeerCount(); count != 0 {
+		t.Errorf("expected no peer after disconnecting, got %d", count)
+	}
+
+	if err := node1.Disconnect("not a peer id"); err == nil {
+		t.Error("expected an invalid peer id to be refused")
+	}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+	ipfs_options "github.com/ipfs/interface-go-ipfs-core/options"
+)
+
+// Pin status returned by IsPinned
+const (
+	PinStatusRecursive = "recursive"
+	PinStatusDirect    = "direct"
+	PinStatusIndirect  = "indirect"
+	PinStatusNotPinned = "not pinned"
+)
+
+// ErrNotPinned is returned by Unpin when the content is not pinned, or only
+// pinned indirectly by another pin.
+var ErrNotPinned = errors.New("not pinned")
+
+// Pin pins the content at the given path, e.g. `/ipfs/<cid>/dir` or a bare
+// cid, fetching it if needed. A recursive pin keeps the whole DAG, a direct
+// pin keeps the root block only.
+func (n *Node) Pin(cidPath string, recursive bool) error {
+	p, err := parsePath(cidPath)
+	if err != nil {
+		return err
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return err
+	}
+
+	if err := api.Pin().Add(n.ctx, p, ipfs_options.Pin.Recursive(recursive)); err != nil {
+		return fmt.Errorf("unable to pin `%s`: %w", p, err)
+	}
+	return nil
+}
+
+// Unpin removes the recursive or direct pin of the content at the given path,
+// along with its label if it was pinned with PinNamed. It returns an error
+// wrapping ErrNotPinned when there is no such pin, so callers can ignore it.
+func (n *Node) Unpin(cidPath string) error {
+	p, err := parsePath(cidPath)
+	if err != nil {
+		return err
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return err
+	}
+
+	resolved, err := api.ResolvePath(n.ctx, p)
+	if err != nil {
+		return fmt.Errorf("unable to resolve `%s`: %w", p, err)
+	}
+	c := resolved.Cid()
+
+	status, err := n.pinStatus(n.ctx, c)
+	if err != nil {
+		return err
+	}
+	if status != PinStatusRecursive && status != PinStatusDirect {
+		return fmt.Errorf("unable to unpin `%s`: %w", c, ErrNotPinned)
+	}
+
+	if err := api.Pin().Rm(n.ctx, resolved); err != nil {
+		return fmt.Errorf("unable to unpin `%s`: %w", c, err)
+	}
+
+	return n.removePinLabel(c)
+}
+
+// ListPins returns the recursive and direct pins, one `<cid> <type>` line per
+// pin. Indirect pins are not listed.
+func (n *Node) ListPins() (string, error) {
+	api, err := n.coreAPI()
+	if err != nil {
+		return "", err
+	}
+
+	// stops the listing when returning early
+	ctx, cancel := context.WithCancel(n.ctx)
+	defer cancel()
+
+	var lines []string
+	for _, opt := range []ipfs_options.PinLsOption{
+		ipfs_options.Pin.Ls.Recursive(),
+		ipfs_options.Pin.Ls.Direct(),
+	} {
+		pins, err := api.Pin().Ls(ctx, opt)
+		if err != nil {
+			return "", fmt.Errorf("unable to list pins: %w", err)
+		}
+
+		for pin := range pins {
+			if err := pin.Err(); err != nil {
+				return "", fmt.Errorf("unable to list pins: %w", err)
+			}
+			lines = append(lines, pin.Path().Cid().String()+" "+pin.Type())
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// IsPinned returns the pin status of the given cid, one of `recursive`,
+// `direct`, `indirect` or `not pinned`.
+func (n *Node) IsPinned(cid string) (string, error) {
+	c, err := ipfs_cid.Decode(cid)
+	if err != nil {
+		return "", fmt.Errorf("invalid cid `%s`: %w", cid, err)
+	}
+
+	return n.pinStatus(context.Background(), c)
+}
+
+func (n *Node) pinStatus(ctx context.Context, c ipfs_cid.Cid) (string, error) {
+	reason, pinned, err := n.ipfsMobile.IpfsNode.Pinning.IsPinned(ctx, c)
+	if err != nil {
+		return "", fmt.Errorf("unable to check pin status of `%s`: %w", c, err)
+	}
+
+	if !pinned {
+		return PinStatusNotPinned, nil
+	}
+
+	switch reason {
+	case PinStatusRecursive, PinStatusDirect:
+		return reason, nil
+	default:
+		// for indirect pins the pinner returns the recursive root
+		// pinning the block
+		return PinStatusIndirect, nil
+	}
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	ds_query "github.com/ipfs/go-datastore/query"
+	ipfs_path "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// pinLabelsKey is the datastore prefix under which the pin labels are stored,
+// pins themselves have no name.
+var pinLabelsKey = ds.NewKey("/gomobile/pins/labels")
+
+// NamedPin is an entry returned by ListNamedPins.
+type NamedPin struct {
+	Cid   string
+	Label string
+}
+
+// PinNamed recursively pins the given cid, fetching it if needed, and
+// associates a label with it. Pinning an already labeled cid replaces its
+// label.
+func (n *Node) PinNamed(cid, label string) error {
+	c, err := ipfs_cid.Decode(cid)
+	if err != nil {
+		return fmt.Errorf("invalid cid `%s`: %w", cid, err)
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return err
+	}
+
+	if err := api.Pin().Add(n.ctx, ipfs_path.IpfsPath(c)); err != nil {
+		return fmt.Errorf("unable to pin `%s`: %w", c, err)
+	}
+
+	dstore := n.ipfsMobile.IpfsNode.Repo.Datastore()
+	if err := dstore.Put(n.ctx, pinLabelsKey.ChildString(c.String()), []byte(label)); err != nil {
+		return fmt.Errorf("unable to store label of `%s`: %w", c, err)
+	}
+	return nil
+}
+
+// UnpinNamed removes the recursive pin of the given cid along with its label.
+func (n *Node) UnpinNamed(cid string) error {
+	c, err := ipfs_cid.Decode(cid)
+	if err != nil {
+		return fmt.Errorf("invalid cid `%s`: %w", cid, err)
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return err
+	}
+
+	if err := api.Pin().Rm(n.ctx, ipfs_path.IpfsPath(c)); err != nil {
+		return fmt.Errorf("unable to unpin `%s`: %w", c, err)
+	}
+
+	return n.removePinLabel(c)
+}
+
+func (n *Node) removePinLabel(c ipfs_cid.Cid) error {
+	dstore := n.ipfsMobile.IpfsNode.Repo.Datastore()
+	if err := dstore.Delete(n.ctx, pinLabelsKey.ChildString(c.String())); err != nil {
+		return fmt.Errorf("unable to remove label of `%s`: %w", c, err)
+	}
+	return nil
+}
+
+// ListNamedPins returns a JSON encoded list of NamedPin. Labels whose cid is
+// no longer pinned are skipped.
+func (n *Node) ListNamedPins() (string, error) {
+	dstore := n.ipfsMobile.IpfsNode.Repo.Datastore()
+	res, err := dstore.Query(n.ctx, ds_query.Query{Prefix: pinLabelsKey.String()})
+	if err != nil {
+		return "", fmt.Errorf("unable to list pin labels: %w", err)
+	}
+
+	entries, err := res.Rest()
+	if err != nil {
+		return "", fmt.Errorf("unable to list pin labels: %w", err)
+	}
+
+	pins := []NamedPin{}
+	for _, entry := range entries {
+		key := strings.TrimPrefix(entry.Key, pinLabelsKey.String()+"/")
+		c, err := ipfs_cid.Decode(key)
+		if err != nil {
+			continue
+		}
+
+		status, err := n.pinStatus(n.ctx, c)
+		if err != nil {
+			return "", err
+		}
+		if status == PinStatusNotPinned {
+			continue
+		}
+
+		pins = append(pins, NamedPin{Cid: c.String(), Label: string(entry.Value)})
+	}
+
+	sort.Slice(pins, func(i, j int) bool { return pins[i].Label < pins[j].Label })
+
+	out, err := json.Marshal(pins)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
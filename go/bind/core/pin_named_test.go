@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+	ipfs_options "github.com/ipfs/interface-go-ipfs-core/options"
+	ipfs_coreapi "github.com/ipfs/kubo/core/coreapi"
+)
+
+func TestNodeNamedPins(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	api, err := ipfs_coreapi.NewCoreAPI(node.ipfsMobile.IpfsNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := api.Unixfs().Add(context.Background(), ipfs_files.NewBytesFile([]byte("vacation photos")), ipfs_options.Unixfs.Pin(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cid := added.Cid().String()
+
+	if err := node.PinNamed(cid, "Vacation Photos"); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := node.IsPinned(cid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != PinStatusRecursive {
+		t.Errorf("expected pin status `%s` got `%s`", PinStatusRecursive, status)
+	}
+
+	listNamedPins := func() []NamedPin {
+		out, err := node.ListNamedPins()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var pins []NamedPin
+		if err := json.Unmarshal([]byte(out), &pins); err != nil {
+			t.Fatal(err)
+		}
+		return pins
+	}
+
+	pins := listNamedPins()
+	if len(pins) != 1 || pins[0].Cid != cid || pins[0].Label != "Vacation Photos" {
+		t.Errorf("unexpected named pins `%+v`", pins)
+	}
+
+	if err := node.UnpinNamed(cid); err != nil {
+		t.Fatal(err)
+	}
+
+	if pins := listNamedPins(); len(pins) != 0 {
+		t.Errorf("expected no named pins got `%+v`", pins)
+	}
+}
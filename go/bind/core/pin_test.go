@@ -0,0 +1,150 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+)
+
+func TestNodePin(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	recursive, err := node.AddBytes([]byte("pinned recursively"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	direct, err := node.AddBytes([]byte("pinned directly"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := node.Pin(recursive, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Pin("/ipfs/"+direct, false); err != nil {
+		t.Fatal(err)
+	}
+
+	pins, err := node.ListPins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(pins, "\n")
+	for _, expected := range []string{recursive + " recursive", direct + " direct"} {
+		found := false
+		for _, line := range lines {
+			found = found || line == expected
+		}
+		if !found {
+			t.Errorf("expected pin `%s` in `%s`", expected, pins)
+		}
+	}
+
+	if err := node.Unpin(recursive); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Unpin(direct); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := node.IsPinned(recursive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != PinStatusNotPinned {
+		t.Errorf("expected pin status `%s` got `%s`", PinStatusNotPinned, status)
+	}
+
+	if err := node.Unpin(recursive); !errors.Is(err, ErrNotPinned) {
+		t.Errorf("expected a not pinned error got `%v`", err)
+	}
+
+	// unpinning removes the label of a named pin
+	if err := node.PinNamed(recursive, "label"); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Unpin(recursive); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Pin(recursive, true); err != nil {
+		t.Fatal(err)
+	}
+
+	named, err := node.ListNamedPins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if named != "[]" {
+		t.Errorf("expected no named pin got `%s`", named)
+	}
+}
+
+func TestNodePinStatus(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	// larger than a chunk, so the root links to its leaves
+	parent, err := node.AddBytes(bytes.Repeat([]byte("a"), 1<<20), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	direct, err := node.AddBytes([]byte("pinned directly"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpinned, err := node.AddBytes([]byte("not pinned"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := node.Pin(parent, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Pin(direct, false); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := ipfs_cid.Decode(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd, err := node.ipfsMobile.IpfsNode.DAG.Get(context.Background(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nd.Links()) == 0 {
+		t.Fatalf("expected `%s` to have children", parent)
+	}
+	child := nd.Links()[0].Cid.String()
+
+	for cid, expected := range map[string]string{
+		parent:   PinStatusRecursive,
+		child:    PinStatusIndirect,
+		direct:   PinStatusDirect,
+		unpinned: PinStatusNotPinned,
+	} {
+		status, err := node.IsPinned(cid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != expected {
+			t.Errorf("expected pin status `%s` for `%s` got `%s`", expected, cid, status)
+		}
+	}
+
+	// an indirect pin can't be removed on its own
+	if err := node.Unpin(child); !errors.Is(err, ErrNotPinned) {
+		t.Errorf("expected a not pinned error got `%v`", err)
+	}
+}
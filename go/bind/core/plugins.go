@@ -0,0 +1,89 @@
+package core
+
+import (
+	"encoding/json"
+	"sort"
+
+	ipfs_plugin "github.com/ipfs/kubo/plugin"
+	pluginbadgerds "github.com/ipfs/kubo/plugin/plugins/badgerds"
+	pluginiplddagjose "github.com/ipfs/kubo/plugin/plugins/dagjose"
+	pluginflatfs "github.com/ipfs/kubo/plugin/plugins/flatfs"
+	pluginfxtest "github.com/ipfs/kubo/plugin/plugins/fxtest"
+	pluginipldgit "github.com/ipfs/kubo/plugin/plugins/git"
+	pluginlevelds "github.com/ipfs/kubo/plugin/plugins/levelds"
+	pluginpeerlog "github.com/ipfs/kubo/plugin/plugins/peerlog"
+)
+
+// PluginInfo describes a plugin loaded by the global plugin loader.
+type PluginInfo struct {
+	Name    string
+	Version string
+	Types   []string
+}
+
+// preloadedPlugins are the plugins the kubo plugin loader preloads, listed
+// like kubo's plugin/loader/preload.go does since the loader doesn't expose
+// the plugins it loaded.
+var preloadedPlugins = [][]ipfs_plugin.Plugin{
+	pluginipldgit.Plugins,
+	pluginiplddagjose.Plugins,
+	pluginbadgerds.Plugins,
+	pluginflatfs.Plugins,
+	pluginlevelds.Plugins,
+	pluginpeerlog.Plugins,
+	pluginfxtest.Plugins,
+}
+
+// ListPlugins returns a JSON encoded list of PluginInfo for the plugins
+// preloaded by the global plugin loader, the list is empty until a repo has
+// been opened. The plugins loaded dynamically from the plugins directory of
+// the repo are not listed.
+func ListPlugins() (string, error) {
+	muPlugins.Lock()
+	loaded := plugins != nil
+	muPlugins.Unlock()
+
+	infos := []PluginInfo{}
+	if loaded {
+		for _, pls := range preloadedPlugins {
+			for _, pl := range pls {
+				infos = append(infos, PluginInfo{
+					Name:    pl.Name(),
+					Version: pl.Version(),
+					Types:   pluginTypes(pl),
+				})
+			}
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	out, err := json.Marshal(infos)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func pluginTypes(pl ipfs_plugin.Plugin) []string {
+	types := []string{}
+	if _, ok := pl.(ipfs_plugin.PluginIPLD); ok {
+		types = append(types, "ipld")
+	}
+	if _, ok := pl.(ipfs_plugin.PluginTracer); ok {
+		types = append(types, "tracer")
+	}
+	if _, ok := pl.(ipfs_plugin.PluginDatastore); ok {
+		types = append(types, "datastore")
+	}
+	if _, ok := pl.(ipfs_plugin.PluginDaemon); ok {
+		types = append(types, "daemon")
+	}
+	if _, ok := pl.(ipfs_plugin.PluginDaemonInternal); ok {
+		types = append(types, "daemon-internal")
+	}
+	if _, ok := pl.(ipfs_plugin.PluginFx); ok {
+		types = append(types, "fx")
+	}
+	return types
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestListPlugins(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	_, clean = testingRepo(t, path)
+	defer clean()
+
+	out, err := ListPlugins()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var infos []PluginInfo
+	if err := json.Unmarshal([]byte(out), &infos); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, info := range infos {
+		if info.Name != "ds-flatfs" {
+			continue
+		}
+
+		if len(info.Types) != 1 || info.Types[0] != "datastore" {
+			t.Errorf("expected `ds-flatfs` to be a datastore plugin got `%v`", info.Types)
+		}
+		return
+	}
+
+	t.Errorf("`ds-flatfs` plugin not found in `%s`", out)
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	ipfs_merkledag "github.com/ipfs/go-merkledag"
+)
+
+// progressReportInterval is the interval at which the progress of background
+// requests is reported to their handler
+const progressReportInterval = 250 * time.Millisecond
+
+// Prefetch fetches the blocks of the given cid or path into the blockstore
+// without pinning them, the whole DAG is walked when recursive is set. Blocks
+// are written to the blockstore as they are fetched so the DAG is never held
+// in memory. It returns a handle that can be passed to CancelRequest, the
+// progress and the outcome are reported to handler.
+func (n *Node) Prefetch(cidOrPath string, recursive bool, handler ProgressHandler) (int64, error) {
+	p, err := parsePath(cidOrPath)
+	if err != nil {
+		return 0, err
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return 0, err
+	}
+
+	id, ctx, done := n.newRequest()
+	go func() {
+		defer done()
+
+		resolved, err := api.ResolvePath(ctx, p)
+		if err != nil {
+			handler.OnError(fmt.Sprintf("unable to resolve `%s`: %s", cidOrPath, err))
+			return
+		}
+
+		if err := n.fetchDAG(ctx, resolved.Cid(), recursive, handler); err != nil {
+			handler.OnError(err.Error())
+			return
+		}
+
+		handler.OnComplete()
+	}()
+
+	return id, nil
+}
+
+// PinAndFetch recursively pins the given cid or path once all its blocks are
+// stored locally, unlike a plain pin which only returns once the DAG is
+// fetched without reporting any progress. The garbage collector is blocked
+// while the DAG is fetched. It returns a handle that can be passed to
+// CancelRequest, as the pin is only added at the end, canceling never leaves
+// a pin on a partially fetched DAG.
+func (n *Node) PinAndFetch(cidOrPath string, handler ProgressHandler) (int64, error) {
+	p, err := parsePath(cidOrPath)
+	if err != nil {
+		return 0, err
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return 0, err
+	}
+
+	id, ctx, done := n.newRequest()
+	go func() {
+		defer done()
+
+		resolved, err := api.ResolvePath(ctx, p)
+		if err != nil {
+			handler.OnError(fmt.Sprintf("unable to resolve `%s`: %s", cidOrPath, err))
+			return
+		}
+
+		if err := n.pinAndFetch(ctx, resolved.Cid(), handler); err != nil {
+			handler.OnError(err.Error())
+			return
+		}
+
+		handler.OnComplete()
+	}()
+
+	return id, nil
+}
+
+func (n *Node) pinAndFetch(ctx context.Context, root ipfs_cid.Cid, handler ProgressHandler) error {
+	inode := n.ipfsMobile.IpfsNode
+
+	// keep the fetched blocks from being collected until they are pinned
+	unlocker := inode.Blockstore.PinLock(ctx)
+	defer unlocker.Unlock(ctx)
+
+	if err := n.fetchDAG(ctx, root, true, handler); err != nil {
+		return err
+	}
+
+	nd, err := inode.DAG.Get(ctx, root)
+	if err != nil {
+		return fmt.Errorf("unable to get `%s`: %w", root, err)
+	}
+
+	if err := inode.Pinning.Pin(ctx, nd, true); err != nil {
+		return fmt.Errorf("unable to pin `%s`: %w", root, err)
+	}
+	return inode.Pinning.Flush(ctx)
+}
+
+// fetchDAG fetches the root block, and all its descendants when recursive is
+// set, reporting the progress to handler.
+func (n *Node) fetchDAG(ctx context.Context, root ipfs_cid.Cid, recursive bool, handler ProgressHandler) error {
+	dag := n.ipfsMobile.IpfsNode.DAG
+
+	var blocks, bytes int64
+	report := func() {
+		handler.OnProgress(atomic.LoadInt64(&blocks), atomic.LoadInt64(&bytes))
+	}
+
+	// fetching a node through the dag service stores it in the blockstore
+	getLinks := func(ctx context.Context, c ipfs_cid.Cid) ([]*ipld.Link, error) {
+		nd, err := dag.Get(ctx, c)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch `%s`: %w", c, err)
+		}
+
+		atomic.AddInt64(&blocks, 1)
+		atomic.AddInt64(&bytes, int64(len(nd.RawData())))
+		return nd.Links(), nil
+	}
+
+	if !recursive {
+		if _, err := getLinks(ctx, root); err != nil {
+			return err
+		}
+		report()
+		return nil
+	}
+
+	walkDone := make(chan struct{})
+	reporterDone := make(chan struct{})
+	go func() {
+		defer close(reporterDone)
+
+		ticker := time.NewTicker(progressReportInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-walkDone:
+				return
+			case <-ticker.C:
+				report()
+			}
+		}
+	}()
+
+	visited := ipfs_cid.NewSet()
+	err := ipfs_merkledag.Walk(ctx, getLinks, root, visited.Visit, ipfs_merkledag.Concurrent())
+	close(walkDone)
+	<-reporterDone
+	if err != nil {
+		return err
+	}
+
+	report()
+	return nil
+}
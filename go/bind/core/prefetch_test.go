@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+	ipfs_options "github.com/ipfs/interface-go-ipfs-core/options"
+	ipfs_coreapi "github.com/ipfs/kubo/core/coreapi"
+)
+
+type testProgressHandler struct {
+	blocks   int64
+	done     chan struct{}
+	errorMsg string
+}
+
+func (h *testProgressHandler) OnProgress(blocks int64, _ int64) { h.blocks = blocks }
+func (h *testProgressHandler) OnComplete()                      { close(h.done) }
+func (h *testProgressHandler) OnError(message string) {
+	h.errorMsg = message
+	close(h.done)
+}
+
+func TestNodePrefetch(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	api, err := ipfs_coreapi.NewCoreAPI(node.ipfsMobile.IpfsNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := ipfs_files.NewMapDirectory(map[string]ipfs_files.Node{
+		"a.txt": ipfs_files.NewBytesFile([]byte("content a")),
+		"b.txt": ipfs_files.NewBytesFile([]byte("content b")),
+	})
+	root, err := api.Unixfs().Add(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &testProgressHandler{done: make(chan struct{})}
+	if _, err := node.Prefetch(root.String(), true, handler); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-handler.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for prefetch")
+	}
+
+	if handler.errorMsg != "" {
+		t.Fatal(handler.errorMsg)
+	}
+
+	// root directory and its two files
+	if handler.blocks != 3 {
+		t.Errorf("expected 3 blocks to be fetched got %d", handler.blocks)
+	}
+
+	if err := node.CancelRequest(42); err == nil {
+		t.Error("canceling an unknown request should fail")
+	}
+}
+
+func TestNodePinAndFetch(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	api, err := node.coreAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := ipfs_files.NewBytesFile([]byte("content"))
+	root, err := api.Unixfs().Add(context.Background(), file, ipfs_options.Unixfs.Pin(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &testProgressHandler{done: make(chan struct{})}
+	if _, err := node.PinAndFetch(root.String(), handler); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-handler.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for pin")
+	}
+
+	if handler.errorMsg != "" {
+		t.Fatal(handler.errorMsg)
+	}
+
+	_, pinned, err := api.Pin().IsPinned(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pinned {
+		t.Errorf("expected `%s` to be pinned", root)
+	}
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	ds_query "github.com/ipfs/go-datastore/query"
+	ipfs_options "github.com/ipfs/interface-go-ipfs-core/options"
+)
+
+// provideQueueKey is the datastore namespace of the kubo provider queue.
+var provideQueueKey = ds.NewKey("/provider-v1/queue")
+
+// ProvideQueueStats is returned by Node.ProvideQueueStats.
+type ProvideQueueStats struct {
+	// Queued is the number of cids waiting to be provided.
+	Queued int64
+	// Rate is the number of cids drained from the queue per second since
+	// the previous call, it is 0 on the first call.
+	Rate float64
+}
+
+type provideQueueSample struct {
+	mu     sync.Mutex
+	queued int64
+	at     time.Time
+}
+
+// ProvideQueueStats returns a JSON encoded ProvideQueueStats describing the
+// queue of cids waiting to be announced to the DHT. Zeros are returned when
+// the node doesn't provide to the DHT.
+func (n *Node) ProvideQueueStats() (string, error) {
+	var stats ProvideQueueStats
+
+	if n.ipfsMobile.IpfsNode.DHT != nil {
+		queued, err := n.provideQueueDepth()
+		if err != nil {
+			return "", err
+		}
+		stats.Queued = queued
+
+		now := time.Now()
+		sample := &n.provideQueueSample
+		sample.mu.Lock()
+		if !sample.at.IsZero() && queued < sample.queued {
+			stats.Rate = float64(sample.queued-queued) / now.Sub(sample.at).Seconds()
+		}
+		sample.queued, sample.at = queued, now
+		sample.mu.Unlock()
+	}
+
+	out, err := json.Marshal(&stats)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (n *Node) provideQueueDepth() (int64, error) {
+	dstore := n.ipfsMobile.IpfsNode.Repo.Datastore()
+	res, err := dstore.Query(n.ctx, ds_query.Query{
+		Prefix:   provideQueueKey.String(),
+		KeysOnly: true,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to query provide queue: %w", err)
+	}
+	defer res.Close()
+
+	var queued int64
+	for r := range res.Next() {
+		if r.Error != nil {
+			return 0, fmt.Errorf("unable to query provide queue: %w", r.Error)
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// errNoRouting is returned by the content routing methods in limited mode.
+var errNoRouting = errors.New("content routing is disabled, enable the dht or delegated routing")
+
+// defaultMaxProviders is the number of providers FindProviders looks for
+// when maxPeers is not positive.
+const defaultMaxProviders = 20
+
+// defaultFindProvidersTimeout bounds the FindProviders query when no timeout
+// is given, a dht walk may otherwise never end.
+const defaultFindProvidersTimeout = time.Minute
+
+// Provide announces to the content routing that the node provides the block
+// at cidPath, along with all its children when recursive is set, without
+// waiting for the next reprovide. The blocks must be stored locally. It fails
+// in limited mode, when the node runs without the DHT nor delegated routing.
+func (n *Node) Provide(cidPath string, recursive bool) error {
+	if n.noRouting {
+		return errNoRouting
+	}
+
+	p, err := parsePath(cidPath)
+	if err != nil {
+		return err
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return err
+	}
+
+	if err := api.Dht().Provide(n.ctx, p, ipfs_options.Dht.Recursive(recursive)); err != nil {
+		return fmt.Errorf("unable to provide `%s`: %w", cidPath, err)
+	}
+	return nil
+}
+
+// FindProviders returns a JSON encoded list of the ids of at most maxPeers
+// peers (20 when not positive) providing the block at cidPath. The query
+// stops after timeoutSeconds (one minute when not positive), the providers
+// found so far are then returned. It fails in limited mode.
+func (n *Node) FindProviders(cidPath string, maxPeers int, timeoutSeconds int64) (string, error) {
+	if n.noRouting {
+		return "", errNoRouting
+	}
+
+	p, err := parsePath(cidPath)
+	if err != nil {
+		return "", err
+	}
+
+	if maxPeers <= 0 {
+		maxPeers = defaultMaxProviders
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return "", err
+	}
+
+	timeout := defaultFindProvidersTimeout
+	if timeoutSeconds > 0 {
+		timeout = time.Duration(timeoutSeconds) * time.Second
+	}
+
+	// the dht query is canceled on timeout or when the node closes
+	ctx, cancel := context.WithTimeout(n.ctx, timeout)
+	defer cancel()
+
+	found, err := api.Dht().FindProviders(ctx, p, ipfs_options.Dht.NumProviders(maxPeers))
+	if err != nil {
+		return "", fmt.Errorf("unable to find providers of `%s`: %w", cidPath, err)
+	}
+
+	providers := []string{}
+	for ai := range found {
+		providers = append(providers, ai.ID.String())
+	}
+
+	out, err := json.Marshal(providers)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/libp2p/go-libp2p-kad-dht/providers"
+	p2p_host "github.com/libp2p/go-libp2p/core/host"
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// provideLimiter spaces out the provides of the node, a zero interval means
+// unlimited.
+type provideLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (l *provideLimiter) setRate(perSecond int) {
+	if perSecond == 0 {
+		l.setInterval(0)
+	} else {
+		l.setInterval(time.Second / time.Duration(perSecond))
+	}
+}
+
+func (l *provideLimiter) setInterval(interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.interval = interval
+	l.next = time.Time{}
+}
+
+func (l *provideLimiter) getInterval() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.interval
+}
+
+// wait blocks until the next provide is allowed or ctx is done.
+func (l *provideLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	if l.interval == 0 {
+		l.mu.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttledProviderStore is the provider store of the WAN DHT. The DHT adds
+// the node itself to its store before announcing a provider record to the
+// network, it is held there until the limiter allows it.
+type throttledProviderStore struct {
+	*providers.ProviderManager
+	self    p2p_peer.ID
+	limiter *provideLimiter
+}
+
+func newThrottledProviderStore(ctx context.Context, h p2p_host.Host, dstore ds.Batching, limiter *provideLimiter) (*throttledProviderStore, error) {
+	pm, err := providers.NewProviderManager(ctx, h.ID(), h.Peerstore(), dstore)
+	if err != nil {
+		return nil, err
+	}
+
+	return &throttledProviderStore{
+		ProviderManager: pm,
+		self:            h.ID(),
+		limiter:         limiter,
+	}, nil
+}
+
+func (s *throttledProviderStore) AddProvider(ctx context.Context, key []byte, prov p2p_peer.AddrInfo) error {
+	// records of other peers are stored right away
+	if prov.ID == s.self {
+		if err := s.limiter.wait(ctx); err != nil {
+			return err
+		}
+	}
+	return s.ProviderManager.AddProvider(ctx, key, prov)
+}
+
+// SetMaxProvideRate limits the number of provider records the node announces
+// to the DHT per second, so a large add doesn't trigger a burst of provides
+// draining battery and data. Provides over the limit are delayed, 0 means
+// unlimited (default). It applies to every provide, whether triggered by an
+// add, a pin, bitswap or reproviding, except with the accelerated DHT client.
+func (n *Node) SetMaxProvideRate(perSecond int) error {
+	if perSecond < 0 {
+		return fmt.Errorf("invalid provide rate %d", perSecond)
+	}
+
+	if n.ipfsMobile.IpfsNode.DHT == nil {
+		return errors.New("dht is disabled")
+	}
+
+	n.provideLimiter.setRate(perSecond)
+	return nil
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProvideLimiter(t *testing.T) {
+	var limiter provideLimiter
+	ctx := context.Background()
+
+	wait := func(n int) time.Duration {
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			if err := limiter.wait(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return time.Since(start)
+	}
+
+	// unlimited
+	if d := wait(100); d > 100*time.Millisecond {
+		t.Errorf("unlimited provides took %s", d)
+	}
+
+	limiter.setRate(20)
+	if d := wait(5); d < 150*time.Millisecond {
+		t.Errorf("5 provides at 20/s took only %s", d)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	limiter.setRate(1)
+	limiter.wait(canceled) // the first provide is never delayed
+	if err := limiter.wait(canceled); err == nil {
+		t.Error("expected an error with a canceled context")
+	}
+}
+
+func TestNodeSetMaxProvideRate(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	if err := node.SetMaxProvideRate(-1); err == nil {
+		t.Error("expected an error with a negative rate")
+	}
+
+	if err := node.SetMaxProvideRate(10); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := node.SetMaxProvideRate(0); err != nil {
+		t.Fatal(err)
+	}
+}
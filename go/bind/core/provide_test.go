@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+)
+
+func TestNodeProvideQueueStats(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	// the provider drains its queue right away, count a namespace it
+	// doesn't use
+	defer func(orig ds.Key) { provideQueueKey = orig }(provideQueueKey)
+	provideQueueKey = ds.NewKey("/test/provide-queue")
+
+	stats := func() ProvideQueueStats {
+		t.Helper()
+
+		out, err := node.ProvideQueueStats()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var stats ProvideQueueStats
+		if err := json.Unmarshal([]byte(out), &stats); err != nil {
+			t.Fatal(err)
+		}
+		return stats
+	}
+
+	ctx := context.Background()
+	dstore := node.ipfsMobile.IpfsNode.Repo.Datastore()
+	keys := []ds.Key{provideQueueKey.ChildString("1"), provideQueueKey.ChildString("2"), provideQueueKey.ChildString("3")}
+	for _, k := range keys {
+		if err := dstore.Put(ctx, k, []byte("cid")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if s := stats(); s.Queued != 3 || s.Rate != 0 {
+		t.Errorf("expected 3 queued cids and no rate on the first call got `%+v`", s)
+	}
+
+	for _, k := range keys[:2] {
+		if err := dstore.Delete(ctx, k); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if s := stats(); s.Queued != 1 || s.Rate <= 0 {
+		t.Errorf("expected 1 queued cid and a drain rate got `%+v`", s)
+	}
+}
+
+func TestNodeProvide(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	// a cid that isn't stored locally, unlike the empty directory created
+	// with the repo
+	const missing = "QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o"
+	if err := node.Provide(missing, false); err == nil {
+		t.Error("expected providing a missing block to fail")
+	}
+
+	start := time.Now()
+	out, err := node.FindProviders(missing, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the timeout to stop the query, took %s", elapsed)
+	}
+
+	var providers []string
+	if err := json.Unmarshal([]byte(out), &providers); err != nil {
+		t.Fatal(err)
+	}
+	if len(providers) != 0 {
+		t.Errorf("expected no provider got `%v`", providers)
+	}
+}
+
+func TestNodeProvideLimitedMode(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	config := NewNodeConfig()
+	config.SetEnableDHT(false)
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	c, err := node.AddBytes([]byte("provide"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := node.Provide(c, true); !errors.Is(err, errNoRouting) {
+		t.Errorf("expected provide to fail in limited mode got `%v`", err)
+	}
+	if _, err := node.FindProviders(c, 0, 1); !errors.Is(err, errNoRouting) {
+		t.Errorf("expected find providers to fail in limited mode got `%v`", err)
+	}
+}
@@ -4,6 +4,16 @@ import (
 	proximity "github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/proximitytransport"
 )
 
+// Multiaddr protocol of the Wi-Fi Direct (Android) and peer-to-peer Wi-Fi
+// (iOS) proximity drivers. Native drivers for these high-bandwidth local
+// channels should advertise them so both platforms can connect, and are
+// added with NodeConfig.AddProximityDriver alongside the BLE driver.
+const (
+	WifiDirectProtocolName = "wifi-direct"
+	WifiDirectProtocolCode = 0x0043
+	WifiDirectDefaultAddr  = "/wifi-direct/Qmeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+)
+
 type ProximityDriver interface {
 	proximity.ProximityDriver
 }
@@ -12,9 +22,15 @@ type ProximityTransport interface {
 	proximity.ProximityTransport
 }
 
-func GetProximityTransport(protocolName string) (t ProximityTransport) {
+// GetProximityTransport returns the running transport of the given protocol,
+// nil when it isn't listening.
+func GetProximityTransport(protocolName string) ProximityTransport {
 	proximity.TransportMapMutex.RLock()
-	t = proximity.TransportMap[protocolName]
+	t, ok := proximity.TransportMap[protocolName]
 	proximity.TransportMapMutex.RUnlock()
-	return
+	if !ok {
+		// avoid returning a nil pointer in a non nil interface
+		return nil
+	}
+	return t
 }
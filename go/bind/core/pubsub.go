@@ -0,0 +1,241 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	ipfs_iface "github.com/ipfs/interface-go-ipfs-core"
+	ipfs_options "github.com/ipfs/interface-go-ipfs-core/options"
+	p2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+var errPubSubDisabled = errors.New("pubsub is disabled")
+
+// defaultGossipSubParams are the gossipsub parameters of the library, the
+// package globals are updated by every NewNode.
+var defaultGossipSubParams = p2p_pubsub.DefaultGossipSubParams()
+
+// gossipSubParams are the gossipsub parameters set in the NodeConfig, a zero
+// value keeps the default.
+type gossipSubParams struct {
+	heartbeatInterval time.Duration
+	d, dlo, dhi       int
+	historyLength     int
+}
+
+// applyGossipSubParams sets the gossipsub parameters used by the router
+// constructed by the next node. The gossipsub library reads them from package
+// globals, so they are process wide.
+func applyGossipSubParams(params gossipSubParams) {
+	p2p_pubsub.GossipSubHeartbeatInterval = defaultGossipSubParams.HeartbeatInterval
+	if params.heartbeatInterval > 0 {
+		p2p_pubsub.GossipSubHeartbeatInterval = params.heartbeatInterval
+	}
+
+	p2p_pubsub.GossipSubD = defaultGossipSubParams.D
+	p2p_pubsub.GossipSubDlo = defaultGossipSubParams.Dlo
+	p2p_pubsub.GossipSubDhi = defaultGossipSubParams.Dhi
+	p2p_pubsub.GossipSubDscore = defaultGossipSubParams.Dscore
+	p2p_pubsub.GossipSubDout = defaultGossipSubParams.Dout
+	if params.d > 0 {
+		p2p_pubsub.GossipSubD = params.d
+		p2p_pubsub.GossipSubDlo = params.dlo
+		p2p_pubsub.GossipSubDhi = params.dhi
+
+		// keep the derived degrees valid for the router:
+		// Dscore <= Dhi, Dout < Dlo and Dout <= D/2
+		if p2p_pubsub.GossipSubDscore > params.dhi {
+			p2p_pubsub.GossipSubDscore = params.dhi
+		}
+		if p2p_pubsub.GossipSubDout >= params.dlo {
+			p2p_pubsub.GossipSubDout = params.dlo - 1
+		}
+		if p2p_pubsub.GossipSubDout > params.d/2 {
+			p2p_pubsub.GossipSubDout = params.d / 2
+		}
+	}
+
+	p2p_pubsub.GossipSubHistoryLength = defaultGossipSubParams.HistoryLength
+	p2p_pubsub.GossipSubHistoryGossip = defaultGossipSubParams.HistoryGossip
+	if params.historyLength > 0 {
+		p2p_pubsub.GossipSubHistoryLength = params.historyLength
+		// gossip can't cover more heartbeats than the cache holds
+		if p2p_pubsub.GossipSubHistoryGossip > params.historyLength {
+			p2p_pubsub.GossipSubHistoryGossip = params.historyLength
+		}
+	}
+}
+
+// PubSubPeerScore is the score of a peer of a pubsub topic.
+type PubSubPeerScore struct {
+	Peer  string
+	Score float64
+}
+
+// PubSubPeerScores is returned by Node.PubSubPeerScores.
+type PubSubPeerScores struct {
+	// Scoring reports whether gossipsub peer scoring is enabled, when it
+	// isn't every peer has a score of 0 and no peer gets pruned on its
+	// score.
+	Scoring bool
+	Peers   []PubSubPeerScore
+}
+
+// PubSubPeerScores returns a JSON encoded PubSubPeerScores for the peers of
+// the given topic. It fails if pubsub is disabled or doesn't use gossipsub.
+func (n *Node) PubSubPeerScores(topic string) (string, error) {
+	ps := n.ipfsMobile.IpfsNode.PubSub
+	if ps == nil {
+		return "", errPubSubDisabled
+	}
+
+	cfg, err := n.ipfsMobile.Repo.Config()
+	if err != nil {
+		return "", err
+	}
+
+	switch cfg.Pubsub.Router {
+	case "", "gossipsub":
+	default:
+		return "", fmt.Errorf("peer scores are not available with the `%s` pubsub router", cfg.Pubsub.Router)
+	}
+
+	// kubo doesn't enable gossipsub peer scoring
+	scores := PubSubPeerScores{Peers: []PubSubPeerScore{}}
+	for _, pid := range ps.ListPeers(topic) {
+		scores.Peers = append(scores.Peers, PubSubPeerScore{Peer: pid.String()})
+	}
+
+	out, err := json.Marshal(&scores)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (n *Node) pubSubAPI() (ipfs_iface.PubSubAPI, error) {
+	if n.ipfsMobile.IpfsNode.PubSub == nil {
+		return nil, errPubSubDisabled
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return nil, err
+	}
+	return api.PubSub(), nil
+}
+
+// PubSubTopics returns a JSON encoded list of the topics the node is
+// subscribed to.
+func (n *Node) PubSubTopics() (string, error) {
+	api, err := n.pubSubAPI()
+	if err != nil {
+		return "", err
+	}
+
+	topics, err := api.Ls(n.ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to list pubsub topics: %w", err)
+	}
+	if topics == nil {
+		topics = []string{}
+	}
+
+	out, err := json.Marshal(topics)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// PubSubPeers returns a JSON encoded list of the peer ids connected to the
+// node on the given topic, an empty topic returns the peers of every topic.
+func (n *Node) PubSubPeers(topic string) (string, error) {
+	api, err := n.pubSubAPI()
+	if err != nil {
+		return "", err
+	}
+
+	pids, err := api.Peers(n.ctx, ipfs_options.PubSub.Topic(topic))
+	if err != nil {
+		return "", fmt.Errorf("unable to list pubsub peers: %w", err)
+	}
+
+	out, err := json.Marshal(peerIDStrings(pids))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// PubSubHandler receives the messages of a topic subscribed with
+// PubSubSubscribe, it is called from the goroutine of the subscription.
+type PubSubHandler interface {
+	OnMessage(from string, data []byte)
+}
+
+// PubSubSubscription is a topic subscription returned by PubSubSubscribe.
+type PubSubSubscription struct {
+	id   string
+	stop func()
+}
+
+// ID returns the id of the subscription, listed by ActiveSubscriptions.
+func (s *PubSubSubscription) ID() string { return s.id }
+
+// Close stops the subscription, its handler goroutine returns after the
+// message being handled, if any. Closing it again is a no-op.
+func (s *PubSubSubscription) Close() error {
+	s.stop()
+	return nil
+}
+
+// PubSubPublish publishes data to the given topic.
+func (n *Node) PubSubPublish(topic string, data []byte) error {
+	api, err := n.pubSubAPI()
+	if err != nil {
+		return err
+	}
+
+	if err := api.Publish(n.ctx, topic, data); err != nil {
+		return fmt.Errorf("unable to publish to `%s`: %w", topic, err)
+	}
+	return nil
+}
+
+// PubSubSubscribe subscribes to the given topic, the messages are delivered
+// to handler until the subscription is closed, canceled with
+// CancelSubscription, or the node is closed. The messages published by the
+// node itself are delivered too. It fails if pubsub is disabled.
+func (n *Node) PubSubSubscribe(topic string, handler PubSubHandler) (*PubSubSubscription, error) {
+	api, err := n.pubSubAPI()
+	if err != nil {
+		return nil, err
+	}
+
+	id, ctx, done := n.newSubscription(SubscriptionKindPubSub)
+
+	sub, err := api.Subscribe(ctx, topic)
+	if err != nil {
+		done()
+		return nil, fmt.Errorf("unable to subscribe to `%s`: %w", topic, err)
+	}
+
+	go func() {
+		defer done()
+		defer sub.Close()
+
+		for {
+			msg, err := sub.Next(ctx)
+			if err != nil {
+				// canceled or closed
+				return
+			}
+			handler.OnMessage(msg.From().String(), msg.Data())
+		}
+	}()
+
+	return &PubSubSubscription{id: id, stop: done}, nil
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNodePubSubPeerScores(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	out, err := node.PubSubPeerScores("test-topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var scores PubSubPeerScores
+	if err := json.Unmarshal([]byte(out), &scores); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(scores.Peers) != 0 {
+		t.Errorf("expected no peers got `%+v`", scores.Peers)
+	}
+}
+
+func TestNodePubSubTopics(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	api, err := node.pubSubAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := api.Subscribe(node.ctx, "test-topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	out, err := node.PubSubTopics()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var topics []string
+	if err := json.Unmarshal([]byte(out), &topics); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(topics) != 1 || topics[0] != "test-topic" {
+		t.Errorf("expected `[test-topic]` got `%v`", topics)
+	}
+
+	out, err = node.PubSubPeers("test-topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var peers []string
+	if err := json.Unmarshal([]byte(out), &peers); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(peers) != 0 {
+		t.Errorf("expected no peers got `%v`", peers)
+	}
+}
+
+type testPubSubHandler chan []byte
+
+func (h testPubSubHandler) OnMessage(_ string, data []byte) { h <- data }
+
+func TestNodePubSubSubscribe(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	messages := make(testPubSubHandler, 1)
+	sub, err := node.PubSubSubscribe("test-topic", messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := node.ActiveSubscriptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, sub.ID()) {
+		t.Errorf("expected subscription `%s` in `%s`", sub.ID(), out)
+	}
+
+	if err := node.PubSubPublish("test-topic", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case data := <-messages:
+		if string(data) != "hello" {
+			t.Errorf("expected `hello` got `%s`", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Close(); err != nil {
+		t.Errorf("closing again should be a no-op: %s", err)
+	}
+
+	if out, err = node.ActiveSubscriptions(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, sub.ID()) {
+		t.Errorf("expected subscription `%s` to be gone from `%s`", sub.ID(), out)
+	}
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	p2p_host "github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+// ReachabilityUnknown is reported by ReachabilityHandler until AutoNAT
+// determines the reachability of the node.
+const ReachabilityUnknown = "unknown"
+
+// Connectivity states reported by ReachabilityHandler
+const (
+	ConnectivityConnected    = "connected"
+	ConnectivityDisconnected = "disconnected"
+)
+
+// ReachabilityHandler is notified of the changes of the reachability and
+// connectivity of the node, see NodeConfig.SetReachabilityHandler. It is
+// called from a dedicated goroutine, a slow handler only gets the latest
+// states.
+type ReachabilityHandler interface {
+	// OnReachabilityChanged receives `public`, `private` or `unknown`.
+	OnReachabilityChanged(reachability string)
+	// OnConnectivityChanged receives `connected` when the node gains its
+	// first peer and `disconnected` when it loses its last one.
+	OnConnectivityChanged(state string)
+}
+
+type reachabilityState struct {
+	reachability string
+	connectivity string
+}
+
+// reachabilityWatcher forwards the reachability and connectivity changes of
+// a host to a handler. The events of the event bus are folded into the latest
+// states, so the emitters are never blocked by the handler.
+type reachabilityWatcher struct {
+	host    p2p_host.Host
+	handler ReachabilityHandler
+
+	mu      sync.Mutex
+	state   reachabilityState
+	changed chan struct{}
+}
+
+// watchReachability notifies handler of the changes of h until ctx is done.
+func watchReachability(ctx context.Context, h p2p_host.Host, handler ReachabilityHandler) {
+	sub, err := h.EventBus().Subscribe([]interface{}{
+		new(event.EvtLocalReachabilityChanged),
+		new(event.EvtPeerConnectednessChanged),
+	})
+	if err != nil {
+		log.Printf("unable to watch the reachability: %s", err)
+		return
+	}
+
+	initial := reachabilityState{reachability: ReachabilityUnknown, connectivity: ConnectivityDisconnected}
+	w := &reachabilityWatcher{
+		host:    h,
+		handler: handler,
+		state:   initial,
+		changed: make(chan struct{}, 1),
+	}
+
+	// peers connected before the subscription
+	w.updateConnectivity()
+
+	go w.read(ctx, sub)
+	go w.deliver(ctx, initial)
+}
+
+func (w *reachabilityWatcher) read(ctx context.Context, sub event.Subscription) {
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-sub.Out():
+			if !ok {
+				return
+			}
+
+			switch evt := e.(type) {
+			case event.EvtLocalReachabilityChanged:
+				w.update(func(s *reachabilityState) {
+					s.reachability = strings.ToLower(evt.Reachability.String())
+				})
+			case event.EvtPeerConnectednessChanged:
+				w.updateConnectivity()
+			}
+		}
+	}
+}
+
+func (w *reachabilityWatcher) updateConnectivity() {
+	connectivity := ConnectivityDisconnected
+	for _, pid := range w.host.Network().Peers() {
+		if w.host.Network().Connectedness(pid) == network.Connected {
+			connectivity = ConnectivityConnected
+			break
+		}
+	}
+
+	w.update(func(s *reachabilityState) {
+		s.connectivity = connectivity
+	})
+}
+
+func (w *reachabilityWatcher) update(apply func(*reachabilityState)) {
+	w.mu.Lock()
+	apply(&w.state)
+	w.mu.Unlock()
+
+	select {
+	case w.changed <- struct{}{}:
+	default:
+	}
+}
+
+// deliver calls the handler with the states that differ from the last ones
+// delivered.
+func (w *reachabilityWatcher) deliver(ctx context.Context, delivered reachabilityState) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.changed:
+		}
+
+		w.mu.Lock()
+		state := w.state
+		w.mu.Unlock()
+
+		if state.reachability != delivered.reachability {
+			w.handler.OnReachabilityChanged(state.reachability)
+		}
+		if state.connectivity != delivered.connectivity {
+			w.handler.OnConnectivityChanged(state.connectivity)
+		}
+		delivered = state
+	}
+}
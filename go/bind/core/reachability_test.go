@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/network"
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/host/eventbus"
+)
+
+type testReachabilityHandler struct {
+	reachability chan string
+	connectivity chan string
+}
+
+func (h *testReachabilityHandler) OnReachabilityChanged(reachability string) {
+	h.reachability <- reachability
+}
+
+func (h *testReachabilityHandler) OnConnectivityChanged(state string) {
+	h.connectivity <- state
+}
+
+func TestNodeReachabilityHandler(t *testing.T) {
+	path1, clean := testingTempDir(t, "repo1")
+	defer clean()
+
+	repo, clean := testingRepo(t, path1)
+	defer clean()
+
+	// only the explicit connection below should connect the nodes
+	if err := repo.PatchConfig(`{"Discovery": {"MDNS": {"Enabled": false}}}`); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &testReachabilityHandler{
+		reachability: make(chan string, 8),
+		connectivity: make(chan string, 8),
+	}
+
+	config := NewNodeConfig()
+	config.SetReachabilityHandler(handler)
+
+	node1, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node1.Close()
+
+	path2, clean := testingTempDir(t, "repo2")
+	defer clean()
+
+	node2, clean := testingNode(t, path2)
+	defer clean()
+
+	h1, h2 := node1.ipfsMobile.PeerHost(), node2.ipfsMobile.PeerHost()
+	if err := h2.Connect(context.Background(), p2p_peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case state := <-handler.connectivity:
+		if state != ConnectivityConnected {
+			t.Errorf("expected `%s` got `%s`", ConnectivityConnected, state)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the connectivity change")
+	}
+
+	em, err := h1.EventBus().Emitter(new(event.EvtLocalReachabilityChanged), eventbus.Stateful)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer em.Close()
+
+	if err := em.Emit(event.EvtLocalReachabilityChanged{Reachability: network.ReachabilityPrivate}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case reachability := <-handler.reachability:
+		if reachability != ReachabilityPrivate {
+			t.Errorf("expected `%s` got `%s`", ReachabilityPrivate, reachability)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the reachability change")
+	}
+
+	if err := h1.Network().ClosePeer(h2.ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case state := <-handler.connectivity:
+		if state != ConnectivityDisconnected {
+			t.Errorf("expected `%s` got `%s`", ConnectivityDisconnected, state)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the connectivity change")
+	}
+}
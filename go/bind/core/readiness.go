@@ -0,0 +1,73 @@
+package core
+
+import (
+	"log"
+	"sync/atomic"
+
+	ipfs_core "github.com/ipfs/kubo/core"
+	ipfs_bs "github.com/ipfs/kubo/core/bootstrap"
+)
+
+// Readiness states returned by ReadinessState
+const (
+	ReadinessInitializing  = "initializing"
+	ReadinessBootstrapping = "bootstrapping"
+	ReadinessOnline        = "online"
+	ReadinessDegraded      = "degraded"
+	ReadinessClosed        = "closed"
+)
+
+const (
+	phaseInitializing int32 = iota
+	phaseBootstrapping
+	phaseReady
+	phaseBootstrapFailed
+	phaseClosed
+)
+
+// bootstrapNode starts the bootstrap of the ipfs node, it returns once the
+// first round is done.
+var bootstrapNode = (*ipfs_core.IpfsNode).Bootstrap
+
+func (n *Node) setPhase(phase int32) {
+	atomic.StoreInt32(&n.phase, phase)
+}
+
+// bootstrap runs the bootstrap of the node started by NewNode, the node is
+// then ready, or degraded when the bootstrap failed.
+func (n *Node) bootstrap(cfg ipfs_bs.BootstrapConfig) {
+	defer close(n.bootstrapDone)
+
+	phase := phaseReady
+	if err := bootstrapNode(n.ipfsMobile.IpfsNode, cfg); err != nil {
+		log.Printf("failed to bootstrap node: `%s`", err)
+		phase = phaseBootstrapFailed
+	}
+
+	// the node may have been closed meanwhile
+	atomic.CompareAndSwapInt32(&n.phase, phaseBootstrapping, phase)
+}
+
+// ReadinessState returns the current lifecycle phase of the node, one of
+// `initializing`, `bootstrapping` (the first bootstrap round is running in
+// the background), `online`, `degraded` (no connected peers, or the
+// bootstrap failed) or `closed`. It is safe to call at any time, including
+// after Close.
+func (n *Node) ReadinessState() string {
+	switch atomic.LoadInt32(&n.phase) {
+	case phaseInitializing:
+		return ReadinessInitializing
+	case phaseBootstrapping:
+		return ReadinessBootstrapping
+	case phaseBootstrapFailed:
+		return ReadinessDegraded
+	case phaseClosed:
+		return ReadinessClosed
+	}
+
+	if len(n.ipfsMobile.PeerHost().Network().Peers()) == 0 {
+		return ReadinessDegraded
+	}
+
+	return ReadinessOnline
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	p2p_peer "github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// relayFallbackInterval is the interval at which the fallback relay
+// candidates are refreshed while AutoRelay waits for candidates
+const relayFallbackInterval = time.Minute
+
+// relaySource is the AutoRelay peer source of the node, it feeds the
+// candidates set with SetRelayCandidates first, then the peering peers and
+// the peers of the DHT like the default kubo source.
+type relaySource struct {
+	mu         sync.Mutex
+	candidates []p2p_peer.AddrInfo
+	updated    chan struct{} // closed when the candidates change
+	fallback   func() []p2p_peer.AddrInfo
+}
+
+func newRelaySource() *relaySource {
+	return &relaySource{updated: make(chan struct{})}
+}
+
+func (s *relaySource) setCandidates(candidates []p2p_peer.AddrInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.candidates = candidates
+	close(s.updated)
+	s.updated = make(chan struct{})
+}
+
+func (s *relaySource) setFallback(fallback func() []p2p_peer.AddrInfo) {
+	s.mu.Lock()
+	s.fallback = fallback
+	s.mu.Unlock()
+}
+
+func (s *relaySource) snapshot() ([]p2p_peer.AddrInfo, <-chan struct{}) {
+	s.mu.Lock()
+	peers := append([]p2p_peer.AddrInfo{}, s.candidates...)
+	fallback, updated := s.fallback, s.updated
+	s.mu.Unlock()
+
+	if fallback != nil {
+		peers = append(peers, fallback()...)
+	}
+	return peers, updated
+}
+
+// peers implements the autorelay peer source, it sends up to numPeers
+// distinct candidates, waiting for new ones until ctx is done.
+func (s *relaySource) peers(ctx context.Context, numPeers int) <-chan p2p_peer.AddrInfo {
+	out := make(chan p2p_peer.AddrInfo)
+	go func() {
+		defer close(out)
+
+		sent := make(map[p2p_peer.ID]struct{})
+		for numPeers > 0 {
+			peers, updated := s.snapshot()
+			for _, pi := range peers {
+				if _, ok := sent[pi.ID]; ok || len(pi.Addrs) == 0 {
+					continue
+				}
+
+				select {
+				case out <- pi:
+				case <-ctx.Done():
+					return
+				}
+
+				sent[pi.ID] = struct{}{}
+				if numPeers--; numPeers == 0 {
+					return
+				}
+			}
+
+			select {
+			case <-updated:
+			case <-time.After(relayFallbackInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// relayFallback returns the peering peers and the peers of the WAN DHT
+// routing table, the candidates of the default kubo AutoRelay source.
+func (n *Node) relayFallback(peering []p2p_peer.AddrInfo) []p2p_peer.AddrInfo {
+	peers := append([]p2p_peer.AddrInfo{}, peering...)
+
+	dht := n.ipfsMobile.IpfsNode.DHT
+	if dht == nil || dht.WAN == nil {
+		return peers
+	}
+
+	pstore := n.ipfsMobile.PeerHost().Peerstore()
+	for _, pid := range dht.WAN.RoutingTable().ListPeers() {
+		peers = append(peers, pstore.PeerInfo(pid))
+	}
+	return peers
+}
+
+// SetRelayCandidates sets the addresses of the relays AutoRelay tries first,
+// a newline or comma separated list replacing the previous candidates. Each
+// address must be a full peer address like
+// `/ip4/1.2.3.4/tcp/4001/p2p/<peer id>`, not going itself through a relay. It
+// fails when the relay client is disabled or uses static relays.
+func (n *Node) SetRelayCandidates(multiaddrs string) error {
+	if n.relays == nil {
+		return errors.New("autorelay is disabled or uses static relays")
+	}
+
+	addrs := splitList(multiaddrs)
+	maddrs := make([]ma.Multiaddr, len(addrs))
+	for i, addr := range addrs {
+		maddr, err := ma.NewMultiaddr(addr)
+		if err != nil {
+			return fmt.Errorf("invalid relay address `%s`: %w", addr, err)
+		}
+
+		if isRelayedAddr(maddr) {
+			return fmt.Errorf("invalid relay address `%s`: relays can't be reached through another relay", addr)
+		}
+
+		if _, err := p2p_peer.AddrInfoFromP2pAddr(maddr); err != nil {
+			return fmt.Errorf("invalid relay address `%s`: %w", addr, err)
+		}
+		maddrs[i] = maddr
+	}
+
+	// addresses of a same relay are grouped
+	candidates, err := p2p_peer.AddrInfosFromP2pAddrs(maddrs...)
+	if err != nil {
+		return err
+	}
+	for _, pi := range candidates {
+		if len(pi.Addrs) == 0 {
+			return fmt.Errorf("relay `%s` has no transport address", pi.ID)
+		}
+	}
+
+	n.relays.setCandidates(candidates)
+	return nil
+}
+
+// Relays returns a JSON encoded list of the peer ids of the relays the node
+// currently holds a reservation on, through which it is reachable.
+func (n *Node) Relays() (string, error) {
+	relays := []string{}
+	seen := make(map[p2p_peer.ID]struct{})
+
+	for _, addr := range n.ipfsMobile.PeerHost().Addrs() {
+		if !isRelayedAddr(addr) {
+			continue
+		}
+
+		// the relay is the last peer before the circuit
+		relay, _ := ma.SplitFunc(addr, func(c ma.Component) bool {
+			return c.Protocol().Code == ma.P_CIRCUIT
+		})
+		pi, err := p2p_peer.AddrInfoFromP2pAddr(relay)
+		if err != nil {
+			continue
+		}
+
+		if _, ok := seen[pi.ID]; !ok {
+			seen[pi.ID] = struct{}{}
+			relays = append(relays, pi.ID.String())
+		}
+	}
+
+	out, err := json.Marshal(relays)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
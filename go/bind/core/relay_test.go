@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	ipfs_config "github.com/ipfs/kubo/config"
+)
+
+const testRelayPeer = "12D3KooWDpJ7As7BWAwRMfu1VU2WCqNjvq387JEYKDBj4kx6nXTN"
+
+func TestNodeSetRelayCandidates(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	invalid := []string{
+		"not an address",
+		"/p2p/" + testRelayPeer,
+		"/ip4/1.2.3.4/tcp/4001",
+		"/ip4/1.2.3.4/tcp/4001/p2p/" + testRelayPeer + "/p2p-circuit/p2p/" + testRelayPeer,
+	}
+	for _, addr := range invalid {
+		if err := node.SetRelayCandidates(addr); err == nil {
+			t.Errorf("expected `%s` to be refused", addr)
+		}
+	}
+
+	// the addresses of a same relay make a single candidate
+	candidates := "/ip4/1.2.3.4/tcp/4001/p2p/" + testRelayPeer + ",\n/ip4/1.2.3.4/udp/4001/quic/p2p/" + testRelayPeer
+	if err := node.SetRelayCandidates(candidates); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	select {
+	case pi := <-node.relays.peers(ctx, 1):
+		if pi.ID.String() != testRelayPeer || len(pi.Addrs) != 2 {
+			t.Errorf("expected candidate `%s` with 2 addresses got `%s`", testRelayPeer, pi)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the relay candidate")
+	}
+
+	out, err := node.Relays()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var relays []string
+	if err := json.Unmarshal([]byte(out), &relays); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNodeConfigRelayOverrides(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	config := NewNodeConfig()
+	config.SetEnableRelayClient(false)
+	config.SetEnableRelayService(false)
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	if node.relays != nil {
+		t.Error("expected AutoRelay to be disabled with the relay client")
+	}
+
+	// the overrides only apply to the node
+	cfg, err := repo.mr.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Swarm.RelayClient.Enabled != ipfs_config.Default || cfg.Swarm.RelayService.Enabled != ipfs_config.Default {
+		t.Error("expected the repo config to be restored")
+	}
+}
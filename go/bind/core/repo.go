@@ -14,27 +14,67 @@ package core
 
 import (
 	// 标准库导入
+	"bytes"         // 字节缓冲
+	"context"       // 上下文控制
+	"encoding/json" // JSON解析
+	"errors"        // 错误创建
+	"fmt"           // 格式化错误信息
+	"io/ioutil"     // 丢弃密钥生成输出
+	"os"            // 文件操作
 	"path/filepath" // 处理文件路径
 	"sync"          // 提供同步原语，如互斥锁
+	"sync/atomic"   // 原子操作
+	"time"          // 超时控制
 
 	// 项目内部包
 	ipfs_mobile "github.com/ipfs-shipyard/gomobile-ipfs/go/pkg/ipfsmobile" // 移动平台IPFS实现
 
+	humanize "github.com/dustin/go-humanize" // 解析存储大小
+
+	ds "github.com/ipfs/go-datastore"        // 数据存储接口
+	dsq "github.com/ipfs/go-datastore/query" // 数据存储查询
+
 	// IPFS核心包
-	ipfs_loader "github.com/ipfs/kubo/plugin/loader" // IPFS插件加载器
-	ipfs_repo "github.com/ipfs/kubo/repo"            // IPFS仓库接口
-	ipfs_fsrepo "github.com/ipfs/kubo/repo/fsrepo"   // 基于文件系统的IPFS仓库实现
+	ipfs_config "github.com/ipfs/kubo/config"                     // IPFS配置
+	ipfs_serialize "github.com/ipfs/kubo/config/serialize"        // 配置文件读写
+	ipfs_loader "github.com/ipfs/kubo/plugin/loader"              // IPFS插件加载器
+	ipfs_repo "github.com/ipfs/kubo/repo"                         // IPFS仓库接口
+	ipfs_common "github.com/ipfs/kubo/repo/common"                // 配置map的点分路径读写
+	ipfs_fsrepo "github.com/ipfs/kubo/repo/fsrepo"                // 基于文件系统的IPFS仓库实现
+	ipfs_migrations "github.com/ipfs/kubo/repo/fsrepo/migrations" // 仓库版本迁移
 )
 
 var (
 	// 全局变量，用于插件管理
 	muPlugins sync.Mutex                // 保护plugins变量的互斥锁
 	plugins   *ipfs_loader.PluginLoader // 全局插件加载器实例
+
+	pluginsLoading *pluginsLoad // 正在进行的插件加载，超时后仍会继续
 )
 
+// defaultPluginLoadTimeout 插件加载的默认超时时间
+const defaultPluginLoadTimeout = 30 * time.Second
+
+// initPlugins 初始化并注入插件：初始化会查找和加载所有可用插件的元数据，注入将插件集成到IPFS系统中
+var initPlugins = func(lp *ipfs_loader.PluginLoader) error {
+	if err := lp.Initialize(); err != nil {
+		return err
+	}
+	return lp.Inject()
+}
+
+// pluginsLoad 表示一次插件加载过程
+type pluginsLoad struct {
+	lp   *ipfs_loader.PluginLoader // 插件加载器
+	err  error                     // 加载结果，done关闭后有效
+	done chan struct{}             // 加载完成时关闭
+}
+
 // Repo 结构体包装了移动平台的IPFS仓库
 type Repo struct {
 	mr *ipfs_mobile.RepoMobile // 指向移动平台IPFS仓库的指针
+
+	nodeRunning int32 // 使用该仓库运行中的节点数（原子访问）
 }
 
 // RepoIsInitialized 检查指定路径的IPFS仓库是否已初始化
@@ -43,10 +83,21 @@ func RepoIsInitialized(path string) bool {
 	return ipfs_fsrepo.IsInitialized(path)
 }
 
+// RepoIsLocked 检查指定路径的仓库当前是否被打开（由本进程或其他进程持有锁）
+// 锁基于文件锁，持有者退出后自动释放，因此残留的锁文件不会被视为已锁定
+// 可用于前台和后台进程之间协调仓库的使用
+func RepoIsLocked(path string) (bool, error) {
+	locked, err := ipfs_fsrepo.LockedByOtherProcess(path)
+	if err != nil {
+		return false, fmt.Errorf("unable to check the repo lock: %w", err)
+	}
+	return locked, nil
+}
+
 // InitRepo 在指定路径初始化IPFS仓库
 func InitRepo(path string, cfg *Config) error {
 	// 加载插件，确保初始化仓库前插件系统已就绪
-	if _, err := loadPlugins(path); err != nil {
+	if _, err := loadPlugins(path, defaultPluginLoadTimeout); err != nil {
 		return err
 	}
 
@@ -54,13 +105,110 @@ func InitRepo(path string, cfg *Config) error {
 	return ipfs_fsrepo.Init(path, cfg.getConfig())
 }
 
-// OpenRepo 打开现有的IPFS仓库
+// ResetRepo 将指定路径的仓库重置为干净状态，清除数据存储和固定（pin）
+// keepIdentity为true时保留配置（包括身份密钥）和keystore，节点的Peer ID不变
+// 否则使用新生成的默认配置和身份重新初始化仓库
+// 私有网络密钥swarm.key和plugins目录始终保留，重置后节点仍留在原来的网络中
+// 调用前必须关闭使用该仓库的节点和仓库
+func ResetRepo(path string, keepIdentity bool) error {
+	// 校验仓库已初始化
+	if !RepoIsInitialized(path) {
+		return fmt.Errorf("`%s` is not an initialized repo", path)
+	}
+
+	// 仓库被打开时持有锁，不能重置
+	locked, err := RepoIsLocked(path)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return errors.New("unable to reset the repo while it is open, close the node and the repo first")
+	}
+
+	// 准备重新初始化使用的配置
+	var cfg *ipfs_config.Config
+	if keepIdentity {
+		filename, err := ipfs_config.Filename(path, "")
+		if err != nil {
+			return err
+		}
+		if cfg, err = ipfs_serialize.Load(filename); err != nil {
+			return fmt.Errorf("unable to read the repo config: %w", err)
+		}
+	} else {
+		if cfg, err = initConfig(ioutil.Discard, 2048); err != nil {
+			return err
+		}
+	}
+
+	// 删除仓库内容，保留swarm.key和plugins，保留身份时不删除keystore
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		switch name := entry.Name(); {
+		case name == "swarm.key" || name == "plugins":
+			continue
+		case keepIdentity && name == "keystore":
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(path, entry.Name())); err != nil {
+			return fmt.Errorf("unable to remove `%s`: %w", entry.Name(), err)
+		}
+	}
+
+	// 使用准备好的配置重新初始化仓库
+	return InitRepo(path, &Config{cfg})
+}
+
+// ErrRepoNeedsMigration 表示仓库版本低于当前kubo支持的版本，需要迁移
+// OpenRepo返回的*RepoMigrationError包装该错误
+var ErrRepoNeedsMigration = errors.New("repo needs migration")
+
+// RepoMigrationError 记录需要迁移的仓库版本From和目标版本To
+type RepoMigrationError struct {
+	From int
+	To   int
+}
+
+func (e *RepoMigrationError) Error() string {
+	return fmt.Sprintf("%s from version %d to %d", ErrRepoNeedsMigration, e.From, e.To)
+}
+
+func (e *RepoMigrationError) Unwrap() error {
+	return ErrRepoNeedsMigration
+}
+
+// OpenRepo 打开现有的IPFS仓库，仓库需要迁移时返回*RepoMigrationError
 func OpenRepo(path string) (*Repo, error) {
+	return OpenRepoWithMigration(path, false)
+}
+
+// OpenRepoWithMigration 打开现有的IPFS仓库，仓库版本低于当前kubo支持的版本时：
+// allowMigrate为true则先运行迁移，否则返回*RepoMigrationError，以便应用提示用户
+// 迁移与`ipfs daemon --migrate`相同：根据仓库配置的Migration.DownloadSources下载
+// 迁移程序并执行，iOS和较新的Android不允许执行下载的程序，此时迁移失败并返回错误
+func OpenRepoWithMigration(path string, allowMigrate bool) (*Repo, error) {
 	// 加载插件，确保打开仓库前插件系统已就绪
-	if _, err := loadPlugins(path); err != nil {
+	if _, err := loadPlugins(path, defaultPluginLoadTimeout); err != nil {
 		return nil, err
 	}
 
+	// 检查仓库版本
+	version, err := ipfs_migrations.RepoVersion(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the repo version: %w", err)
+	}
+	if version < ipfs_fsrepo.RepoVersion {
+		if !allowMigrate {
+			return nil, &RepoMigrationError{From: version, To: ipfs_fsrepo.RepoVersion}
+		}
+		if err := migrateRepo(path); err != nil {
+			return nil, err
+		}
+	}
+
 	// 打开标准IPFS仓库
 	irepo, err := ipfs_fsrepo.Open(path)
 	if err != nil {
@@ -69,7 +217,27 @@ func OpenRepo(path string) (*Repo, error) {
 
 	// 创建移动平台适用的仓库包装
 	mRepo := ipfs_mobile.NewRepoMobile(path, irepo)
-	return &Repo{mRepo}, nil
+	return &Repo{mr: mRepo}, nil
+}
+
+// migrateRepo 将仓库迁移到当前kubo支持的版本
+func migrateRepo(path string) error {
+	cfg, err := ipfs_migrations.ReadMigrationConfig(path, "")
+	if err != nil {
+		return fmt.Errorf("unable to read the migration config: %w", err)
+	}
+
+	// 移动端不运行用于下载的临时IPFS节点，只使用HTTP来源
+	fetcher, err := ipfs_migrations.GetMigrationFetcher(cfg.DownloadSources, "", nil)
+	if err != nil {
+		return fmt.Errorf("unable to fetch migrations: %w", err)
+	}
+	defer fetcher.Close()
+
+	if err := ipfs_migrations.RunMigration(context.Background(), fetcher, ipfs_fsrepo.RepoVersion, path, false); err != nil {
+		return fmt.Errorf("unable to migrate the repo: %w", err)
+	}
+	return nil
 }
 
 // GetRootPath 返回仓库的根路径
@@ -94,6 +262,251 @@ func (r *Repo) GetConfig() (*Config, error) {
 	return &Config{cfg}, nil
 }
 
+// 连接管理器类型
+const (
+	ConnMgrTypeBasic = "basic" // 根据水位线自动裁剪连接
+	ConnMgrTypeNone  = "none"  // 不裁剪任何连接
+)
+
+// SetConnMgrType 设置连接管理器类型（Swarm.ConnMgr.Type），可选basic或none
+// 新的类型在下一次创建节点时生效
+func (r *Repo) SetConnMgrType(kind string) error {
+	// 校验类型字符串
+	switch kind {
+	case ConnMgrTypeBasic, ConnMgrTypeNone:
+	default:
+		return fmt.Errorf("invalid connection manager type `%s`, expected `%s` or `%s`",
+			kind, ConnMgrTypeBasic, ConnMgrTypeNone)
+	}
+
+	// 通过配置补丁持久化
+	return r.mr.ApplyPatchs(func(cfg *ipfs_config.Config) error {
+		cfg.Swarm.ConnMgr.Type = kind
+		return nil
+	})
+}
+
+// PatchConfig 将部分配置（JSON对象）深度合并到仓库配置中
+// 合并规则：标量和数组直接替换，对象则递归合并
+// 所有修改通过一次ApplyPatchs原子地写入
+func (r *Repo) PatchConfig(patchJSON string) error {
+	// 解析补丁
+	var patch map[string]interface{}
+	if err := json.Unmarshal([]byte(patchJSON), &patch); err != nil {
+		return fmt.Errorf("invalid config patch: %w", err)
+	}
+
+	return r.mr.ApplyPatchs(func(cfg *ipfs_config.Config) error {
+		// 将配置转换为map以便合并
+		mapcfg, err := ipfs_config.ToMap(cfg)
+		if err != nil {
+			return err
+		}
+
+		mergeConfigMap(mapcfg, patch)
+
+		// 转换回配置结构，同时校验合并结果
+		newcfg, err := ipfs_config.FromMap(mapcfg)
+		if err != nil {
+			return fmt.Errorf("unable to apply config patch: %w", err)
+		}
+
+		*cfg = *newcfg
+		return nil
+	})
+}
+
+// mergeConfigMap 将patch递归合并到dst中
+func mergeConfigMap(dst, patch map[string]interface{}) {
+	for key, value := range patch {
+		// 两边都是对象时递归合并
+		if patchObj, ok := value.(map[string]interface{}); ok {
+			if dstObj, ok := dst[key].(map[string]interface{}); ok {
+				mergeConfigMap(dstObj, patchObj)
+				continue
+			}
+		}
+
+		// 标量、数组或新键直接替换
+		dst[key] = value
+	}
+}
+
+// SetConfigKey 将配置中点分路径key（如`Swarm.ConnMgr.HighWater`）的值设为jsonValue
+// 语义与`ipfs config --json <key> <value>`相同，不存在的中间对象会被创建
+// 路径不属于配置结构或值的类型不匹配时返回错误，仓库配置保持不变
+func (r *Repo) SetConfigKey(key string, jsonValue string) error {
+	if key == "" {
+		return errors.New("empty config key")
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(jsonValue), &value); err != nil {
+		return fmt.Errorf("invalid value for `%s`: %w", key, err)
+	}
+
+	return r.mr.ApplyPatchs(func(cfg *ipfs_config.Config) error {
+		mapcfg, err := ipfs_config.ToMap(cfg)
+		if err != nil {
+			return err
+		}
+
+		if err := ipfs_common.MapSetKV(mapcfg, key, value); err != nil {
+			return fmt.Errorf("invalid config key `%s`: %w", key, err)
+		}
+
+		newcfg, err := configFromMapStrict(mapcfg)
+		if err != nil {
+			return fmt.Errorf("unable to set `%s`: %w", key, err)
+		}
+
+		*cfg = *newcfg
+		return nil
+	})
+}
+
+// GetConfigKey 返回配置中点分路径key（如`Swarm.ConnMgr.HighWater`）的JSON编码值
+// 语义与`ipfs config <key>`相同
+func (r *Repo) GetConfigKey(key string) (string, error) {
+	if key == "" {
+		return "", errors.New("empty config key")
+	}
+
+	cfg, err := r.mr.Config()
+	if err != nil {
+		return "", err
+	}
+
+	mapcfg, err := ipfs_config.ToMap(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := ipfs_common.MapGetKV(mapcfg, key)
+	if err != nil {
+		return "", fmt.Errorf("invalid config key `%s`: %w", key, err)
+	}
+
+	out, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// configFromMapStrict 与ipfs_config.FromMap相同，但拒绝配置结构中不存在的键
+// 否则拼错的键会被静默忽略
+func configFromMapStrict(mapcfg map[string]interface{}) (*ipfs_config.Config, error) {
+	buf, err := json.Marshal(mapcfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.DisallowUnknownFields()
+
+	var cfg ipfs_config.Config
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Compact 压缩仓库的数据存储以回收磁盘空间
+// 对于badger数据存储会运行value log GC，清理大量写入和删除后留下的碎片
+// flatfs和leveldb没有可回收的内容，不做任何操作
+// 调用前必须关闭使用该仓库的节点
+func (r *Repo) Compact() error {
+	if atomic.LoadInt32(&r.nodeRunning) > 0 {
+		return errors.New("unable to compact the repo while a node is running, close the node first")
+	}
+
+	// 关闭节点时仓库也会被关闭，这里重新打开仓库
+	// 如果仓库仍处于打开状态，fsrepo会返回同一个实例
+	repo, err := ipfs_fsrepo.Open(r.mr.Path)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	// fsrepo的数据存储总是measure包装，它经mount将CollectGarbage转发给各个数据存储，
+	// 不支持的数据存储直接跳过
+	gcds, ok := repo.Datastore().(ds.GCDatastore)
+	if !ok {
+		return nil
+	}
+
+	if err := gcds.CollectGarbage(context.Background()); err != nil {
+		return fmt.Errorf("unable to compact datastore: %w", err)
+	}
+	return nil
+}
+
+// RepoStat 是Stat返回的仓库统计信息
+type RepoStat struct {
+	// RepoSize 仓库占用的磁盘空间（字节），withSize为false时为0
+	RepoSize int64
+	// StorageMax 配置的存储上限（Datastore.StorageMax，字节），0表示不限制
+	StorageMax int64
+	// NumObjects 块存储中的块数量
+	NumObjects int64
+	RepoPath   string
+}
+
+// Stat 返回JSON编码的RepoStat，与`ipfs repo stat`相同但不需要运行节点
+// 统计磁盘占用需要遍历整个数据存储，大仓库上很慢，withSize为false时跳过，只统计块数量
+func (r *Repo) Stat(withSize bool) (string, error) {
+	// 与Compact相同，节点关闭后仓库也已关闭，这里重新打开
+	repo, err := ipfs_fsrepo.Open(r.mr.Path)
+	if err != nil {
+		return "", err
+	}
+	defer repo.Close()
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", err
+	}
+
+	stat := RepoStat{RepoPath: r.mr.Path}
+	if cfg.Datastore.StorageMax != "" {
+		limit, err := humanize.ParseBytes(cfg.Datastore.StorageMax)
+		if err != nil {
+			return "", fmt.Errorf("invalid storage max `%s`: %w", cfg.Datastore.StorageMax, err)
+		}
+		stat.StorageMax = int64(limit)
+	}
+
+	ctx := context.Background()
+
+	// 只读取键，统计块数量
+	res, err := repo.Datastore().Query(ctx, dsq.Query{Prefix: "/blocks", KeysOnly: true})
+	if err != nil {
+		return "", fmt.Errorf("unable to list blocks: %w", err)
+	}
+	defer res.Close()
+	for entry := range res.Next() {
+		if entry.Error != nil {
+			return "", fmt.Errorf("unable to list blocks: %w", entry.Error)
+		}
+		stat.NumObjects++
+	}
+
+	if withSize {
+		usage, err := repo.GetStorageUsage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("unable to get repo storage usage: %w", err)
+		}
+		stat.RepoSize = int64(usage)
+	}
+
+	out, err := json.Marshal(&stat)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
 // Close 关闭仓库
 func (r *Repo) Close() error {
 	return r.mr.Close()
@@ -106,39 +519,69 @@ func (r *Repo) getRepo() ipfs_repo.Repo {
 }
 
 // loadPlugins 加载IPFS插件系统
-func loadPlugins(repoPath string) (*ipfs_loader.PluginLoader, error) {
+// timeout限制插件初始化和注入的最长时间，小于等于0表示不限制
+func loadPlugins(repoPath string, timeout time.Duration) (*ipfs_loader.PluginLoader, error) {
 	// 加锁确保多线程安全
 	muPlugins.Lock()
-	defer muPlugins.Unlock() // 确保函数退出时解锁
 
 	// 如果插件已加载，直接返回现有实例（单例模式）
 	if plugins != nil {
+		defer muPlugins.Unlock()
 		return plugins, nil
 	}
 
-	// 构建插件目录路径
-	// 默认IPFS插件存放在仓库的"plugins"子目录
-	pluginpath := filepath.Join(repoPath, "plugins")
+	// 如果没有正在进行的加载（例如之前的调用已超时），启动新的加载
+	if pluginsLoading == nil {
+		// 构建插件目录路径
+		// 默认IPFS插件存放在仓库的"plugins"子目录
+		pluginpath := filepath.Join(repoPath, "plugins")
 
-	// 创建新的插件加载器
-	lp, err := ipfs_loader.NewPluginLoader(pluginpath)
-	if err != nil {
-		return nil, err
-	}
+		// 创建新的插件加载器
+		lp, err := ipfs_loader.NewPluginLoader(pluginpath)
+		if err != nil {
+			muPlugins.Unlock()
+			return nil, err
+		}
 
-	// 初始化插件系统
-	// 这会查找和加载所有可用插件的元数据
-	if err = lp.Initialize(); err != nil {
-		return nil, err
+		load := &pluginsLoad{lp: lp, done: make(chan struct{})}
+		pluginsLoading = load
+
+		// 在单独的协程中初始化和注入插件，避免阻塞的插件冻结调用者
+		go func() {
+			// 初始化并注入插件
+			err := initPlugins(lp)
+
+			// 保存全局实例（即使调用者已超时返回）
+			muPlugins.Lock()
+			if err == nil {
+				plugins = lp
+			}
+			pluginsLoading = nil
+			load.err = err
+			muPlugins.Unlock()
+
+			close(load.done)
+		}()
 	}
 
-	// 注入插件
-	// 这会将插件实际集成到IPFS系统中，使其功能可用
-	if err = lp.Inject(); err != nil {
-		return nil, err
+	load := pluginsLoading
+	muPlugins.Unlock()
+
+	// 等待加载完成或超时
+	var timeoutc <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutc = timer.C
 	}
 
-	// 保存全局实例并返回
-	plugins = lp
-	return lp, nil
+	select {
+	case <-load.done:
+		if load.err != nil {
+			return nil, load.err
+		}
+		return load.lp, nil
+	case <-timeoutc:
+		return nil, fmt.Errorf("timeout after %s while loading plugins", timeout)
+	}
 }
@@ -1,8 +1,22 @@
 package core
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+	ipfs_config "github.com/ipfs/kubo/config"
+	ipfs_loader "github.com/ipfs/kubo/plugin/loader"
+	ipfs_fsrepo "github.com/ipfs/kubo/repo/fsrepo"
+	ipfs_migrations "github.com/ipfs/kubo/repo/fsrepo/migrations"
 )
 
 func TestRepo(t *testing.T) {
@@ -48,3 +62,449 @@ func TestRepo(t *testing.T) {
 		t.Error("GetConfig value and original config should be equal")
 	}
 }
+
+func TestRepoSetConnMgrType(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	if err := repo.SetConnMgrType("aggressive"); err == nil {
+		t.Error("expected an error for an unknown connection manager type")
+	}
+
+	if err := repo.SetConnMgrType(ConnMgrTypeNone); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := repo.GetConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if kind := cfg.getConfig().Swarm.ConnMgr.Type; kind != ConnMgrTypeNone {
+		t.Errorf("expected `%s` got `%s`", ConnMgrTypeNone, kind)
+	}
+}
+
+func TestRepoPatchConfig(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	patch := `{"Swarm": {"ConnMgr": {"LowWater": 10, "HighWater": 20}}, "Bootstrap": []}`
+	if err := repo.PatchConfig(patch); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := repo.GetConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	connmgr := cfg.getConfig().Swarm.ConnMgr
+	if connmgr.LowWater != 10 || connmgr.HighWater != 20 {
+		t.Errorf("expected watermarks 10/20 got %d/%d", connmgr.LowWater, connmgr.HighWater)
+	}
+
+	// sibling keys must be preserved by the deep merge
+	if connmgr.Type != "basic" {
+		t.Errorf("expected connmgr type to be preserved, got `%s`", connmgr.Type)
+	}
+
+	if err := repo.PatchConfig(`not json`); err == nil {
+		t.Error("expected an error for an invalid patch")
+	}
+}
+
+func TestRepoConfigKey(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	if err := repo.SetConfigKey("Swarm.ConnMgr.HighWater", "300"); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := repo.GetConfigKey("Swarm.ConnMgr.HighWater")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "300" {
+		t.Errorf("expected `300` got `%s`", value)
+	}
+
+	value, err = repo.GetConfigKey("Swarm.ConnMgr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(value, `"HighWater":300`) || !strings.Contains(value, `"Type":"basic"`) {
+		t.Errorf("expected the sibling keys to be preserved got `%s`", value)
+	}
+
+	for key, value := range map[string]string{
+		"Swarm.ConnMgr.HighWater": `"many"`,
+		"Swarm.ConnMgr.Unknown":   "1",
+		"Swarm.ConnMgr.Type.Sub":  "1",
+		"Swarm.ConnMgr.LowWater":  "not json",
+	} {
+		if err := repo.SetConfigKey(key, value); err == nil {
+			t.Errorf("expected setting `%s` to `%s` to fail", key, value)
+		}
+	}
+
+	if _, err := repo.GetConfigKey("Swarm.Unknown"); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+
+	// failed updates leave the config untouched
+	if value, err := repo.GetConfigKey("Swarm.ConnMgr.HighWater"); err != nil || value != "300" {
+		t.Errorf("expected `300` got `%s` (%v)", value, err)
+	}
+}
+
+func TestRepoCompact(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	node, err := NewNode(repo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Compact(); err == nil {
+		t.Error("expected an error while the node is running")
+	}
+
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// flatfs and leveldb have nothing to collect
+	if err := repo.Compact(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepoCompactBadger(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	cfg := testingConfig(t)
+	if err := ipfs_config.Profiles["badgerds"].Transform(cfg.getConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if err := InitRepo(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := OpenRepo(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+
+	if _, err := os.Stat(filepath.Join(path, "badgerds")); err != nil {
+		t.Fatalf("expected a badger datastore: %s", err)
+	}
+
+	node, err := NewNode(repo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// leave deleted entries in the value log
+	for i := 0; i < 16; i++ {
+		added, err := node.AddBytes(bytes.Repeat([]byte{byte(i)}, 64<<10), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := node.EvictBlock(added); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Compact(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResetRepo(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	peerID := func() string {
+		t.Helper()
+
+		repo, err := OpenRepo(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer repo.Close()
+
+		cfg, err := repo.GetConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cfg.getConfig().Identity.PeerID
+	}
+
+	if err := ResetRepo(path, true); err == nil {
+		t.Error("expected an error while the repo is open")
+	}
+
+	id := peerID()
+
+	// a pinned block, wiped by the resets
+	node, err := NewNode(repo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := node.AddBytes([]byte("reset me"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ipfs_cid.Decode(added)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the private network key and the plugins survive the resets
+	swarmKey := []byte("/key/swarm/psk/1.0.0/\n/base16/\n" + strings.Repeat("ab", 32) + "\n")
+	if err := os.WriteFile(filepath.Join(path, "swarm.key"), swarmKey, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(path, "plugins"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	wiped := func() {
+		t.Helper()
+
+		repo, err := OpenRepo(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the repo is now in a private network
+		cfg := NewNodeConfig()
+		if err := cfg.SetSwarmKey(swarmKey); err != nil {
+			t.Fatal(err)
+		}
+		node, err := NewNode(repo, cfg)
+		if err != nil {
+			repo.Close()
+			t.Fatal(err)
+		}
+		defer node.Close()
+
+		has, err := node.ipfsMobile.IpfsNode.Blockstore.Has(context.Background(), c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if has {
+			t.Error("expected the block to be wiped")
+		}
+
+		pins, err := node.ListPins()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pins != "" {
+			t.Errorf("expected no pin got `%s`", pins)
+		}
+	}
+	kept := func() {
+		t.Helper()
+
+		if key, err := os.ReadFile(filepath.Join(path, "swarm.key")); err != nil || !bytes.Equal(key, swarmKey) {
+			t.Errorf("expected swarm.key to be kept, err: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(path, "plugins")); err != nil {
+			t.Errorf("expected the plugins directory to be kept: %s", err)
+		}
+	}
+
+	if err := ResetRepo(path, true); err != nil {
+		t.Fatal(err)
+	}
+	if newID := peerID(); newID != id {
+		t.Errorf("expected identity `%s` to be kept got `%s`", id, newID)
+	}
+	kept()
+	wiped()
+
+	if err := ResetRepo(path, false); err != nil {
+		t.Fatal(err)
+	}
+	if newID := peerID(); newID == id {
+		t.Error("expected a new identity")
+	}
+	kept()
+	wiped()
+
+	if err := ResetRepo(filepath.Join(path, "missing"), true); err == nil {
+		t.Error("expected an error for an uninitialized repo")
+	}
+}
+
+func TestRepoIsLocked(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	locked, err := RepoIsLocked(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !locked {
+		t.Error("expected an open repo to be locked")
+	}
+
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the lock file is left behind but has no holder anymore
+	locked, err = RepoIsLocked(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locked {
+		t.Error("expected a closed repo to be unlocked")
+	}
+}
+
+func TestRepoStat(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	node, err := NewNode(repo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node.AddBytes([]byte("stat"), true); err != nil {
+		t.Fatal(err)
+	}
+	node.Close()
+
+	out, err := repo.Stat(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stat RepoStat
+	if err := json.Unmarshal([]byte(out), &stat); err != nil {
+		t.Fatal(err)
+	}
+	if stat.NumObjects == 0 {
+		t.Error("expected at least one object")
+	}
+	if stat.RepoSize != 0 {
+		t.Errorf("expected no size without withSize got %d", stat.RepoSize)
+	}
+	if stat.StorageMax != 10*1000*1000*1000 {
+		t.Errorf("expected a 10GB storage max got %d", stat.StorageMax)
+	}
+	if stat.RepoPath != path {
+		t.Errorf("expected repo path `%s` got `%s`", path, stat.RepoPath)
+	}
+
+	out, err = repo.Stat(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(out), &stat); err != nil {
+		t.Fatal(err)
+	}
+	if stat.RepoSize == 0 {
+		t.Error("expected a repo size")
+	}
+}
+
+func TestOpenRepoNeedsMigration(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	if err := InitRepo(path, testingConfig(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	old := ipfs_fsrepo.RepoVersion - 1
+	if err := ipfs_migrations.WriteRepoVersion(path, old); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := OpenRepoWithMigration(path, false)
+	if !errors.Is(err, ErrRepoNeedsMigration) {
+		t.Fatalf("expected ErrRepoNeedsMigration got `%v`", err)
+	}
+
+	var merr *RepoMigrationError
+	if !errors.As(err, &merr) || merr.From != old || merr.To != ipfs_fsrepo.RepoVersion {
+		t.Errorf("expected a migration from %d to %d got `%v`", old, ipfs_fsrepo.RepoVersion, err)
+	}
+}
+
+func TestLoadPluginsTimeout(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	// start unloaded, the plugins loaded by the other tests are restored
+	muPlugins.Lock()
+	loaded := plugins
+	plugins = nil
+	muPlugins.Unlock()
+
+	origInit := initPlugins
+	defer func() {
+		initPlugins = origInit
+		muPlugins.Lock()
+		plugins = loaded
+		muPlugins.Unlock()
+	}()
+
+	errBlocked := errors.New("blocked plugin")
+	release := make(chan struct{})
+	initPlugins = func(*ipfs_loader.PluginLoader) error {
+		<-release
+		return errBlocked
+	}
+
+	start := time.Now()
+	_, err := loadPlugins(path, 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("expected a timeout error got `%v`", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the timeout to stop the wait, took %s", elapsed)
+	}
+
+	// the load goes on in the background, the next call waits for it
+	close(release)
+	if _, err := loadPlugins(path, 5*time.Second); !errors.Is(err, errBlocked) {
+		t.Errorf("expected the error of the blocked plugin got `%v`", err)
+	}
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	ipfs_config "github.com/ipfs/kubo/config"
+)
+
+// defaultReprovideInterval is the interval used by kubo when
+// `Reprovider.Interval` is not set.
+const defaultReprovideInterval = 12 * time.Hour
+
+// initialReprovideDelay is the wait before the first reprovide, as done by
+// the kubo reprovider, so a freshly started node announces its content
+// without waiting a whole interval.
+const initialReprovideDelay = time.Minute
+
+// reprovideLoop periodically reprovides the content of the node. It replaces
+// the kubo reprovider loop, whose interval can't be changed once started.
+type reprovideLoop struct {
+	mu       sync.Mutex
+	interval time.Duration
+
+	// initialDelay is the wait before the first reprovide
+	initialDelay time.Duration
+
+	// reset restarts the wait after an interval update
+	reset chan struct{}
+}
+
+func newReprovideLoop(interval time.Duration) *reprovideLoop {
+	return &reprovideLoop{
+		interval:     interval,
+		initialDelay: initialReprovideDelay,
+		reset:        make(chan struct{}, 1),
+	}
+}
+
+func (l *reprovideLoop) setInterval(interval time.Duration) {
+	l.mu.Lock()
+	l.interval = interval
+	l.mu.Unlock()
+
+	select {
+	case l.reset <- struct{}{}:
+	default:
+	}
+}
+
+func (l *reprovideLoop) getInterval() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.interval
+}
+
+func (l *reprovideLoop) run(ctx context.Context, reprovide func(context.Context) error) {
+	first := true
+	for {
+		// a zero interval pauses reproviding until the next update
+		var tick <-chan time.Time
+		var timer *time.Timer
+		if interval := l.getInterval(); interval > 0 {
+			if first && l.initialDelay < interval {
+				interval = l.initialDelay
+			}
+			timer = time.NewTimer(interval)
+			tick = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-l.reset:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-tick:
+			first = false
+			if err := reprovide(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("reprovide failed: %s", err)
+			}
+		}
+	}
+}
+
+// configReprovideInterval returns the reprovide interval set in the config,
+// 0 means reproviding is disabled.
+func configReprovideInterval(cfg *ipfs_config.Config) (time.Duration, error) {
+	if cfg.Experimental.StrategicProviding {
+		// the node uses an offline provider
+		return 0, nil
+	}
+
+	if cfg.Reprovider.Interval == "" {
+		return defaultReprovideInterval, nil
+	}
+
+	interval, err := time.ParseDuration(cfg.Reprovider.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Reprovider.Interval `%s`: %w", cfg.Reprovider.Interval, err)
+	}
+	return interval, nil
+}
+
+// formatReprovideInterval formats an interval for `Reprovider.Interval`.
+func formatReprovideInterval(interval time.Duration) string {
+	if interval <= 0 {
+		return "0"
+	}
+	return interval.String()
+}
+
+func (n *Node) reprovide(ctx context.Context) error {
+	return n.ipfsMobile.IpfsNode.Provider.Reprovide(ctx)
+}
+
+// SetReprovideInterval changes the interval, in seconds, at which the running
+// node reprovides its content, without updating the config. The next
+// reprovide happens one interval after the call, 0 pauses reproviding. It
+// fails when reproviding is disabled in the config or NodeConfig the node has
+// been started with, or handled by the accelerated DHT client.
+func (n *Node) SetReprovideInterval(seconds int64) error {
+	if n.reprovider == nil {
+		return errors.New("reproviding is not active on this node")
+	}
+	if seconds < 0 {
+		return fmt.Errorf("invalid reprovide interval %ds", seconds)
+	}
+
+	n.reprovider.setInterval(time.Duration(seconds) * time.Second)
+	return nil
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNodeSetReprovideInterval(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	cfg, err := repo.mr.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	interval := cfg.Reprovider.Interval
+
+	node, err := NewNode(repo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	// the config should be left untouched by the node
+	cfg, err = repo.mr.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Reprovider.Interval != interval {
+		t.Errorf("expected Reprovider.Interval to be `%s` got `%s`", interval, cfg.Reprovider.Interval)
+	}
+
+	if err := node.SetReprovideInterval(3600); err != nil {
+		t.Fatal(err)
+	}
+	if got := node.reprovider.getInterval(); got != time.Hour {
+		t.Errorf("expected reprovide interval to be `%s` got `%s`", time.Hour, got)
+	}
+
+	// pause
+	if err := node.SetReprovideInterval(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := node.SetReprovideInterval(-3600); err == nil {
+		t.Error("negative interval should fail")
+	}
+}
+
+func TestReprovideLoopInitialDelay(t *testing.T) {
+	loop := newReprovideLoop(time.Hour)
+	loop.initialDelay = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var count int32
+	done := make(chan struct{})
+	go func() {
+		loop.run(ctx, func(context.Context) error {
+			atomic.AddInt32(&count, 1)
+			return nil
+		})
+		close(done)
+	}()
+
+	// the first reprovide doesn't wait for the whole interval
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&count) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected a reprovide shortly after start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the next ones do
+	time.Sleep(100 * time.Millisecond)
+	if got := atomic.LoadInt32(&count); got != 1 {
+		t.Errorf("expected a single reprovide got %d", got)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestNodeConfigReprovider(t *testing.T) {
+	config := NewNodeConfig()
+	if err := config.SetReproviderStrategy("flowers"); err == nil {
+		t.Error("expected an invalid strategy to be refused")
+	}
+	if err := config.SetReproviderInterval(-3600); err == nil {
+		t.Error("expected a negative interval to be refused")
+	}
+
+	if err := config.SetReproviderStrategy(ReproviderStrategyRoots); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetReproviderInterval(3600); err != nil {
+		t.Fatal(err)
+	}
+
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	cfg, err := repo.mr.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reprovider := cfg.Reprovider
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	if got := node.reprovider.getInterval(); got != time.Hour {
+		t.Errorf("expected reprovide interval to be `%s` got `%s`", time.Hour, got)
+	}
+
+	// the overrides only apply to the node
+	cfg, err = repo.mr.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Reprovider != reprovider {
+		t.Errorf("expected the repo config to be restored got `%+v`", cfg.Reprovider)
+	}
+}
+
+func TestNodeConfigReproviderDisabled(t *testing.T) {
+	config := NewNodeConfig()
+	if err := config.SetReproviderInterval(0); err != nil {
+		t.Fatal(err)
+	}
+
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	repo, clean := testingRepo(t, path)
+	defer clean()
+
+	node, err := NewNode(repo, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	if err := node.SetReprovideInterval(3600); err == nil {
+		t.Error("expected reproviding to be disabled")
+	}
+}
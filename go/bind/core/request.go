@@ -0,0 +1,50 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// ProgressHandler receives the progress of a long running request, it is
+// called from the goroutine of the request.
+type ProgressHandler interface {
+	OnProgress(blocks int64, bytes int64)
+	OnComplete()
+	OnError(message string)
+}
+
+// newRequest registers a cancelable request and returns its handle along with
+// its context. done must be called once the request is over.
+func (n *Node) newRequest() (id int64, ctx context.Context, done func()) {
+	ctx, cancel := context.WithCancel(n.ctx)
+
+	n.muRequests.Lock()
+	n.lastRequestID++
+	id = n.lastRequestID
+	if n.requests == nil {
+		n.requests = make(map[int64]context.CancelFunc)
+	}
+	n.requests[id] = cancel
+	n.muRequests.Unlock()
+
+	return id, ctx, func() {
+		n.muRequests.Lock()
+		delete(n.requests, id)
+		n.muRequests.Unlock()
+		cancel()
+	}
+}
+
+// CancelRequest cancels the running request identified by the given handle.
+func (n *Node) CancelRequest(id int64) error {
+	n.muRequests.Lock()
+	cancel, ok := n.requests[id]
+	n.muRequests.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no running request with handle %d", id)
+	}
+
+	cancel()
+	return nil
+}
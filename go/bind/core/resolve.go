@@ -0,0 +1,48 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	ipfs_path "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// ResolvePath resolves a path like `/ipfs/<cid>/dir/file.txt` or
+// `/ipns/<name>/file.txt` to the cid of the node it points to. A path without
+// namespace is considered to be relative to `/ipfs/`.
+func (n *Node) ResolvePath(path string) (string, error) {
+	p, err := parsePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	api, err := n.coreAPI()
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := api.ResolvePath(n.ctx, p)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve `%s`: %w", path, err)
+	}
+
+	return resolved.Cid().String(), nil
+}
+
+// parsePath parses an ipfs or ipns path, a path without namespace is
+// considered to be relative to `/ipfs/`.
+func parsePath(path string) (ipfs_path.Path, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/ipfs/" + path
+	}
+
+	p := ipfs_path.New(path)
+	if err := p.IsValid(); err != nil {
+		return nil, fmt.Errorf("invalid path `%s`: %w", path, err)
+	}
+	return p, nil
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+	ipfs_path "github.com/ipfs/interface-go-ipfs-core/path"
+	ipfs_coreapi "github.com/ipfs/kubo/core/coreapi"
+)
+
+func TestNodeResolvePath(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	api, err := ipfs_coreapi.NewCoreAPI(node.ipfsMobile.IpfsNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	dir := ipfs_files.NewMapDirectory(map[string]ipfs_files.Node{
+		"file.txt": ipfs_files.NewBytesFile([]byte("nested content")),
+	})
+	root, err := api.Unixfs().Add(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := api.ResolvePath(ctx, ipfs_path.Join(root, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{
+		root.String() + "/file.txt",
+		root.Cid().String() + "/file.txt",
+	} {
+		c, err := node.ResolvePath(p)
+		if err != nil {
+			t.Fatalf("unable to resolve `%s`: %s", p, err)
+		}
+		if c != file.Cid().String() {
+			t.Errorf("`%s`: expected `%s` got `%s`", p, file.Cid(), c)
+		}
+	}
+
+	if _, err := node.ResolvePath(root.String() + "/missing.txt"); err == nil {
+		t.Error("resolving a missing path component should fail")
+	}
+}
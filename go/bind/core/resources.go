@@ -0,0 +1,143 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	p2p_rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+)
+
+var errNoResourceManager = errors.New("no resource manager is installed, enable it with `Swarm.ResourceMgr.Enabled` in the config")
+
+// ResourceStat is the current usage of a resource along with its limit.
+type ResourceStat struct {
+	Current int64
+	Limit   int64
+}
+
+// ScopeUsage holds the usage of every resource of a resource manager scope.
+type ScopeUsage struct {
+	Conns           ResourceStat
+	ConnsInbound    ResourceStat
+	ConnsOutbound   ResourceStat
+	Streams         ResourceStat
+	StreamsInbound  ResourceStat
+	StreamsOutbound ResourceStat
+	Memory          ResourceStat
+	FD              ResourceStat
+}
+
+// ResourceUsageStats is the usage of the resource manager scopes, protocols
+// and peers are keyed by protocol and peer id.
+type ResourceUsageStats struct {
+	System    *ScopeUsage
+	Transient *ScopeUsage
+	Protocols map[string]*ScopeUsage
+	Peers     map[string]*ScopeUsage
+}
+
+// ResourceUsage returns a JSON encoded ResourceUsageStats, the current usage
+// and limits of the system and transient scopes of the resource manager, and
+// of the scopes of the protocols handled by the node and of the connected
+// peers. It helps finding the saturated scope when dials or streams fail
+// because of resource limits. It fails when no resource manager is installed.
+func (n *Node) ResourceUsage() (string, error) {
+	mgr := n.ipfsMobile.IpfsNode.ResourceManager
+	if mgr == nil {
+		return "", errNoResourceManager
+	}
+
+	stats := &ResourceUsageStats{
+		Protocols: make(map[string]*ScopeUsage),
+		Peers:     make(map[string]*ScopeUsage),
+	}
+
+	err := mgr.ViewSystem(func(s network.ResourceScope) (err error) {
+		stats.System, err = scopeUsage(s)
+		return
+	})
+	if err != nil {
+		return "", err
+	}
+
+	err = mgr.ViewTransient(func(s network.ResourceScope) (err error) {
+		stats.Transient, err = scopeUsage(s)
+		return
+	})
+	if err != nil {
+		return "", err
+	}
+
+	h := n.ipfsMobile.PeerHost()
+	for _, proto := range h.Mux().Protocols() {
+		err = mgr.ViewProtocol(protocol.ID(proto), func(s network.ProtocolScope) (err error) {
+			stats.Protocols[proto], err = scopeUsage(s)
+			return
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for _, pid := range h.Network().Peers() {
+		err = mgr.ViewPeer(pid, func(s network.PeerScope) (err error) {
+			stats.Peers[pid.String()], err = scopeUsage(s)
+			return
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	out, err := json.Marshal(stats)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func scopeUsage(s network.ResourceScope) (*ScopeUsage, error) {
+	// the scopes of the null resource manager have no limit
+	limiter, ok := s.(p2p_rcmgr.ResourceScopeLimiter)
+	if !ok {
+		return nil, errNoResourceManager
+	}
+
+	stat, limit := s.Stat(), limiter.Limit()
+	return &ScopeUsage{
+		Conns: ResourceStat{
+			Current: int64(stat.NumConnsInbound + stat.NumConnsOutbound),
+			Limit:   int64(limit.GetConnTotalLimit()),
+		},
+		ConnsInbound: ResourceStat{
+			Current: int64(stat.NumConnsInbound),
+			Limit:   int64(limit.GetConnLimit(network.DirInbound)),
+		},
+		ConnsOutbound: ResourceStat{
+			Current: int64(stat.NumConnsOutbound),
+			Limit:   int64(limit.GetConnLimit(network.DirOutbound)),
+		},
+		Streams: ResourceStat{
+			Current: int64(stat.NumStreamsInbound + stat.NumStreamsOutbound),
+			Limit:   int64(limit.GetStreamTotalLimit()),
+		},
+		StreamsInbound: ResourceStat{
+			Current: int64(stat.NumStreamsInbound),
+			Limit:   int64(limit.GetStreamLimit(network.DirInbound)),
+		},
+		StreamsOutbound: ResourceStat{
+			Current: int64(stat.NumStreamsOutbound),
+			Limit:   int64(limit.GetStreamLimit(network.DirOutbound)),
+		},
+		Memory: ResourceStat{
+			Current: stat.Memory,
+			Limit:   limit.GetMemoryLimit(),
+		},
+		FD: ResourceStat{
+			Current: int64(stat.NumFD),
+			Limit:   int64(limit.GetFDLimit()),
+		},
+	}, nil
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNodeResourceUsage(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		path, clean := testingTempDir(t, "repo")
+		defer clean()
+
+		node, clean := testingNode(t, path)
+		defer clean()
+
+		if _, err := node.ResourceUsage(); err == nil {
+			t.Fatal("expected an error without resource manager")
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		path, clean := testingTempDir(t, "repo")
+		defer clean()
+
+		repo, clean := testingRepo(t, path)
+		defer clean()
+
+		err := repo.PatchConfig(`{"Swarm": {"ResourceMgr": {"Enabled": true}}}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		node, err := NewNode(repo, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer node.Close()
+
+		out, err := node.ResourceUsage()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var stats ResourceUsageStats
+		if err := json.Unmarshal([]byte(out), &stats); err != nil {
+			t.Fatal(err)
+		}
+
+		if stats.System == nil || stats.Transient == nil {
+			t.Fatalf("missing system or transient scope in `%s`", out)
+		}
+
+		if stats.System.Conns.Limit <= 0 {
+			t.Errorf("expected a system connection limit, got %d", stats.System.Conns.Limit)
+		}
+	})
+}
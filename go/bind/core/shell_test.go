@@ -75,12 +75,12 @@ func TestShell(t *testing.T) {
 
 	for clientk, clienttc := range casesClient {
 		t.Run(clientk, func(t *testing.T) {
-			maddr, err := node.ServeAPIMultiaddr(clienttc.MAddr)
+			l, err := node.ServeAPIMultiaddr(clienttc.MAddr)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			shell := NewShell(maddr)
+			shell := NewShell(l.Multiaddr())
 			for _, cmdtc := range casesCommand {
 				t.Run(cmdtc.Command, func(t *testing.T) {
 					req := shell.NewRequest(cmdtc.Command)
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Kinds of the subscriptions returned by ActiveSubscriptions
+const (
+	SubscriptionKindGC                 = "gc"
+	SubscriptionKindConnectionUpgrades = "connection-upgrades"
+	SubscriptionKindPubSub             = "pubsub"
+)
+
+// ActiveSubscription is a subscription returned by ActiveSubscriptions.
+type ActiveSubscription struct {
+	ID   string
+	Kind string
+	// Since is the unix time in milliseconds at which the subscription has
+	// been created.
+	Since int64
+}
+
+type subscription struct {
+	kind   string
+	seq    int64
+	since  time.Time
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newSubscription registers a cancelable subscription of the given kind and
+// returns its id along with its context, canceled by CancelSubscription or
+// when the node is closed. done must be called once the subscription
+// goroutine returns.
+func (n *Node) newSubscription(kind string) (id string, ctx context.Context, done func()) {
+	ctx, cancel := context.WithCancel(n.ctx)
+
+	n.muSubscriptions.Lock()
+	n.lastSubscriptionID++
+	id = fmt.Sprintf("%s-%d", kind, n.lastSubscriptionID)
+	if n.subscriptions == nil {
+		n.subscriptions = make(map[string]*subscription)
+	}
+	n.subscriptions[id] = &subscription{
+		kind:   kind,
+		seq:    n.lastSubscriptionID,
+		since:  time.Now(),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	n.muSubscriptions.Unlock()
+
+	return id, ctx, func() {
+		n.muSubscriptions.Lock()
+		delete(n.subscriptions, id)
+		n.muSubscriptions.Unlock()
+		cancel()
+	}
+}
+
+// ActiveSubscriptions returns a JSON encoded list of ActiveSubscription, the
+// running event subscriptions of the node (GC, connection upgrades and pubsub
+// topics), oldest first.
+func (n *Node) ActiveSubscriptions() (string, error) {
+	n.muSubscriptions.Lock()
+	seqs := make(map[string]int64, len(n.subscriptions))
+	subs := make([]ActiveSubscription, 0, len(n.subscriptions))
+	for id, sub := range n.subscriptions {
+		if sub.ctx.Err() != nil {
+			// canceled, its goroutine is returning
+			continue
+		}
+
+		seqs[id] = sub.seq
+		subs = append(subs, ActiveSubscription{
+			ID:    id,
+			Kind:  sub.kind,
+			Since: sub.since.UnixMilli(),
+		})
+	}
+	n.muSubscriptions.Unlock()
+
+	sort.Slice(subs, func(i, j int) bool {
+		return seqs[subs[i].ID] < seqs[subs[j].ID]
+	})
+
+	out, err := json.Marshal(subs)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// CancelSubscription stops the subscription with the given id, its handler
+// goroutine returns after the event being handled, if any.
+func (n *Node) CancelSubscription(id string) error {
+	n.muSubscriptions.Lock()
+	sub, ok := n.subscriptions[id]
+	n.muSubscriptions.Unlock()
+
+	if !ok || sub.ctx.Err() != nil {
+		return fmt.Errorf("no active subscription with id `%s`", id)
+	}
+
+	sub.cancel()
+	return nil
+}
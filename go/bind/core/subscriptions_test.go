@@ -0,0 +1,82 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type noopUpgradeHandler struct{}
+
+func (noopUpgradeHandler) OnConnectionUpgraded(_ string, _ string) {}
+
+func TestNodeSubscriptions(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	active := func() []ActiveSubscription {
+		t.Helper()
+
+		out, err := node.ActiveSubscriptions()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var subs []ActiveSubscription
+		if err := json.Unmarshal([]byte(out), &subs); err != nil {
+			t.Fatal(err)
+		}
+		return subs
+	}
+
+	gcID := node.SubscribeGC(&testGCHandler{completed: make(chan int64, 1)})
+	upgradeID := node.SubscribeConnectionUpgrades(noopUpgradeHandler{})
+
+	subs := active()
+	if len(subs) != 2 {
+		t.Fatalf("expected 2 subscriptions, got %d", len(subs))
+	}
+	if subs[0].ID != gcID || subs[0].Kind != SubscriptionKindGC {
+		t.Errorf("expected gc subscription `%s` first, got `%s` (%s)", gcID, subs[0].ID, subs[0].Kind)
+	}
+	if subs[1].ID != upgradeID || subs[1].Kind != SubscriptionKindConnectionUpgrades {
+		t.Errorf("expected upgrade subscription `%s`, got `%s` (%s)", upgradeID, subs[1].ID, subs[1].Kind)
+	}
+
+	if err := node.CancelSubscription(gcID); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.CancelSubscription(gcID); err == nil {
+		t.Error("expected an error canceling a subscription twice")
+	}
+	if err := node.CancelSubscription("unknown"); err == nil {
+		t.Error("expected an error with an unknown subscription")
+	}
+
+	if subs := active(); len(subs) != 1 || subs[0].ID != upgradeID {
+		t.Fatalf("expected only `%s` to be active, got %v", upgradeID, subs)
+	}
+
+	// the canceled gc subscription doesn't block the collection
+	done := make(chan error, 1)
+	go func() {
+		_, err := node.RepoGC()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("gc blocked on a canceled subscription")
+	}
+
+	node.Close()
+	if subs := active(); len(subs) != 0 {
+		t.Fatalf("expected no active subscription after close, got %v", subs)
+	}
+}
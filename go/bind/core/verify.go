@@ -0,0 +1,105 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+	ipfs_chunker "github.com/ipfs/go-ipfs-chunker"
+	ipld "github.com/ipfs/go-ipld-format"
+	ipfs_balanced "github.com/ipfs/go-unixfs/importer/balanced"
+	ipfs_ihelper "github.com/ipfs/go-unixfs/importer/helpers"
+)
+
+// VerifyContent returns whether data matches the given cid, so content
+// received out of band (e.g. over BLE or from a backend) can be checked
+// before being trusted. It never touches the network or the blockstore.
+//
+// For a raw cid, data is the content of the block. For a dag-pb cid, data is
+// either the block itself or the content of a UnixFS file, which is then
+// chunked again with the default parameters of add: 256KiB chunks, balanced
+// layout, and raw leaves with cid version 1. A file added with other
+// parameters doesn't match. Other codecs are not supported.
+func (n *Node) VerifyContent(cid string, data []byte) (bool, error) {
+	c, err := ipfs_cid.Decode(cid)
+	if err != nil {
+		return false, fmt.Errorf("invalid cid `%s`: %w", cid, err)
+	}
+
+	prefix := c.Prefix()
+	switch prefix.Codec {
+	case ipfs_cid.Raw, ipfs_cid.DagProtobuf:
+	default:
+		return false, fmt.Errorf("unsupported codec 0x%x of `%s`, expected raw or dag-pb", prefix.Codec, c)
+	}
+
+	sum, err := prefix.Sum(data)
+	if err != nil {
+		return false, fmt.Errorf("unable to hash content: %w", err)
+	}
+	if sum.Equals(c) {
+		return true, nil
+	}
+	if prefix.Codec == ipfs_cid.Raw {
+		return false, nil
+	}
+
+	root, err := unixfsRoot(data, prefix, defaultMaxLinks)
+	if err != nil {
+		return false, fmt.Errorf("unable to rebuild the dag of the content: %w", err)
+	}
+	return root.Equals(c), nil
+}
+
+// unixfsRoot returns the root cid of the UnixFS file holding data, built with
+// the default add parameters but maxLinks, and the cid version and hash of
+// prefix.
+func unixfsRoot(data []byte, prefix ipfs_cid.Prefix, maxLinks int) (ipfs_cid.Cid, error) {
+	params := ipfs_ihelper.DagBuilderParams{
+		Dagserv:   discardDAG{},
+		Maxlinks:  maxLinks,
+		RawLeaves: prefix.Version == 1,
+		CidBuilder: ipfs_cid.Prefix{
+			Version:  prefix.Version,
+			Codec:    ipfs_cid.DagProtobuf,
+			MhType:   prefix.MhType,
+			MhLength: -1,
+		},
+	}
+
+	db, err := params.New(ipfs_chunker.DefaultSplitter(bytes.NewReader(data)))
+	if err != nil {
+		return ipfs_cid.Undef, err
+	}
+
+	nd, err := ipfs_balanced.Layout(db)
+	if err != nil {
+		return ipfs_cid.Undef, err
+	}
+	return nd.Cid(), nil
+}
+
+// discardDAG is a DAG service dropping the added nodes, the importer only
+// needs them to compute the cids.
+type discardDAG struct{}
+
+func (discardDAG) Get(context.Context, ipfs_cid.Cid) (ipld.Node, error) {
+	return nil, ipld.ErrNotFound{}
+}
+
+func (discardDAG) GetMany(ctx context.Context, cids []ipfs_cid.Cid) <-chan *ipld.NodeOption {
+	out := make(chan *ipld.NodeOption, len(cids))
+	for _, c := range cids {
+		out <- &ipld.NodeOption{Err: ipld.ErrNotFound{Cid: c}}
+	}
+	close(out)
+	return out
+}
+
+func (discardDAG) Add(context.Context, ipld.Node) error             { return nil }
+func (discardDAG) AddMany(context.Context, []ipld.Node) error       { return nil }
+func (discardDAG) Remove(context.Context, ipfs_cid.Cid) error       { return nil }
+func (discardDAG) RemoveMany(context.Context, []ipfs_cid.Cid) error { return nil }
+
+var _ ipld.DAGService = discardDAG{}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ipfs_cid "github.com/ipfs/go-cid"
+	ipfs_files "github.com/ipfs/go-ipfs-files"
+	ipfs_options "github.com/ipfs/interface-go-ipfs-core/options"
+)
+
+func TestNodeVerifyContent(t *testing.T) {
+	path, clean := testingTempDir(t, "repo")
+	defer clean()
+
+	node, clean := testingNode(t, path)
+	defer clean()
+
+	api, err := node.coreAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// several chunks
+	content := bytes.Repeat([]byte("verify me\n"), 100000)
+	tampered := append([]byte{}, content...)
+	tampered[42] = '!'
+
+	for _, version := range []int{0, 1} {
+		resolved, err := api.Unixfs().Add(context.Background(), ipfs_files.NewBytesFile(content),
+			ipfs_options.Unixfs.CidVersion(version), ipfs_options.Unixfs.Pin(false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		root := resolved.Cid().String()
+
+		if ok, err := node.VerifyContent(root, content); err != nil || !ok {
+			t.Errorf("expected the content to match `%s`, err: %v", root, err)
+		}
+
+		if ok, err := node.VerifyContent(root, tampered); err != nil || ok {
+			t.Errorf("expected tampered content not to match `%s`, err: %v", root, err)
+		}
+	}
+
+	raw, err := ipfs_cid.Prefix{
+		Version:  1,
+		Codec:    ipfs_cid.Raw,
+		MhType:   0x12, // sha2-256
+		MhLength: -1,
+	}.Sum([]byte("raw block"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := node.VerifyContent(raw.String(), []byte("raw block")); err != nil || !ok {
+		t.Errorf("expected the raw block to match, err: %v", err)
+	}
+
+	if ok, err := node.VerifyContent(raw.String(), []byte("other block")); err != nil || ok {
+		t.Errorf("expected another block not to match, err: %v", err)
+	}
+
+	cbor, err := ipfs_cid.Prefix{
+		Version:  1,
+		Codec:    ipfs_cid.DagCBOR,
+		MhType:   0x12,
+		MhLength: -1,
+	}.Sum([]byte("cbor"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := node.VerifyContent(cbor.String(), []byte("cbor")); err == nil {
+		t.Error("expected an error with an unsupported codec")
+	}
+
+	if _, err := node.VerifyContent("not a cid", content); err == nil {
+		t.Error("expected an error with an invalid cid")
+	}
+
+	// a file added with another maximum number of links only matches the DAG
+	// rebuilt with it
+	fpath := filepath.Join(path, "content.txt")
+	if err := os.WriteFile(fpath, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out, err := node.AddFileDetailed(fpath, `{"MaxLinks": 3, "Pin": false}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res AddResult
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatal(err)
+	}
+	root, err := ipfs_cid.Decode(res.Cid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := node.VerifyContent(res.Cid, content); err != nil || ok {
+		t.Errorf("expected the content not to match `%s` with the default links, err: %v", root, err)
+	}
+	if rebuilt, err := unixfsRoot(content, root.Prefix(), 3); err != nil || !rebuilt.Equals(root) {
+		t.Errorf("expected the content rebuilt with 3 links to match `%s` got `%s`, err: %v", root, rebuilt, err)
+	}
+}
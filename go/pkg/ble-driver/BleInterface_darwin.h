@@ -25,6 +25,7 @@ void BLECloseConnWithPeer(char *remotePID);
 int BLEBridgeHandleFoundPeer(NSString *remotePID);
 void BLEBridgeHandleLostPeer(NSString *remotePID);
 void BLEBridgeReceiveFromPeer(NSString *remotePID, NSData *payload);
+void BLEBridgeSetPeerMTU(NSString *remotePID, int mtu);
 void BLEBridgeLog(enum level level, NSString *message);
 void BLEUseExternalLogger(void);
 
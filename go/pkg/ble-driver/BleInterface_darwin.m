@@ -13,6 +13,7 @@
 extern int BLEHandleFoundPeer(char *);
 extern void BLEHandleLostPeer(char *);
 extern void BLEReceiveFromPeer(char *, void *, unsigned long);
+extern void BLESetPeerMTU(char *, int);
 extern void BLELog(enum level level, const char *message);
 
 static BleManager *manager = nil;
@@ -147,6 +148,11 @@ void BLEBridgeReceiveFromPeer(NSString *remotePID, NSData *payload) {
     BLEReceiveFromPeer(cPID, cPayload, length);
 }
 
+void BLEBridgeSetPeerMTU(NSString *remotePID, int mtu) {
+    char *cPID = (char *)[remotePID UTF8String];
+    BLESetPeerMTU(cPID, mtu);
+}
+
 // Write logs to the external logger
 void BLEBridgeLog(enum level level, NSString *message) {
     char *cMessage = (char *)[message UTF8String];
@@ -70,6 +70,12 @@
             return NULL;
         }
         
+        if (device.peripheral != nil) {
+            BLEBridgeSetPeerMTU(peerID, (int)[device.peripheral maximumWriteValueLengthForType:CBCharacteristicWriteWithResponse]);
+        } else if (device.cbCentral != nil) {
+            BLEBridgeSetPeerMTU(peerID, (int)device.cbCentral.maximumUpdateValueLength);
+        }
+        
         [device flushCache];
     }
     
@@ -94,6 +94,19 @@ func BLEReceiveFromPeer(remotePID *C.char, payload unsafe.Pointer, length C.int)
 	t.ReceiveFromPeer(goPID, goPayload)
 }
 
+//export BLESetPeerMTU
+func BLESetPeerMTU(remotePID *C.char, mtu C.int) { // nolint:revive // Need to prefix func name to avoid duplicate symbols between proximity drivers
+	goPID := C.GoString(remotePID)
+
+	proximity.TransportMapMutex.RLock()
+	t, ok := proximity.TransportMap[ProtocolName]
+	proximity.TransportMapMutex.RUnlock()
+	if !ok {
+		return
+	}
+	t.SetPeerMTU(goPID, int(mtu))
+}
+
 //export BLELog
 func BLELog(level C.enum_level, message *C.char) { //nolint:revive
 	if gLogger == nil {
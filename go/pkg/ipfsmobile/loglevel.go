@@ -0,0 +1,75 @@
+/*
+文件概览：go/pkg/ipfsmobile/loglevel.go
+此文件处理IPFS日志级别的配置：
+1. 解析全局级别（如"error"）和按子系统的级别（如"dht=info,bitswap=warn"）
+2. 将解析后的级别应用到go-log的各个子系统
+
+未配置日志级别时不修改任何级别，保留环境变量（GOLOG_LOG_LEVEL）或go-log的默认值。
+*/
+
+// 与node.go在同一个包中
+package node
+
+import (
+	"fmt"
+	"strings"
+
+	ipfs_log "github.com/ipfs/go-log/v2" // IPFS日志系统
+)
+
+// allSubsystems表示所有日志子系统
+const allSubsystems = "*"
+
+// ParseLogLevel解析逗号分隔的日志级别设置，返回子系统到级别的映射
+// 不带子系统的项（如"error"）作用于所有子系统，以"*"为键
+// 可用级别：debug、info、warn、error、dpanic、panic、fatal
+func ParseLogLevel(levels string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, entry := range strings.Split(levels, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		subsystem, level := allSubsystems, entry
+		if i := strings.Index(entry, "="); i >= 0 {
+			subsystem, level = strings.TrimSpace(entry[:i]), strings.TrimSpace(entry[i+1:])
+			if subsystem == "" || level == "" {
+				return nil, fmt.Errorf("invalid log level `%s`, expected `<subsystem>=<level>`", entry)
+			}
+		}
+
+		if _, err := ipfs_log.LevelFromString(level); err != nil {
+			return nil, fmt.Errorf("invalid log level `%s`: %w", level, err)
+		}
+		out[subsystem] = level
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no log level in `%s`", levels)
+	}
+	return out, nil
+}
+
+// SetLogLevel解析并应用日志级别设置，格式见ParseLogLevel
+// 先应用全局级别，再应用各子系统的级别，未知的子系统返回错误
+func SetLogLevel(levels string) error {
+	parsed, err := ParseLogLevel(levels)
+	if err != nil {
+		return err
+	}
+
+	if level, ok := parsed[allSubsystems]; ok {
+		if err := ipfs_log.SetLogLevel(allSubsystems, level); err != nil {
+			return err
+		}
+		delete(parsed, allSubsystems)
+	}
+
+	for subsystem, level := range parsed {
+		if err := ipfs_log.SetLogLevel(subsystem, level); err != nil {
+			return fmt.Errorf("unable to set the log level of `%s`: %w", subsystem, err)
+		}
+	}
+	return nil
+}
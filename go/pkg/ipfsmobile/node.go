@@ -15,9 +15,11 @@ package node
 
 import (
 	// 导入必要的标准库
-	"context" // 用于上下文管理
-	"fmt"     // 用于格式化错误消息
-	"net"     // 提供网络连接接口
+	"context"  // 用于上下文管理
+	"fmt"      // 用于格式化错误消息
+	"net"      // 提供网络连接接口
+	"net/http" // HTTP服务器
+	"time"     // HTTP服务器超时
 
 	// 导入IPFS核心组件
 	ipfs_oldcmds "github.com/ipfs/kubo/commands"       // IPFS命令接口
@@ -43,6 +45,10 @@ type IpfsConfig struct {
 	RepoMobile *RepoMobile
 	// 额外选项映射，用于启用/禁用特定功能
 	ExtraOpts map[string]bool
+
+	// 日志级别，如"error"或"dht=info,bitswap=warn"（见loglevel.go）
+	// 为空时不修改日志级别，保留环境变量或go-log的默认值
+	LogLevel string
 }
 
 // fillDefault为配置填充默认值
@@ -107,33 +113,110 @@ func (im *IpfsMobile) Close() error {
 // ServeCoreHTTP在给定网络监听器上提供IPFS HTTP API服务
 // 允许通过HTTP访问IPFS功能
 func (im *IpfsMobile) ServeCoreHTTP(l net.Listener, opts ...ipfs_corehttp.ServeOption) error {
+	// 启动HTTP服务
+	return ipfs_corehttp.Serve(im.IpfsNode, l, im.coreHTTPOptions(opts)...)
+}
+
+// ServeGateway在给定网络监听器上提供IPFS HTTP网关服务
+// 允许通过HTTP访问IPFS内容
+func (im *IpfsMobile) ServeGateway(l net.Listener, writable bool, opts ...ipfs_corehttp.ServeOption) error {
+	// 启动网关服务
+	return ipfs_corehttp.Serve(im.IpfsNode, l, im.gatewayOptions(writable, opts)...)
+}
+
+// CoreHTTPServer创建在l上提供IPFS HTTP API服务的HTTP服务器，由调用者运行Serve(l)
+// 与ServeCoreHTTP不同，服务器不随节点关闭，调用者可以通过Shutdown等待请求处理完毕
+func (im *IpfsMobile) CoreHTTPServer(l net.Listener, opts ...ipfs_corehttp.ServeOption) (*http.Server, error) {
+	return newHTTPServer(im.IpfsNode, l, im.coreHTTPOptions(opts)...)
+}
+
+// GatewayServer创建在l上提供IPFS HTTP网关服务的HTTP服务器，见CoreHTTPServer
+func (im *IpfsMobile) GatewayServer(l net.Listener, writable bool, opts ...ipfs_corehttp.ServeOption) (*http.Server, error) {
+	return newHTTPServer(im.IpfsNode, l, im.gatewayOptions(writable, opts)...)
+}
+
+// coreHTTPOptions返回API服务的选项
+func (im *IpfsMobile) coreHTTPOptions(opts []ipfs_corehttp.ServeOption) []ipfs_corehttp.ServeOption {
 	// 配置网关选项，不可写，包含WebUI路径
 	gatewayOpt := ipfs_corehttp.GatewayOption(false, ipfs_corehttp.WebUIPaths...)
 	// 添加标准选项：WebUI、网关和命令处理
-	opts = append(opts,
+	return append(opts,
 		ipfs_corehttp.WebUIOption, // 启用Web界面
 		gatewayOpt,                // 配置网关
 		ipfs_corehttp.CommandsOption(im.commandCtx), // 添加HTTP命令处理
 	)
-
-	// 启动HTTP服务
-	return ipfs_corehttp.Serve(im.IpfsNode, l, opts...)
 }
 
-// ServeGateway在给定网络监听器上提供IPFS HTTP网关服务
-// 允许通过HTTP访问IPFS内容
-func (im *IpfsMobile) ServeGateway(l net.Listener, writable bool, opts ...ipfs_corehttp.ServeOption) error {
+// gatewayOptions返回网关服务的选项
+// 只读网关仅处理内容获取请求，不挂载命令API；可写网关才挂载命令处理
+func (im *IpfsMobile) gatewayOptions(writable bool, opts []ipfs_corehttp.ServeOption) []ipfs_corehttp.ServeOption {
+	if !writable {
+		return append(opts,
+			readOnlyMethodsOption(),                              // 拒绝GET/HEAD/OPTIONS以外的请求
+			ipfs_corehttp.HostnameOption(),                       // 处理基于主机名的解析
+			ipfs_corehttp.GatewayOption(false, "/ipfs", "/ipns"), // 配置IPFS/IPNS路径
+			ipfs_corehttp.VersionOption(),                        // 添加版本信息头
+		)
+	}
+
 	// 添加标准网关选项
-	opts = append(opts,
-		ipfs_corehttp.HostnameOption(),                          // 处理基于主机名的解析
-		ipfs_corehttp.GatewayOption(writable, "/ipfs", "/ipns"), // 配置IPFS/IPNS路径
-		ipfs_corehttp.VersionOption(),                           // 添加版本信息头
-		ipfs_corehttp.CheckVersionOption(),                      // 检查客户端兼容性
-		ipfs_corehttp.CommandsROOption(im.commandCtx),           // 只读命令支持
+	return append(opts,
+		ipfs_corehttp.HostnameOption(),                      // 处理基于主机名的解析
+		ipfs_corehttp.GatewayOption(true, "/ipfs", "/ipns"), // 配置IPFS/IPNS路径
+		ipfs_corehttp.VersionOption(),                       // 添加版本信息头
+		ipfs_corehttp.CheckVersionOption(),                  // 检查客户端兼容性
+		ipfs_corehttp.CommandsROOption(im.commandCtx),       // 只读命令支持
 	)
+}
 
-	// 启动网关服务
-	return ipfs_corehttp.Serve(im.IpfsNode, l, opts...)
+// readOnlyMethodsOption只放行获取内容的请求（GET、HEAD和CORS预检的OPTIONS），其他方法返回405
+func readOnlyMethodsOption() ipfs_corehttp.ServeOption {
+	return func(_ *ipfs_core.IpfsNode, _ net.Listener, mux *http.ServeMux) (*http.ServeMux, error) {
+		childMux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				childMux.ServeHTTP(w, r)
+			default:
+				w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+				http.Error(w, "method not allowed on a read-only gateway", http.StatusMethodNotAllowed)
+			}
+		})
+		return childMux, nil
+	}
+}
+
+// HTTP服务器的超时：监听器限制了并发连接数，不发送请求头（或未完成TLS握手）和空闲的
+// keep-alive连接不能一直占用连接数
+const (
+	httpReadHeaderTimeout = 10 * time.Second
+	httpIdleTimeout       = 60 * time.Second
+)
+
+// newHTTPServer按顺序应用选项构建处理器，与corehttp.Serve使用的处理器相同
+func newHTTPServer(node *ipfs_core.IpfsNode, l net.Listener, opts ...ipfs_corehttp.ServeOption) (*http.Server, error) {
+	topMux := http.NewServeMux()
+	mux := topMux
+	for _, opt := range opts {
+		var err error
+		if mux, err = opt(node, l, mux); err != nil {
+			return nil, err
+		}
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// ServeMux不支持CONNECT请求，与corehttp相同直接返回
+		if r.Method == http.MethodConnect {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		topMux.ServeHTTP(w, r)
+	})
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: httpReadHeaderTimeout,
+		IdleTimeout:       httpIdleTimeout,
+	}, nil
 }
 
 // NewNode根据给定配置创建新的IPFS移动节点
@@ -144,6 +227,13 @@ func NewNode(ctx context.Context, cfg *IpfsConfig) (*IpfsMobile, error) {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	// 设置日志级别（仅在配置了级别时）
+	if cfg.LogLevel != "" {
+		if err := SetLogLevel(cfg.LogLevel); err != nil {
+			return nil, fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
 	// 构建IPFS节点配置
 	buildcfg := &ipfs_core.BuildCfg{
 		Online:                      true,                                                         // 节点处于在线模式
@@ -36,6 +36,9 @@ type RepoMobile struct {
 	// 仓库在文件系统中的路径
 	// 在移动环境中，这通常指向应用数据目录
 	Path string
+
+	// 私有网络的共享密钥，设置时覆盖仓库中的swarm.key（见SetSwarmKey）
+	swarmKey []byte
 }
 
 // NewRepoMobile创建一个新的移动平台仓库实例
@@ -54,6 +57,20 @@ func NewRepoMobile(path string, repo ipfs_repo.Repo) *RepoMobile {
 	}
 }
 
+// SetSwarmKey设置私有网络的共享密钥（swarm.key文件的内容），覆盖仓库中的swarm.key
+// kubo创建节点时通过SwarmKey读取密钥并构建私有网络，传入nil恢复使用仓库中的文件
+func (mr *RepoMobile) SetSwarmKey(key []byte) {
+	mr.swarmKey = key
+}
+
+// SwarmKey返回私有网络的共享密钥，优先使用SetSwarmKey设置的密钥
+func (mr *RepoMobile) SwarmKey() ([]byte, error) {
+	if mr.swarmKey != nil {
+		return mr.swarmKey, nil
+	}
+	return mr.Repo.SwarmKey()
+}
+
 // ApplyPatchs应用一系列配置补丁到仓库配置
 // 这允许以可组合的方式修改IPFS配置
 // 参数:
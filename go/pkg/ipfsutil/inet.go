@@ -1,6 +1,7 @@
 package ipfsutil
 
 import (
+	"fmt"
 	"net"
 	"sync"
 )
@@ -8,6 +9,9 @@ import (
 var (
 	muNetDriver     = sync.RWMutex{}
 	netdriver   Net = &inet{}
+
+	muBindInterface = sync.RWMutex{}
+	bindInterface   string
 )
 
 type Net interface {
@@ -23,6 +27,12 @@ type NetAddrs interface {
 	InterfaceAddrs() ([]net.Addr, error)
 }
 
+// NetInterfaceAddrs can be implemented by a Net driver able to list the
+// addresses of a single interface.
+type NetInterfaceAddrs interface {
+	InterfaceAddrsByName(name string) ([]net.Addr, error)
+}
+
 var _ Net = (*inet)(nil)
 
 type inet struct{}
@@ -47,3 +57,40 @@ func getNetDriver() (n Net) {
 	muNetDriver.RUnlock()
 	return
 }
+
+// SetBindInterface restricts the interfaces used for mDNS to the given one,
+// an empty name uses every multicast interface.
+func SetBindInterface(name string) {
+	muBindInterface.Lock()
+	bindInterface = name
+	muBindInterface.Unlock()
+}
+
+func getBindInterface() (name string) {
+	muBindInterface.RLock()
+	name = bindInterface
+	muBindInterface.RUnlock()
+	return
+}
+
+// InterfaceAddrsByName returns the addresses of the named interface, it fails
+// if the interface doesn't exist.
+func InterfaceAddrsByName(name string) ([]net.Addr, error) {
+	driver := getNetDriver()
+	if d, ok := driver.(NetInterfaceAddrs); ok {
+		return d.InterfaceAddrsByName(name)
+	}
+
+	ifaces, err := driver.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, iface := range ifaces {
+		if iface.Name == name {
+			return iface.Addrs()
+		}
+	}
+
+	return nil, fmt.Errorf("interface `%s` not found", name)
+}
@@ -26,7 +26,16 @@ const (
 
 var DiscoveryTimeout = time.Second * 30
 
-var _ p2p_mdns.Service = (*mdnsService)(nil)
+var _ MdnsService = (*mdnsService)(nil)
+
+// MdnsService is a p2p_mdns.Service whose advertisement can be toggled while
+// the discovery of other peers keeps running.
+type MdnsService interface {
+	p2p_mdns.Service
+
+	// SetAdvertise starts or stops announcing the local peer.
+	SetAdvertise(enabled bool) error
+}
 
 type mdnsService struct {
 	logger *zap.Logger
@@ -40,7 +49,11 @@ type mdnsService struct {
 	ctxCancel context.CancelFunc
 
 	resolverWG sync.WaitGroup
-	server     *zeroconf.Server
+
+	muServer  sync.Mutex
+	server    *zeroconf.Server
+	advertise bool
+	started   bool
 
 	notifee p2p_mdns.Notifee
 }
@@ -77,7 +90,7 @@ func (dh *discoveryHandler) HandlePeerFound(p peer.AddrInfo) {
 	}
 }
 
-func NewMdnsService(logger *zap.Logger, host host.Host, serviceName string, notifee p2p_mdns.Notifee) p2p_mdns.Service {
+func NewMdnsService(logger *zap.Logger, host host.Host, serviceName string, notifee p2p_mdns.Notifee) MdnsService {
 	if serviceName == "" {
 		serviceName = p2p_mdns.ServiceName
 	}
@@ -87,26 +100,61 @@ func NewMdnsService(logger *zap.Logger, host host.Host, serviceName string, noti
 		host:        host,
 		serviceName: serviceName,
 		// generate a random string between 32 and 63 characters long
-		peerName: randomString(32 + rand.Intn(32)), // nolint:gosec
-		notifee:  notifee,
+		peerName:  randomString(32 + rand.Intn(32)), // nolint:gosec
+		notifee:   notifee,
+		advertise: true,
 	}
 	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
 	return s
 }
 
 func (s *mdnsService) Start() error {
-	if err := s.startServer(); err != nil {
-		return err
+	s.muServer.Lock()
+	if s.advertise {
+		if err := s.startServer(); err != nil {
+			s.muServer.Unlock()
+			return err
+		}
 	}
+	s.started = true
+	s.muServer.Unlock()
+
 	s.startResolver(s.ctx)
 	return nil
 }
 
+func (s *mdnsService) SetAdvertise(enabled bool) error {
+	s.muServer.Lock()
+	defer s.muServer.Unlock()
+
+	s.advertise = enabled
+	if !s.started {
+		// will be applied on Start
+		return nil
+	}
+
+	switch {
+	case enabled && s.server == nil:
+		return s.startServer()
+	case !enabled && s.server != nil:
+		s.server.Shutdown()
+		s.server = nil
+	}
+
+	return nil
+}
+
 func (s *mdnsService) Close() error {
 	s.ctxCancel()
+
+	s.muServer.Lock()
 	if s.server != nil {
 		s.server.Shutdown()
+		s.server = nil
 	}
+	s.started = false
+	s.muServer.Unlock()
+
 	s.resolverWG.Wait()
 	return nil
 }
@@ -223,14 +271,12 @@ func (s *mdnsService) startResolver(ctx context.Context) {
 		}
 	}()
 	go func() {
-		// manually get interfaces list
-		ifaces, err := getNetDriver().Interfaces()
+		// manually get multicast interfaces list
+		ifaces, err := GetMulticastInterfaces()
 		if err != nil {
 			s.logger.Error("zeroconf failed to get device interfaces", zap.Error(err))
 			return
 		}
-		// filter Multicast interfaces
-		ifaces = filterMulticastInterfaces(ifaces)
 
 		defer s.resolverWG.Done()
 		if err := zeroconf.Browse(ctx, s.serviceName, mdnsDomain, entryChan, zeroconf.SelectIfaces(ifaces)); err != nil {
@@ -247,7 +293,19 @@ func GetMulticastInterfaces() ([]net.Interface, error) {
 	}
 
 	// filter Multicast interfaces
-	return filterMulticastInterfaces(ifaces), nil
+	ifaces = filterMulticastInterfaces(ifaces)
+
+	// restrict to the bind interface if any
+	if name := getBindInterface(); name != "" {
+		for _, iface := range ifaces {
+			if iface.Name == name {
+				return []net.Interface{iface}, nil
+			}
+		}
+		return []net.Interface{}, nil
+	}
+
+	return ifaces, nil
 }
 
 func randomString(l int) string {
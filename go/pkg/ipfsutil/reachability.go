@@ -0,0 +1,187 @@
+package ipfsutil
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+// reachabilityInjectBufferSize is the number of overridden reachability
+// events that can be queued for a subscriber.
+const reachabilityInjectBufferSize = 8
+
+var reachabilityEventType = reflect.TypeOf(new(event.EvtLocalReachabilityChanged))
+
+// ReachabilityHost wraps a host so the local reachability seen by the
+// subscribers of its event bus can be overridden. Given to a DHT running in
+// auto mode, it allows switching the DHT between client and server mode at
+// runtime, without affecting the other users of the wrapped host.
+type ReachabilityHost struct {
+	host.Host
+	bus *reachabilityBus
+}
+
+var _ host.Host = (*ReachabilityHost)(nil)
+
+func NewReachabilityHost(h host.Host) *ReachabilityHost {
+	return &ReachabilityHost{
+		Host: h,
+		bus:  &reachabilityBus{Bus: h.EventBus()},
+	}
+}
+
+func (h *ReachabilityHost) EventBus() event.Bus {
+	return h.bus
+}
+
+// SetReachability overrides the reachability reported to the subscribers,
+// network.ReachabilityUnknown restores the reachability detected by the host.
+func (h *ReachabilityHost) SetReachability(r network.Reachability) {
+	h.bus.setReachability(r)
+}
+
+// Reachability returns the overridden reachability, or
+// network.ReachabilityUnknown if not overridden.
+func (h *ReachabilityHost) Reachability() network.Reachability {
+	h.bus.mu.Lock()
+	defer h.bus.mu.Unlock()
+	return h.bus.forced
+}
+
+type reachabilityBus struct {
+	event.Bus
+
+	mu       sync.Mutex
+	forced   network.Reachability
+	detected network.Reachability
+	subs     map[*reachabilitySub]struct{}
+}
+
+func (b *reachabilityBus) Subscribe(eventType interface{}, opts ...event.SubscriptionOpt) (event.Subscription, error) {
+	sub, err := b.Bus.Subscribe(eventType, opts...)
+	if err != nil || !subscribesReachability(eventType) {
+		return sub, err
+	}
+
+	rs := &reachabilitySub{
+		Subscription: sub,
+		bus:          b,
+		out:          make(chan interface{}, cap(sub.Out())),
+		inject:       make(chan network.Reachability, reachabilityInjectBufferSize),
+		closed:       make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = make(map[*reachabilitySub]struct{})
+	}
+	b.subs[rs] = struct{}{}
+	if b.forced != network.ReachabilityUnknown {
+		rs.inject <- b.forced
+	}
+	b.mu.Unlock()
+
+	go rs.forward()
+	return rs, nil
+}
+
+func (b *reachabilityBus) setReachability(r network.Reachability) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.forced == r {
+		return
+	}
+	b.forced = r
+
+	// back to the reachability detected by the host
+	if r == network.ReachabilityUnknown {
+		r = b.detected
+	}
+
+	for rs := range b.subs {
+		select {
+		case rs.inject <- r:
+		default:
+		}
+	}
+}
+
+func subscribesReachability(eventType interface{}) bool {
+	switch t := eventType.(type) {
+	case []interface{}:
+		for _, e := range t {
+			if reflect.TypeOf(e) == reachabilityEventType {
+				return true
+			}
+		}
+		return false
+	default:
+		return reflect.TypeOf(t) == reachabilityEventType
+	}
+}
+
+type reachabilitySub struct {
+	event.Subscription
+
+	bus       *reachabilityBus
+	out       chan interface{}
+	inject    chan network.Reachability
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *reachabilitySub) Out() <-chan interface{} {
+	return s.out
+}
+
+func (s *reachabilitySub) Close() error {
+	s.closeOnce.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+
+		close(s.closed)
+	})
+	return s.Subscription.Close()
+}
+
+func (s *reachabilitySub) forward() {
+	defer close(s.out)
+
+	for {
+		var evt interface{}
+		select {
+		case <-s.closed:
+			return
+		case r := <-s.inject:
+			evt = event.EvtLocalReachabilityChanged{Reachability: r}
+		case e, ok := <-s.Subscription.Out():
+			if !ok {
+				return
+			}
+
+			if r, ok := e.(event.EvtLocalReachabilityChanged); ok {
+				s.bus.mu.Lock()
+				s.bus.detected = r.Reachability
+				forced := s.bus.forced
+				s.bus.mu.Unlock()
+
+				// masked by the override
+				if forced != network.ReachabilityUnknown {
+					continue
+				}
+			}
+			evt = e
+		}
+
+		select {
+		case s.out <- evt:
+		case <-s.closed:
+			return
+		}
+	}
+}
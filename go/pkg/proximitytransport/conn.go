@@ -6,6 +6,7 @@ import (
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
@@ -24,6 +25,15 @@ var _ manet.Conn = &Conn{}
 // result of calling the Dial or Listen functions in this
 // package, with associated local and remote Multiaddrs.
 type Conn struct {
+	// byte counters accessed atomically, kept first for their 64-bit
+	// alignment on 32-bit platforms
+	bytesSent     int64
+	bytesReceived int64
+
+	// connected is set to 1 once the connection is reported to the
+	// EventHandler of the transport
+	connected int32
+
 	readIn  *io.PipeWriter
 	readOut *io.PipeReader
 
@@ -73,11 +83,20 @@ func newConn(ctx context.Context, t *proximityTransport, remoteMa ma.Multiaddr,
 	maconn.mp.setOutput(pw)
 
 	// Returns an upgraded CapableConn (muxed, addr filtered, secured, etc...)
+	dir := network.DirOutbound
 	if inbound {
-		return t.upgrader.Upgrade(ctx, t, maconn, network.DirInbound, remotePID, network.NullScope)
+		dir = network.DirInbound
 	}
 
-	return t.upgrader.Upgrade(ctx, t, maconn, network.DirOutbound, remotePID, network.NullScope)
+	conn, err := t.upgrader.Upgrade(ctx, t, maconn, dir, remotePID, network.NullScope)
+	if err != nil {
+		t.notifyError(remotePID.String(), err)
+		return nil, err
+	}
+
+	atomic.StoreInt32(&maconn.connected, 1)
+	t.notifyConnected(remotePID.String())
+	return conn, nil
 }
 
 // Read reads data from the connection.
@@ -120,9 +139,13 @@ func (c *Conn) Write(payload []byte) (n int, err error) {
 	// Write to the peer's device using native driver.
 	if !c.transport.driver.SendToPeer(c.RemoteAddr().String(), payload) {
 		c.transport.logger.Error("Conn.Write failed")
-		return 0, fmt.Errorf("error: Conn.Write failed: native write failed")
+		err := fmt.Errorf("error: Conn.Write failed: native write failed")
+		c.transport.notifyError(c.RemoteAddr().String(), err)
+		return 0, err
 	}
 	c.transport.logger.Debug("Conn.Write successful")
+	atomic.AddInt64(&c.bytesSent, int64(len(payload)))
+	atomic.AddInt64(&c.transport.bytesSent, int64(len(payload)))
 
 	return len(payload), nil
 }
@@ -145,6 +168,11 @@ func (c *Conn) Close() error {
 	// Disconnect the driver
 	c.transport.driver.CloseConnWithPeer(c.RemoteAddr().String())
 
+	// Only reports the first close of a connection reported as connected
+	if atomic.CompareAndSwapInt32(&c.connected, 1, 0) {
+		c.transport.notifyDisconnected(c.RemoteAddr().String())
+	}
+
 	return nil
 }
 
@@ -0,0 +1,58 @@
+package proximitytransport
+
+import (
+	"fmt"
+
+	peer "github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// NewProtocol returns the multiaddr protocol of a proximity transport, its
+// value is the peer ID of the device: /<name>/<peerID>.
+func NewProtocol(name string, code int) ma.Protocol {
+	return ma.Protocol{
+		Name:       name,
+		Code:       code,
+		VCode:      ma.CodeToVarint(code),
+		Size:       -1,
+		Path:       false,
+		Transcoder: ma.NewTranscoderFromFunctions(peerIDStB, peerIDBtS, peerIDVal),
+	}
+}
+
+// RegisterProtocol registers the multiaddr protocol of a proximity transport
+// so its addresses can be parsed. Registering the same protocol twice is a
+// noop, it fails if the name or the code is used by another protocol.
+func RegisterProtocol(name string, code int) error {
+	byName, byCode := ma.ProtocolWithName(name), ma.ProtocolWithCode(code)
+	if byName.Name == name && byName.Code == code {
+		return nil
+	}
+	if byName.Code != 0 {
+		return fmt.Errorf("multiaddr protocol name `%s` is already used by code %d", name, byName.Code)
+	}
+	if byCode.Code != 0 {
+		return fmt.Errorf("multiaddr protocol code %d is already used by `%s`", code, byCode.Name)
+	}
+
+	return ma.AddProtocol(NewProtocol(name, code))
+}
+
+func peerIDStB(s string) ([]byte, error) {
+	if _, err := peer.Decode(s); err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+func peerIDBtS(b []byte) (string, error) {
+	if _, err := peer.Decode(string(b)); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func peerIDVal(b []byte) error {
+	_, err := peer.Decode(string(b))
+	return err
+}
@@ -0,0 +1,103 @@
+package proximitytransport
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// EventHandler is notified of the connections of a proximity transport, see
+// WithEventHandler. Its methods are called from the transport and native
+// driver goroutines and must return quickly.
+type EventHandler interface {
+	// OnConnected is called once the libp2p connection with the peer is
+	// upgraded.
+	OnConnected(remotePID string)
+
+	// OnDisconnected is called when a connection reported by OnConnected
+	// is closed.
+	OnDisconnected(remotePID string)
+
+	// OnError is called when a connection with the peer fails.
+	OnError(remotePID string, err error)
+}
+
+// Option configures a proximity transport created by NewTransport.
+type Option func(t *proximityTransport)
+
+// WithEventHandler sets the handler notified of the connections of the
+// transport.
+func WithEventHandler(handler EventHandler) Option {
+	return func(t *proximityTransport) {
+		t.events = handler
+	}
+}
+
+// ConnStats describes an active connection of a proximity transport.
+type ConnStats struct {
+	RemotePID     string
+	BytesSent     int64
+	BytesReceived int64
+	// MTU is the last MTU reported by the native driver for the peer, 0 when
+	// unknown.
+	MTU int
+}
+
+// TransportStats describes the activity of a proximity transport.
+type TransportStats struct {
+	Conns []ConnStats
+	// BytesSent and BytesReceived count the bytes exchanged with all the
+	// peers since the transport was created.
+	BytesSent     int64
+	BytesReceived int64
+}
+
+// Stats returns the active connections of the transport, sorted by peer id,
+// and its byte counters.
+func (t *proximityTransport) Stats() TransportStats {
+	stats := TransportStats{
+		BytesSent:     atomic.LoadInt64(&t.bytesSent),
+		BytesReceived: atomic.LoadInt64(&t.bytesReceived),
+	}
+
+	t.connMapMutex.RLock()
+	for remotePID, c := range t.connMap {
+		stats.Conns = append(stats.Conns, ConnStats{
+			RemotePID:     remotePID,
+			BytesSent:     atomic.LoadInt64(&c.bytesSent),
+			BytesReceived: atomic.LoadInt64(&c.bytesReceived),
+			MTU:           t.mtuMap[remotePID],
+		})
+	}
+	t.connMapMutex.RUnlock()
+
+	sort.Slice(stats.Conns, func(i, j int) bool {
+		return stats.Conns[i].RemotePID < stats.Conns[j].RemotePID
+	})
+	return stats
+}
+
+// SetPeerMTU is called by the native driver when the MTU negotiated with the
+// peer's device is known or changes.
+func (t *proximityTransport) SetPeerMTU(remotePID string, mtu int) {
+	t.connMapMutex.Lock()
+	t.mtuMap[remotePID] = mtu
+	t.connMapMutex.Unlock()
+}
+
+func (t *proximityTransport) notifyConnected(remotePID string) {
+	if t.events != nil {
+		t.events.OnConnected(remotePID)
+	}
+}
+
+func (t *proximityTransport) notifyDisconnected(remotePID string) {
+	if t.events != nil {
+		t.events.OnDisconnected(remotePID)
+	}
+}
+
+func (t *proximityTransport) notifyError(remotePID string, err error) {
+	if t.events != nil {
+		t.events.OnError(remotePID, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	host "github.com/libp2p/go-libp2p/core/host"
 	peer "github.com/libp2p/go-libp2p/core/peer"
@@ -44,13 +45,20 @@ type ProximityTransport interface {
 	HandleLostPeer(remotePID string)
 	ReceiveFromPeer(remotePID string, payload []byte)
 	Log(level int, message string)
+	SetPeerMTU(remotePID string, mtu int)
 }
 
 type proximityTransport struct {
+	// byte counters of all the connections, accessed atomically and kept
+	// first for their 64-bit alignment on 32-bit platforms
+	bytesSent     int64
+	bytesReceived int64
+
 	host     host.Host
 	upgrader tpt.Upgrader
 
 	connMap      map[string]*Conn
+	mtuMap       map[string]int // guarded by connMapMutex
 	connMapMutex sync.RWMutex
 	cache        *RingBufferMap
 	lock         sync.RWMutex
@@ -58,9 +66,10 @@ type proximityTransport struct {
 	driver       ProximityDriver
 	logger       *zap.Logger
 	ctx          context.Context
+	events       EventHandler
 }
 
-func NewTransport(ctx context.Context, l *zap.Logger, driver ProximityDriver) func(h host.Host, u tpt.Upgrader) (*proximityTransport, error) {
+func NewTransport(ctx context.Context, l *zap.Logger, driver ProximityDriver, opts ...Option) func(h host.Host, u tpt.Upgrader) (*proximityTransport, error) {
 	if l == nil {
 		l = zap.NewNop()
 	}
@@ -78,11 +87,15 @@ func NewTransport(ctx context.Context, l *zap.Logger, driver ProximityDriver) fu
 			host:     h,
 			upgrader: u,
 			connMap:  make(map[string]*Conn),
+			mtuMap:   make(map[string]int),
 			cache:    NewRingBufferMap(l, 128),
 			driver:   driver,
 			logger:   l,
 			ctx:      ctx,
 		}
+		for _, opt := range opts {
+			opt(transport)
+		}
 
 		return transport, nil
 	}
@@ -184,11 +197,14 @@ func (t *proximityTransport) ReceiveFromPeer(remotePID string, payload []byte) {
 	// copy value from driver
 	data := make([]byte, len(payload))
 	copy(data, payload)
+	atomic.AddInt64(&t.bytesReceived, int64(len(data)))
 
 	t.connMapMutex.RLock()
 	c, ok := t.connMap[remotePID]
 	t.connMapMutex.RUnlock()
 	if ok {
+		atomic.AddInt64(&c.bytesReceived, int64(len(data)))
+
 		// Put payload in the Conn cache if libp2p connection is not ready
 		if !c.isReady() {
 			c.Lock()
@@ -260,6 +276,7 @@ func (t *proximityTransport) HandleFoundPeer(sRemotePID string) bool {
 			})
 			if err != nil {
 				t.logger.Error("HandleFoundPeer: async connect error", zap.Error(err))
+				t.notifyError(sRemotePID, err)
 				t.host.Peerstore().SetAddr(remotePID, remoteMa, -1)
 				t.driver.CloseConnWithPeer(sRemotePID)
 			}
@@ -301,6 +318,10 @@ func (t *proximityTransport) HandleLostPeer(sRemotePID string) {
 	// Remove peer's address to peerstore.
 	t.host.Peerstore().SetAddr(remotePID, remoteMa, -1)
 
+	t.connMapMutex.Lock()
+	delete(t.mtuMap, sRemotePID)
+	t.connMapMutex.Unlock()
+
 	// Close the peer connection
 	conns := t.host.Network().ConnsToPeer(remotePID)
 	for _, conn := range conns {
@@ -85,12 +85,12 @@ public class Node {
     public func serveGateway(onMultiaddr: String, writable: Bool = false) throws -> String {
         var err: NSError?
 
-        let maddr = self.node.serveGatewayMultiaddr(onMultiaddr, writable: writable, error: &err)
+        let listener = self.node.serveGatewayMultiaddr(onMultiaddr, writable: writable, error: &err)
         if err != nil {
             throw NodeError("unable to serve gateway on \(onMultiaddr)", err)
         }
 
-        return maddr
+        return listener!.multiaddr()
     }
 
     /// Serves any multiaddr (api & gateway) inside `Addresses.Api` and